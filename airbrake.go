@@ -0,0 +1,147 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// defaultAirbrakeURL is the Airbrake API.
+const defaultAirbrakeURL = "https://api.airbrake.io"
+
+// AirbrakeOptions configures an AirbrakeSink.
+type AirbrakeOptions struct {
+	// ProjectID is the id of the project.
+	ProjectID int64
+	// ProjectKey is the API key of the project.
+	ProjectKey string
+	// Environment is the environment the notices are reported in, like "production". Default is blank.
+	Environment string
+	// BaseURL is the API endpoint, like "https://errbit.example.com" for a self-hosted Errbit. Default is "https://api.airbrake.io".
+	BaseURL string
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// airbrakeNotice is the payload of an Airbrake notice, in the v3 API Errbit speaks too.
+type airbrakeNotice struct {
+	Errors  []airbrakeError   `json:"errors"`
+	Context airbrakeContext   `json:"context"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+type airbrakeError struct {
+	Type      string          `json:"type"`
+	Message   string          `json:"message"`
+	Backtrace []airbrakeFrame `json:"backtrace"`
+}
+
+type airbrakeFrame struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+type airbrakeContext struct {
+	Notifier    airbrakeNotifier `json:"notifier"`
+	Environment string           `json:"environment,omitempty"`
+	Hostname    string           `json:"hostname"`
+	OS          string           `json:"os"`
+	Language    string           `json:"language"`
+	Severity    string           `json:"severity"`
+	URL         string           `json:"url,omitempty"`
+	HTTPMethod  string           `json:"httpMethod,omitempty"`
+	Route       string           `json:"route,omitempty"`
+	UserAddr    string           `json:"userAddr,omitempty"`
+}
+
+type airbrakeNotifier struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+// airbrakeFormatter renders a panic as an Airbrake notice.
+type airbrakeFormatter struct {
+	environment string
+	host        string
+}
+
+func (f airbrakeFormatter) Format(rec *PanicRecord) []byte {
+	// Airbrake lists the innermost call first, like the frames are.
+	backtrace := make([]airbrakeFrame, 0, len(rec.Frames))
+	for _, frame := range rec.Frames {
+		backtrace = append(backtrace, airbrakeFrame{File: frame.File, Line: frame.Line, Function: frame.Func})
+	}
+
+	notice := airbrakeNotice{
+		Errors: []airbrakeError{{
+			Type:      fmt.Sprintf("%T", rec.Value),
+			Message:   rec.Panic,
+			Backtrace: backtrace,
+		}},
+		Context: airbrakeContext{
+			Notifier: airbrakeNotifier{
+				Name:    "unrolled/recovery",
+				Version: "1",
+				URL:     "https://github.com/unrolled/recovery",
+			},
+			Environment: f.environment,
+			Hostname:    f.host,
+			OS:          runtime.GOOS + "/" + runtime.GOARCH,
+			Language:    "go/" + strings.TrimPrefix(runtime.Version(), "go"),
+			Severity:    airbrakeSeverity(rec.Severity),
+			HTTPMethod:  rec.Method,
+			Route:       rec.Route,
+			UserAddr:    remoteIP(rec.RemoteAddr),
+		},
+		Params: map[string]string{},
+	}
+
+	notice.Context.URL = rec.URL
+	if len(notice.Context.URL) == 0 {
+		notice.Context.URL = rec.Path
+	}
+
+	for name, value := range map[string]string{
+		"request_id":  rec.RequestID,
+		"fingerprint": rec.Fingerprint,
+		"origin":      rec.Origin,
+		"body":        string(rec.Body),
+	} {
+		if len(value) > 0 {
+			notice.Params[name] = value
+		}
+	}
+	for name, value := range rec.Headers {
+		notice.Params["header_"+name] = value
+	}
+
+	return jsonPayload(notice)
+}
+
+// airbrakeSeverity maps a Severity to an Airbrake severity.
+func airbrakeSeverity(s Severity) string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "error"
+	}
+}
+
+// AirbrakeSink returns a Sink that reports each panic to Airbrake as a notice, with the backtrace of the goroutine that panicked and the request. Every post happens as the panic is logged, so consider AsyncLogging.
+func AirbrakeSink(opt AirbrakeOptions) Sink {
+	// Errbit only takes the key from the query string.
+	endpoint := apiEndpoint(opt.BaseURL, defaultAirbrakeURL, fmt.Sprintf("/api/v3/projects/%d/notices?key=%s", opt.ProjectID, url.QueryEscape(opt.ProjectKey)))
+
+	return Sink{Out: newPostWriter(opt.Client, endpoint, "application/json", "Airbrake"), Formatter: airbrakeFormatter{opt.Environment, hostname()}}
+}
@@ -0,0 +1,61 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAirbrakeSink(t *testing.T) {
+	notices := make(chan []byte, 1)
+	var uri string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		uri = req.URL.RequestURI()
+		b, _ := io.ReadAll(req.Body)
+		notices <- b
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	r := New(Options{
+		Sinks:            []Sink{AirbrakeSink(AirbrakeOptions{ProjectID: 42, ProjectKey: "sec ret", Environment: "staging", BaseURL: srv.URL + "/", Client: srv.Client()})},
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var notice airbrakeNotice
+	if err := json.Unmarshal(<-notices, &notice); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, uri, "/api/v3/projects/42/notices?key=sec+ret")
+
+	expect(t, len(notice.Errors), 1)
+	expect(t, notice.Errors[0].Type, "string")
+	expect(t, notice.Errors[0].Message, "this did not work")
+	expect(t, notice.Context.Environment, "staging")
+	expect(t, notice.Context.Severity, "error")
+	expect(t, notice.Context.HTTPMethod, "GET")
+	expect(t, notice.Context.URL, "/foo")
+	expect(t, notice.Params["request_id"], "abc123")
+
+	var found bool
+	for _, frame := range notice.Errors[0].Backtrace {
+		if strings.HasSuffix(frame.File, "recovery_test.go") {
+			found = true
+			break
+		}
+	}
+	expect(t, found, true)
+}
+
+func TestAirbrakeSinkHost(t *testing.T) {
+	sink := AirbrakeSink(AirbrakeOptions{ProjectID: 1, ProjectKey: "key"})
+	expect(t, sink.Out.(*postWriter).endpoint, "https://api.airbrake.io/api/v3/projects/1/notices?key=key")
+}
@@ -0,0 +1,149 @@
+package recovery
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// asyncQueue runs log writes on a background goroutine, in order.
+type asyncQueue struct {
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan func()
+	done   chan struct{}
+}
+
+func newAsyncQueue(size int) *asyncQueue {
+	q := &asyncQueue{
+		jobs: make(chan func(), size),
+		done: make(chan struct{}),
+	}
+	go q.run()
+
+	return q
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	for job := range q.jobs {
+		job()
+	}
+}
+
+// enqueue queues a job without blocking. It returns false when the queue is closed, in which case the job did not run.
+func (q *asyncQueue) enqueue(job func()) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return false
+	}
+
+	select {
+	case q.jobs <- job:
+	default:
+		// The sink cannot keep up. Dropping the record beats stalling the request goroutine.
+	}
+
+	return true
+}
+
+// offer queues a job without blocking, like enqueue, but reports whether it was queued, so a job is never dropped unnoticed when the queue is full.
+func (q *asyncQueue) offer(job func()) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return false
+	}
+
+	select {
+	case q.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// flush blocks until every job queued so far has run.
+func (q *asyncQueue) flush() {
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return
+	}
+
+	flushed := make(chan struct{})
+	q.jobs <- func() { close(flushed) }
+	q.mu.RUnlock()
+
+	<-flushed
+}
+
+// close stops accepting jobs and waits for the queued ones to run.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+// emit runs a log write, on the background goroutine with AsyncLogging. Once the queue is closed, writes happen right away again.
+func (r *Recovery) emit(job func()) {
+	if r.async == nil || !r.async.enqueue(job) {
+		job()
+	}
+}
+
+// Flush blocks until every panic record queued by AsyncLogging has been written, the retries of the sinks posting records are done, and so are the profiles being written.
+func (r *Recovery) Flush() {
+	r.waitProfiles()
+	if r.async != nil {
+		r.async.flush()
+	}
+	for _, w := range r.posters {
+		w.flush()
+	}
+}
+
+// Close writes out the aggregated records pending with DedupWindow and the panic records queued by AsyncLogging, waits for the retries of the sinks posting records and the profiles being written, and stops their background goroutines. The outputs of the sinks that are an io.Closer, like a RotatingFile or the Out of an EmailSink, are closed last, once nothing is left to write to them; the main output, os.Stdout, and os.Stderr are left open. Panics recovered afterwards are logged synchronously. It returns the first error closing an output.
+func (r *Recovery) Close() error {
+	r.waitProfiles()
+	if r.deduper != nil {
+		r.deduper.close()
+	}
+
+	if r.async != nil {
+		r.async.close()
+	}
+	for _, w := range r.posters {
+		w.close()
+	}
+
+	return r.closeOutputs()
+}
+
+// closeOutputs closes the outputs of the sinks that are an io.Closer. Sinks sharing an output share its lock, so each is closed once.
+func (r *Recovery) closeOutputs() error {
+	var err error
+	closed := map[*sync.Mutex]bool{&r.outMu: true}
+	for _, s := range r.sinks {
+		c, ok := s.out.(io.Closer)
+		if !ok || closed[s.mu] || s.out == os.Stdout || s.out == os.Stderr {
+			continue
+		}
+		closed[s.mu] = true
+
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,146 @@
+package recovery
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// blockingWriter holds every write until it is released, like a stalled network sink.
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (bw *blockingWriter) Write(b []byte) (int, error) {
+	<-bw.release
+
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.buf.Write(b)
+}
+
+func (bw *blockingWriter) String() string {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.buf.String()
+}
+
+func TestAsyncLoggingDoesNotBlock(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	r := New(Options{
+		Out:          out,
+		AsyncLogging: true,
+	})
+	defer r.Close()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, out.String(), "")
+
+	close(out.release)
+	r.Flush()
+
+	expectContainsTrue(t, out.String(), "Recovering from Panic: this did not work")
+}
+
+func TestAsyncLoggingClose(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:          buf,
+		AsyncLogging: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+	expect(t, r.Close(), nil)
+	expect(t, bytes.Count(buf.Bytes(), []byte("Recovering from Panic")), 1)
+
+	// After Close, records are written synchronously.
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expect(t, bytes.Count(buf.Bytes(), []byte("Recovering from Panic")), 2)
+
+	expect(t, r.Close(), nil)
+	r.Flush()
+}
+
+func TestAsyncLoggingDropsWhenFull(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	r := New(Options{
+		Out:            out,
+		AsyncLogging:   true,
+		AsyncQueueSize: 1,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	for i := 0; i < 10; i++ {
+		r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	close(out.release)
+	r.Close()
+
+	// One record may be in the writer and one in the queue, the rest is dropped.
+	count := bytes.Count([]byte(out.String()), []byte("Recovering from Panic"))
+	if count < 1 || count > 2 {
+		t.Errorf("Expected 1 or 2 records - Got %d", count)
+	}
+}
+
+func TestFlushWithoutAsyncLogging(t *testing.T) {
+	r := New()
+	r.Flush()
+	expect(t, r.Close(), nil)
+}
+
+// closeCounter counts how often it is closed.
+type closeCounter struct {
+	bytes.Buffer
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestCloseClosesOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.log")
+	file, err := NewRotatingFile(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, main := &closeCounter{}, &closeCounter{}
+
+	r := New(Options{
+		Out: main,
+		Sinks: []Sink{
+			{Format: FormatText},
+			{Out: file, Format: FormatJSON},
+			{Out: shared, Format: FormatText},
+			{Out: shared, Format: FormatJSON},
+			{Out: main, Format: FormatJSON},
+		},
+		AsyncLogging: true,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expect(t, r.Close(), nil)
+
+	// The queued record was written before the outputs were closed, each of them once, and the main output is left open.
+	expectContainsTrue(t, readFile(t, path), `"panic":"this did not work"`)
+	_, err = file.Write([]byte("late\n"))
+	expect(t, err, os.ErrClosed)
+	expect(t, shared.closed, 1)
+	expect(t, main.closed, 0)
+}
@@ -0,0 +1,44 @@
+package recovery
+
+import (
+	"io"
+	"mime"
+	"net/http"
+)
+
+// bodyCapture tees up to limit bytes of a request body as the handler reads it.
+type bodyCapture struct {
+	io.ReadCloser
+	limit int
+	buf   []byte
+}
+
+func (c *bodyCapture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if room := c.limit - len(c.buf); room > 0 && n > 0 {
+		if n < room {
+			room = n
+		}
+		c.buf = append(c.buf, p[:room]...)
+	}
+
+	return n, err
+}
+
+// capturedBody returns the scrubbed part of the body of req read so far, or nil without a capture. A form has the values of its ScrubParams redacted, like a query.
+func (r *Recovery) capturedBody(req *http.Request, c *bodyCapture) []byte {
+	if c == nil || len(c.buf) == 0 {
+		return nil
+	}
+	if isForm(req) {
+		return []byte(r.scrubQuery(string(c.buf)))
+	}
+
+	return []byte(r.scrubText(string(c.buf)))
+}
+
+// isForm reports whether the body of req is form-urlencoded.
+func isForm(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
@@ -0,0 +1,107 @@
+package recovery
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var decodingPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	b, _ := ioutil.ReadAll(r.Body)
+	panic("cannot decode a " + string(b[:1]))
+})
+
+func TestCaptureBody(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:              buf,
+		OutputFlags:      -1,
+		CaptureBodyBytes: 10,
+	})
+
+	var body []byte
+	r.OnPanic(func(req *http.Request, v interface{}, stack []byte) {
+		info, _ := FromContext(req.Context())
+		body = info.Body
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", strings.NewReader("{\"items\": [1, 2, 3]}"))
+	r.Handler(decodingPanicHandler).ServeHTTP(res, req)
+
+	expect(t, string(body), "{\"items\": ")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: cannot decode a {\nBody: \"{\\\"items\\\": \"\ngoroutine ")
+}
+
+func TestCaptureBodyScrubsForm(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:              buf,
+		Format:           FormatJSON,
+		CaptureBodyBytes: 1024,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/login", strings.NewReader("user=bob&password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	r.Handler(decodingPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), `"body":"user=bob\u0026password=[REDACTED]"`)
+	expectContainsFalse(t, buf.String(), "hunter2")
+}
+
+func TestCaptureBodyOnlyWhatWasRead(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:              buf,
+		Format:           FormatJSON,
+		CaptureBodyBytes: 1024,
+	})
+
+	partialPanicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadFull(r.Body, make([]byte, 5))
+		panic("bad payload")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", strings.NewReader("hello world"))
+	r.Handler(partialPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), `"body":"hello"`)
+}
+
+func TestCaptureBodyScrubbed(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:              buf,
+		Format:           FormatLogfmt,
+		CaptureBodyBytes: 1024,
+		ScrubPatterns:    []*regexp.Regexp{EmailPattern},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/signup", strings.NewReader(`{"email": "jane@example.com"}`))
+	r.Handler(decodingPanicHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "example.com")
+	expectContainsTrue(t, buf.String(), `body="{\"email\": \"[REDACTED]\"}"`)
+}
+
+func TestCaptureBodyOff(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:    buf,
+		Format: FormatJSON,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", strings.NewReader("hello world"))
+	r.Handler(decodingPanicHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), `"body"`)
+}
@@ -0,0 +1,187 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// defaultBugsnagURL is the Bugsnag Error Reporting API.
+const defaultBugsnagURL = "https://notify.bugsnag.com"
+
+// BugsnagOptions configures a BugsnagSink.
+type BugsnagOptions struct {
+	// APIKey is the notifier API key of the project.
+	APIKey string
+	// ReleaseStage is the stage the events are reported in, like "production". Default is blank.
+	ReleaseStage string
+	// BaseURL is the notify endpoint, like "https://bugsnag.example.com:49000" for an on-premise install. Default is "https://notify.bugsnag.com".
+	BaseURL string
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// bugsnagPayload is the payload of a Bugsnag notification, version 5.
+type bugsnagPayload struct {
+	APIKey         string          `json:"apiKey"`
+	PayloadVersion string          `json:"payloadVersion"`
+	Notifier       bugsnagNotifier `json:"notifier"`
+	Events         []bugsnagEvent  `json:"events"`
+}
+
+type bugsnagNotifier struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+type bugsnagEvent struct {
+	Exceptions     []bugsnagException                `json:"exceptions"`
+	Context        string                            `json:"context,omitempty"`
+	GroupingHash   string                            `json:"groupingHash,omitempty"`
+	Unhandled      bool                              `json:"unhandled"`
+	Severity       string                            `json:"severity"`
+	SeverityReason bugsnagSeverityReason             `json:"severityReason"`
+	App            bugsnagApp                        `json:"app"`
+	Device         bugsnagDevice                     `json:"device"`
+	Request        *bugsnagRequest                   `json:"request,omitempty"`
+	MetaData       map[string]map[string]interface{} `json:"metaData,omitempty"`
+}
+
+type bugsnagException struct {
+	ErrorClass string         `json:"errorClass"`
+	Message    string         `json:"message"`
+	Stacktrace []bugsnagFrame `json:"stacktrace"`
+	Type       string         `json:"type"`
+}
+
+type bugsnagFrame struct {
+	File       string `json:"file"`
+	LineNumber int    `json:"lineNumber"`
+	Method     string `json:"method"`
+	InProject  bool   `json:"inProject,omitempty"`
+}
+
+type bugsnagSeverityReason struct {
+	Type string `json:"type"`
+}
+
+type bugsnagApp struct {
+	ReleaseStage string `json:"releaseStage,omitempty"`
+}
+
+type bugsnagDevice struct {
+	Hostname string `json:"hostname"`
+	Time     string `json:"time"`
+}
+
+type bugsnagRequest struct {
+	ClientIP   string            `json:"clientIp,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	HTTPMethod string            `json:"httpMethod"`
+	URL        string            `json:"url"`
+}
+
+// bugsnagFormatter renders a panic as a Bugsnag notification.
+type bugsnagFormatter struct {
+	apiKey       string
+	releaseStage string
+	host         string
+}
+
+func (f bugsnagFormatter) Format(rec *PanicRecord) []byte {
+	// Bugsnag lists the innermost call first, like the frames are.
+	stacktrace := make([]bugsnagFrame, 0, len(rec.Frames))
+	for _, frame := range rec.Frames {
+		stacktrace = append(stacktrace, bugsnagFrame{
+			File:       frame.File,
+			LineNumber: frame.Line,
+			Method:     frame.Func,
+			InProject:  DefaultFrameFilter(frame),
+		})
+	}
+
+	event := bugsnagEvent{
+		Exceptions: []bugsnagException{{
+			ErrorClass: fmt.Sprintf("%T", rec.Value),
+			Message:    rec.Panic,
+			Stacktrace: stacktrace,
+			Type:       "go",
+		}},
+		GroupingHash:   rec.Fingerprint,
+		Unhandled:      true,
+		Severity:       bugsnagSeverity(rec.Severity),
+		SeverityReason: bugsnagSeverityReason{Type: "unhandledPanic"},
+		App:            bugsnagApp{ReleaseStage: f.releaseStage},
+		Device:         bugsnagDevice{Hostname: f.host, Time: rec.Time.UTC().Format(time.RFC3339)},
+		MetaData:       map[string]map[string]interface{}{},
+	}
+
+	if len(rec.Method) > 0 {
+		url := rec.URL
+		if len(url) == 0 {
+			url = rec.Path
+		}
+		event.Context = rec.Method + " " + rec.Path
+		event.Request = &bugsnagRequest{
+			ClientIP:   remoteIP(rec.RemoteAddr),
+			Headers:    rec.Headers,
+			HTTPMethod: rec.Method,
+			URL:        url,
+		}
+	}
+
+	// The request tab shows the metadata of the request section alongside the request.
+	request := map[string]interface{}{}
+	for name, value := range map[string]string{
+		"requestId": rec.RequestID,
+		"route":     rec.Route,
+		"traceId":   rec.TraceID,
+		"body":      string(rec.Body),
+	} {
+		if len(value) > 0 {
+			request[name] = value
+		}
+	}
+	if rec.Status > 0 {
+		request["status"] = rec.Status
+	}
+	if len(request) > 0 {
+		event.MetaData["request"] = request
+	}
+	if len(rec.Origin) > 0 {
+		event.MetaData["panic"] = map[string]interface{}{"origin": rec.Origin}
+	}
+
+	return jsonPayload(bugsnagPayload{
+		APIKey:         f.apiKey,
+		PayloadVersion: "5",
+		Notifier: bugsnagNotifier{
+			Name:    "unrolled/recovery",
+			Version: "1",
+			URL:     "https://github.com/unrolled/recovery",
+		},
+		Events: []bugsnagEvent{event},
+	})
+}
+
+// bugsnagSeverity maps a Severity to one of the three Bugsnag severities.
+func bugsnagSeverity(s Severity) string {
+	switch s {
+	case SeverityDebug, SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// BugsnagSink returns a Sink that reports each panic to Bugsnag as an unhandled event, with the panic type as its error class, the stack frames, and the request, grouped by the panic fingerprint. Every post happens as the panic is logged, so consider AsyncLogging.
+func BugsnagSink(opt BugsnagOptions) Sink {
+	w := newPostWriter(opt.Client, apiEndpoint(opt.BaseURL, defaultBugsnagURL, "/"), "application/json", "Bugsnag")
+	w.header.Set("Bugsnag-Api-Key", opt.APIKey)
+	w.header.Set("Bugsnag-Payload-Version", "5")
+
+	return Sink{Out: w, Formatter: bugsnagFormatter{opt.APIKey, opt.ReleaseStage, hostname()}}
+}
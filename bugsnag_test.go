@@ -0,0 +1,72 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBugsnagSink(t *testing.T) {
+	payloads := make(chan []byte, 1)
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header
+		b, _ := io.ReadAll(req.Body)
+		payloads <- b
+	}))
+	defer srv.Close()
+
+	sink := BugsnagSink(BugsnagOptions{APIKey: "secret", ReleaseStage: "staging", BaseURL: srv.URL, Client: srv.Client()})
+
+	r := New(Options{
+		Sinks:            []Sink{sink},
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var payload bugsnagPayload
+	if err := json.Unmarshal(<-payloads, &payload); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, header.Get("Bugsnag-Api-Key"), "secret")
+	expect(t, header.Get("Bugsnag-Payload-Version"), "5")
+	expect(t, payload.APIKey, "secret")
+	expect(t, len(payload.Events), 1)
+
+	event := payload.Events[0]
+	expect(t, event.Severity, "error")
+	expect(t, event.Unhandled, true)
+	expect(t, event.Context, "GET /foo")
+	expect(t, len(event.GroupingHash) > 0, true)
+	expect(t, event.App.ReleaseStage, "staging")
+	expect(t, event.Request.HTTPMethod, "GET")
+	expect(t, event.MetaData["request"]["requestId"], "abc123")
+	expect(t, event.MetaData["request"]["status"], float64(http.StatusInternalServerError))
+
+	exception := event.Exceptions[0]
+	expect(t, exception.ErrorClass, "string")
+	expect(t, exception.Message, "this did not work")
+
+	// The first frame in the project is the handler that panicked.
+	for _, frame := range exception.Stacktrace {
+		if frame.InProject {
+			expect(t, strings.HasSuffix(frame.File, "recovery_test.go"), true)
+			break
+		}
+	}
+}
+
+func TestBugsnagSeverity(t *testing.T) {
+	expect(t, bugsnagSeverity(SeverityDebug), "info")
+	expect(t, bugsnagSeverity(SeverityInfo), "info")
+	expect(t, bugsnagSeverity(SeverityWarning), "warning")
+	expect(t, bugsnagSeverity(SeverityError), "error")
+	expect(t, bugsnagSeverity(SeverityCritical), "error")
+}
@@ -0,0 +1,52 @@
+package recovery
+
+import (
+	"net/http"
+	"time"
+)
+
+// cloudEventsContentType is the media type of a CloudEvent in the JSON structured mode.
+const cloudEventsContentType = "application/cloudevents+json"
+
+// cloudEvent is the shape of a FormatCloudEvents event.
+type cloudEvent struct {
+	SpecVersion     string     `json:"specversion"`
+	ID              string     `json:"id"`
+	Source          string     `json:"source"`
+	Type            string     `json:"type"`
+	Subject         string     `json:"subject,omitempty"`
+	Time            string     `json:"time"`
+	DataContentType string     `json:"datacontenttype"`
+	Data            jsonRecord `json:"data"`
+}
+
+// cloudEventsFormatter renders a panic as a newline terminated CloudEvents 1.0 event.
+type cloudEventsFormatter struct {
+	r *Recovery
+}
+
+func (f cloudEventsFormatter) Format(rec *PanicRecord) []byte {
+	event := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              newRequestID(),
+		Source:          f.r.opt.CloudEventsSource,
+		Type:            f.r.opt.CloudEventsType,
+		Subject:         rec.Path,
+		Time:            rec.Time.UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            f.r.newJSONRecord(rec),
+	}
+
+	return jsonPayload(event)
+}
+
+// CloudEventsSinkOptions configures a CloudEventsSink.
+type CloudEventsSinkOptions struct {
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// CloudEventsSink returns a Sink that posts each panic record to an HTTP endpoint as a FormatCloudEvents event, in the JSON structured mode. Every post happens as the panic is logged, so consider AsyncLogging.
+func CloudEventsSink(endpoint string, opt CloudEventsSinkOptions) Sink {
+	return Sink{Out: newPostWriter(opt.Client, endpoint, cloudEventsContentType, "CloudEvents"), Format: FormatCloudEvents}
+}
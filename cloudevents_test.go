@@ -0,0 +1,90 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFormatCloudEvents(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Prefix: "testapp",
+		Out:    buf,
+		Format: FormatCloudEvents,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var event cloudEvent
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("Expected a single CloudEvent, got error %v for [%s]", err, buf.String())
+	}
+
+	expect(t, event.SpecVersion, "1.0")
+	expect(t, len(event.ID), 32)
+	expect(t, event.Source, "/testapp")
+	expect(t, event.Type, "com.github.unrolled.recovery.panic")
+	expect(t, event.Subject, "/foo")
+	expect(t, event.DataContentType, "application/json")
+	expect(t, event.Data.Panic, "this did not work")
+	expect(t, event.Data.Method, "GET")
+}
+
+func TestCloudEventsSourceAndType(t *testing.T) {
+	r := New(Options{})
+	expect(t, r.opt.CloudEventsSource, "/recovery")
+
+	r = New(Options{
+		CloudEventsSource: "https://example.com/api",
+		CloudEventsType:   "com.example.crash",
+	})
+	expect(t, r.opt.CloudEventsSource, "https://example.com/api")
+	expect(t, r.opt.CloudEventsType, "com.example.crash")
+}
+
+func TestCloudEventsSink(t *testing.T) {
+	events := make(chan []byte, 1)
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		contentType = req.Header.Get("Content-Type")
+		b, _ := io.ReadAll(req.Body)
+		events <- b
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	r := New(Options{
+		Sinks: []Sink{CloudEventsSink(srv.URL, CloudEventsSinkOptions{})},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var event cloudEvent
+	if err := json.Unmarshal(<-events, &event); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, contentType, "application/cloudevents+json")
+	expect(t, event.Data.Panic, "this did not work")
+}
+
+func TestCloudEventsWriterStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	sink := CloudEventsSink(srv.URL, CloudEventsSinkOptions{Client: srv.Client()})
+	_, err := sink.Out.Write([]byte("{}\n"))
+	if err == nil {
+		t.Error("Expected an error for a rejected event")
+	}
+}
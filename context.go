@@ -0,0 +1,61 @@
+package recovery
+
+import (
+	"context"
+	"time"
+)
+
+// PanicInfo describes a recovered panic.
+type PanicInfo struct {
+	// Value is the value that was passed to panic.
+	Value interface{}
+	// Stack is the stack dump captured when the panic was recovered.
+	Stack []byte
+	// Frames are the calls of the goroutine that panicked, innermost first.
+	Frames []Frame
+	// Time is when the panic was recovered.
+	Time time.Time
+	// RequestID correlates the panic with the request. It is taken from the RequestIDHeader of the request, or generated when the request has none.
+	RequestID string
+	// Severity is how bad the panic is, as classified by the Severity option.
+	Severity Severity
+	// Fingerprint is a stable hash of the panic value type and the top frames of the code that panicked, the same for every occurrence of a bug. Use it to group and count panics.
+	Fingerprint string
+	// Origin is where the panic was raised, as the file and line of the first application frame, like "github.com/me/app/handlers/user.go:42". Use it to group panics without parsing the stack.
+	Origin string
+	// Body is the start of the request body, as far as the handler read it, with CaptureBodyBytes. It is nil otherwise.
+	Body []byte
+}
+
+type contextKey int
+
+const (
+	panicInfoKey contextKey = iota
+	requestIDKey
+	panicRecordKey
+	recoveredKey
+)
+
+// FromContext returns the PanicInfo of the panic being handled. Recovery stores it on the request context before calling the panic handler and hooks, so any error rendering code further along can inspect the panic without a special handler signature.
+func FromContext(ctx context.Context) (*PanicInfo, bool) {
+	info, ok := ctx.Value(panicInfoKey).(*PanicInfo)
+	return info, ok
+}
+
+// newContext returns a copy of ctx carrying info, and the panic as a *Recovered error.
+func newContext(ctx context.Context, info *PanicInfo) context.Context {
+	ctx = context.WithValue(ctx, panicInfoKey, info)
+	return context.WithValue(ctx, recoveredKey, info.Recovered())
+}
+
+// RecoveredFromContext returns the panic being handled as a *Recovered error. Recovery stores it on the request context along with the PanicInfo, before calling the panic handler and hooks, so code dealing in errors gets one without a special signature.
+func RecoveredFromContext(ctx context.Context) (*Recovered, bool) {
+	rec, ok := ctx.Value(recoveredKey).(*Recovered)
+	return rec, ok
+}
+
+// RecordFromContext returns the log view of the panic being handled, as the formatters get it: the panic value is scrubbed and capped to MaxPanicValueBytes, the path and URL are scrubbed, and only the LogHeaders are kept, redacted. Recovery stores it on the request context before calling the hooks, so reporters sending panics to a third party send no more than the logs hold.
+func RecordFromContext(ctx context.Context) (*PanicRecord, bool) {
+	rec, ok := ctx.Value(panicRecordKey).(*PanicRecord)
+	return rec, ok
+}
@@ -0,0 +1,102 @@
+package recovery
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFromContext(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	var handlerInfo, hookInfo *PanicInfo
+	r.SetPanicHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		info, ok := FromContext(req.Context())
+		expect(t, ok, true)
+		handlerInfo = info
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		hookInfo, _ = FromContext(req.Context())
+	})
+
+	before := time.Now()
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if handlerInfo == nil {
+		t.Fatal("Expected the panic handler to find a PanicInfo")
+	}
+
+	expect(t, handlerInfo, hookInfo)
+	expect(t, handlerInfo.Value, interface{}("this did not work"))
+	expectContainsTrue(t, string(handlerInfo.Stack), "src/net/http/server.go")
+	if handlerInfo.Time.Before(before) {
+		t.Errorf("Expected the panic time to be after %v, got %v", before, handlerInfo.Time)
+	}
+
+	_, ok := FromContext(req.Context())
+	expect(t, ok, false)
+}
+
+func TestRecordFromContext(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		LogRequestDetails:  true,
+		LogHeaders:         []string{"Authorization"},
+		MaxPanicValueBytes: 16,
+		ScrubPatterns:      []*regexp.Regexp{regexp.MustCompile(`\d{4}-\d{4}`)},
+	})
+
+	var rec *PanicRecord
+	var ok bool
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		rec, ok = RecordFromContext(req.Context())
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/cards/1234-5678?token=s3cret", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("card 1234-5678 declined")
+	})).ServeHTTP(res, req)
+
+	expect(t, ok, true)
+	expect(t, rec.Panic, "card [REDACTED] ...(truncated)")
+	expect(t, rec.Path, "/cards/[REDACTED]")
+	expect(t, rec.URL, "/cards/[REDACTED]?token=[REDACTED]")
+	expect(t, rec.Headers["Authorization"], "[REDACTED]")
+	expect(t, rec.Status, http.StatusInternalServerError)
+
+	// Outside of a hook, there is none.
+	_, ok = RecordFromContext(req.Context())
+	expect(t, ok, false)
+}
+
+func TestRecordFromContextRateLimited(t *testing.T) {
+	r := New(Options{
+		Out:       ioutil.Discard,
+		RateLimit: 1,
+	})
+
+	var panics []string
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		if rec, ok := RecordFromContext(req.Context()); ok {
+			panics = append(panics, rec.Panic)
+		}
+	})
+
+	// The hooks get a record even for the panics the RateLimit keeps out of the log.
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+	expect(t, len(panics), 2)
+	expect(t, panics[1], "this did not work")
+}
@@ -0,0 +1,91 @@
+package recovery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// deduper collapses panics with the same key into one aggregated record per window.
+type deduper struct {
+	window time.Duration
+	write  func(ctx context.Context, rec *PanicRecord)
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+// dedupEntry tracks the duplicates of one key in the current window.
+type dedupEntry struct {
+	timer *time.Timer
+	count int
+	ctx   context.Context
+	last  *PanicRecord
+}
+
+func newDeduper(window time.Duration, write func(ctx context.Context, rec *PanicRecord)) *deduper {
+	return &deduper{
+		window:  window,
+		write:   write,
+		entries: map[string]*dedupEntry{},
+	}
+}
+
+// first reports whether rec is the first panic with its key in the window, and should be logged now. Duplicates are held back for the aggregated record.
+func (d *deduper) first(key string, ctx context.Context, rec *PanicRecord) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.entries[key]; ok {
+		e.count++
+		e.ctx = ctx
+		e.last = rec
+		return false
+	}
+
+	d.entries[key] = &dedupEntry{
+		timer: time.AfterFunc(d.window, func() { d.flush(key) }),
+	}
+	return true
+}
+
+// flush ends the window of a key, writing the aggregated record of its duplicates, if any.
+func (d *deduper) flush(key string) {
+	d.mu.Lock()
+	e, ok := d.entries[key]
+	delete(d.entries, key)
+	d.mu.Unlock()
+
+	if !ok || e.count == 0 {
+		return
+	}
+
+	rec := *e.last
+	rec.Message = "Recovering from repeated Panic"
+	rec.Count = e.count
+	d.write(e.ctx, &rec)
+}
+
+// close ends every window right away.
+func (d *deduper) close() {
+	d.mu.Lock()
+	keys := make([]string, 0, len(d.entries))
+	for key, e := range d.entries {
+		e.timer.Stop()
+		keys = append(keys, key)
+	}
+	d.mu.Unlock()
+
+	for _, key := range keys {
+		d.flush(key)
+	}
+}
+
+// dedupKey is the key panics are deduplicated and rate limited under.
+func (r *Recovery) dedupKey(info *PanicInfo) string {
+	if r.opt.DedupKeyFunc != nil {
+		return r.opt.DedupKeyFunc(*info)
+	}
+
+	return info.Fingerprint
+}
@@ -0,0 +1,149 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the goroutine of the dedup timer.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFingerprintInRecord(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:    buf,
+		Format: FormatJSON,
+	})
+
+	var fp string
+	r.OnPanic(func(req *http.Request, v interface{}, stack []byte) {
+		info, _ := FromContext(req.Context())
+		fp = info.Fingerprint
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expect(t, len(fp), 16)
+	expectContainsTrue(t, buf.String(), `"fingerprint":"`+fp+`"`)
+	expectContainsFalse(t, buf.String(), `"count"`)
+}
+
+func TestDedupWindow(t *testing.T) {
+	out := &syncBuffer{}
+	r := New(Options{
+		Out:         out,
+		OutputFlags: -1,
+		DedupWindow: 50 * time.Millisecond,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	for i := 0; i < 4; i++ {
+		res := httptest.NewRecorder()
+		r.Handler(myPanicHandler).ServeHTTP(res, req)
+		expect(t, res.Code, http.StatusInternalServerError)
+	}
+
+	expect(t, strings.Count(out.String(), "Recovering from"), 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "repeated") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expectContainsTrue(t, out.String(), "Recovering from repeated Panic (count=3): this did not work")
+	expect(t, strings.Count(out.String(), "Recovering from"), 2)
+}
+
+func TestDedupClose(t *testing.T) {
+	out := &syncBuffer{}
+	r := New(Options{
+		Out:         out,
+		Format:      FormatLogfmt,
+		DedupWindow: time.Hour,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Close()
+
+	expectContainsTrue(t, out.String(), " count=1 message=\"Recovering from repeated Panic\"")
+}
+
+func TestDedupKeyFunc(t *testing.T) {
+	out := &syncBuffer{}
+	r := New(Options{
+		Out:         out,
+		DedupWindow: time.Hour,
+		DedupKeyFunc: func(info PanicInfo) string {
+			// Group every "user N not found" panic together, wherever it comes from.
+			if strings.HasSuffix(fmt.Sprint(info.Value), " not found") {
+				return "not-found"
+			}
+			return info.Fingerprint
+		},
+	})
+
+	notFound := func(id int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(fmt.Sprintf("user %d not found", id))
+		})
+	}
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(notFound(1)).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(notFound(2)).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(panicWith("user 3 not found")).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, out.String(), "Recovering from Panic: user 1 not found")
+	expectContainsFalse(t, out.String(), "user 2 not found")
+	expectContainsTrue(t, out.String(), "Recovering from Panic: this did not work")
+
+	r.Close()
+	expectContainsTrue(t, out.String(), "Recovering from repeated Panic (count=2): user 3 not found")
+}
+
+func TestDeduperKeys(t *testing.T) {
+	var written []*PanicRecord
+	d := newDeduper(time.Hour, func(ctx context.Context, rec *PanicRecord) {
+		written = append(written, rec)
+	})
+
+	rec := &PanicRecord{PanicInfo: &PanicInfo{}, Message: "Recovering from Panic"}
+	expect(t, d.first("a", context.Background(), rec), true)
+	expect(t, d.first("a", context.Background(), rec), false)
+	expect(t, d.first("b", context.Background(), rec), true)
+
+	d.close()
+	expect(t, len(written), 1)
+	expect(t, written[0].Count, 1)
+	expect(t, rec.Count, 0)
+
+	// A new window starts after the flush.
+	expect(t, d.first("a", context.Background(), rec), true)
+	d.close()
+}
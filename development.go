@@ -0,0 +1,174 @@
+package recovery
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"sort"
+)
+
+// sourceContext is how many lines around a frame's line the development page shows, unless SourceContext says otherwise.
+const sourceContext = 3
+
+// devPageData is the data the development page is rendered with.
+type devPageData struct {
+	Status     int
+	StatusText string
+	Panic      string
+	Frames     []devFrame
+	Method     string
+	URL        string
+	Proto      string
+	RemoteAddr string
+	Headers    []devHeader
+	Stack      string
+}
+
+type devFrame struct {
+	Frame
+	Source []sourceLine
+}
+
+type sourceLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+type devHeader struct {
+	Name  string
+	Value string
+}
+
+// development reports whether the debug page is enabled. Production mode always wins.
+func (r *Recovery) development() bool {
+	return r.opt.Development && !r.opt.Production
+}
+
+// developmentPage renders the debug page served in Development mode.
+func (r *Recovery) developmentPage(req *http.Request, status int, v interface{}, stack []byte) []byte {
+	data := devPageData{
+		Status:     status,
+		StatusText: http.StatusText(status),
+		Panic:      r.panicValue(v),
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Proto:      req.Proto,
+		RemoteAddr: req.RemoteAddr,
+		Stack:      string(stack),
+	}
+
+	context := sourceContext
+	if r.opt.SourceContext > 0 {
+		context = r.opt.SourceContext
+	}
+
+	// The frames of the PanicInfo keep the paths on disk, whatever TrimPaths does to the dump.
+	frames := parseStack(stack)
+	if info, ok := FromContext(req.Context()); ok && len(info.Frames) > 0 {
+		frames = info.Frames
+	}
+
+	files := sourceFiles{}
+	for _, frame := range frames {
+		data.Frames = append(data.Frames, devFrame{
+			Frame:  frame,
+			Source: sourceSnippet(files.lines(frame.File), frame.Line, context),
+		})
+	}
+
+	for name, values := range req.Header {
+		data.Headers = append(data.Headers, devHeader{Name: name, Value: r.headerValue(name, values)})
+	}
+	sort.Slice(data.Headers, func(i, j int) bool {
+		return data.Headers[i].Name < data.Headers[j].Name
+	})
+
+	var buf bytes.Buffer
+	if err := devPageTemplate.Execute(&buf, data); err != nil {
+		r.notef("Error rendering development page: %s", err)
+		return []byte(data.Panic + "\n\n" + data.Stack)
+	}
+
+	return buf.Bytes()
+}
+
+// sourceSnippet returns the lines within context of line (1-based) in lines.
+func sourceSnippet(lines []string, line, context int) []sourceLine {
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+
+	start, end := line-context, line+context
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	snippet := make([]sourceLine, 0, end-start+1)
+	for n := start; n <= end; n++ {
+		snippet = append(snippet, sourceLine{
+			Number:  n,
+			Text:    lines[n-1],
+			Current: n == line,
+		})
+	}
+
+	return snippet
+}
+
+var devPageTemplate = template.Must(template.New("development").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.StatusText}}: {{.Panic}}</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 0; color: #222; }
+header { background: #b3261e; color: #fff; padding: 1.5em 2em; }
+header h1 { margin: 0 0 .3em; font-size: 1.1em; font-weight: normal; }
+header pre { margin: 0; font-size: 1.4em; white-space: pre-wrap; }
+section { padding: 1em 2em; }
+h2 { font-size: 1em; text-transform: uppercase; color: #666; }
+.frame { margin-bottom: 1.2em; }
+.frame .func { font-weight: bold; }
+.frame .file { color: #666; font-family: monospace; }
+table.source { border-collapse: collapse; font-family: monospace; font-size: .9em; margin-top: .4em; background: #f6f6f6; width: 100%; }
+table.source td { padding: 0 .6em; white-space: pre; }
+table.source td.num { color: #999; text-align: right; width: 1%; }
+table.source tr.current { background: #fde2e0; }
+table.request td { padding: .2em 1em .2em 0; vertical-align: top; font-family: monospace; }
+pre.raw { background: #f6f6f6; padding: 1em; overflow: auto; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Status}} {{.StatusText}} (recovered panic)</h1>
+<pre>{{.Panic}}</pre>
+</header>
+<section>
+<h2>Stack</h2>
+{{range .Frames}}<div class="frame">
+<div class="func">{{.Func}}</div>
+<div class="file">{{.File}}:{{.Line}}</div>
+{{if .Source}}<table class="source">{{range .Source}}<tr{{if .Current}} class="current"{{end}}><td class="num">{{.Number}}</td><td>{{.Text}}</td></tr>{{end}}</table>{{end}}
+</div>
+{{end}}</section>
+<section>
+<h2>Request</h2>
+<table class="request">
+<tr><td>Method</td><td>{{.Method}}</td></tr>
+<tr><td>URL</td><td>{{.URL}}</td></tr>
+<tr><td>Protocol</td><td>{{.Proto}}</td></tr>
+<tr><td>Remote Address</td><td>{{.RemoteAddr}}</td></tr>
+{{range .Headers}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+</section>
+<section>
+<h2>Raw Stack</h2>
+<pre class="raw">{{.Stack}}</pre>
+</section>
+</body>
+</html>
+`))
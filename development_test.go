@@ -0,0 +1,65 @@
+package recovery
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDevelopmentPage(t *testing.T) {
+	r := New(Options{
+		Out:         ioutil.Discard,
+		Development: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders?id=42", nil)
+	req.Header.Set("User-Agent", "recovery-test")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	body := res.Body.String()
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get("Content-Type"), "text/html; charset=utf-8")
+
+	// Panic value and parsed frames.
+	expectContainsTrue(t, body, "<pre>this did not work</pre>")
+	expectContainsTrue(t, body, `<div class="func">net/http.HandlerFunc.ServeHTTP</div>`)
+
+	// Source of this very test file is on disk, so its snippet shows.
+	expectContainsTrue(t, body, `panic(&#34;this did not work&#34;)`)
+	expectContainsTrue(t, body, `<tr class="current">`)
+
+	// Request details.
+	expectContainsTrue(t, body, "<td>POST</td>")
+	expectContainsTrue(t, body, "<td>/orders?id=42</td>")
+	expectContainsTrue(t, body, "<td>recovery-test</td>")
+}
+
+func TestDevelopmentPageRespectsNegotiation(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		Development:        true,
+		ContentNegotiation: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Header().Get("Content-Type"), "application/json; charset=utf-8")
+}
+
+func TestSourceSnippet(t *testing.T) {
+	lines := []string{"one", "two", "three", "four", "five"}
+
+	snippet := sourceSnippet(lines, 1, 1)
+	expect(t, len(snippet), 2)
+	expect(t, snippet[0], sourceLine{Number: 1, Text: "one", Current: true})
+	expect(t, snippet[1], sourceLine{Number: 2, Text: "two"})
+
+	expect(t, len(sourceSnippet(lines, 3, 3)), 5)
+	expect(t, len(sourceSnippet(lines, 9, 3)), 0)
+	expect(t, len(sourceSnippet(nil, 1, 3)), 0)
+}
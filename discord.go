@@ -0,0 +1,98 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// discordInterval is the least time between two messages. Discord allows 30 messages a minute per webhook, and 5 in 2 seconds.
+	discordInterval = 2 * time.Second
+	// discordColor is the red of the embeds.
+	discordColor = 0xE74C3C
+
+	// The limits of an embed.
+	maxDiscordTitle       = 256
+	maxDiscordDescription = 4096
+	maxDiscordFieldValue  = 1024
+)
+
+// discordMessage is the payload of a Discord webhook, holding one embed.
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Timestamp   string         `json:"timestamp"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// discordFormatter renders a panic as a Discord embed, at most one every discordInterval.
+type discordFormatter struct {
+	limiter *rateLimiter
+}
+
+func (f discordFormatter) Format(rec *PanicRecord) []byte {
+	// The limit is of the webhook, so every panic shares one key.
+	ok, suppressed := f.limiter.allow("", rec.Time)
+	if !ok {
+		return nil
+	}
+
+	where := rec.Route
+	if len(where) == 0 {
+		where = rec.Method + " " + rec.Path
+	}
+
+	embed := discordEmbed{
+		Title:       truncate("Panic in "+where, maxDiscordTitle),
+		Description: truncate(rec.Panic, maxDiscordDescription),
+		Color:       discordColor,
+		Timestamp:   rec.Time.UTC().Format(time.RFC3339),
+	}
+	if frames := appFrames(rec.Frames, 1); len(frames) > 0 {
+		value := fmt.Sprintf("`%s`\n%s:%d", frames[0].Func, frames[0].File, frames[0].Line)
+		embed.Fields = append(embed.Fields, discordField{Name: "Top frame", Value: truncate(value, maxDiscordFieldValue)})
+	}
+	for _, field := range [][2]string{
+		{"Severity", rec.Severity.String()},
+		{"Request ID", rec.RequestID},
+	} {
+		if len(field[1]) > 0 {
+			embed.Fields = append(embed.Fields, discordField{Name: field[0], Value: truncate(field[1], maxDiscordFieldValue), Inline: true})
+		}
+	}
+	if suppressed > 0 {
+		embed.Footer = &discordFooter{Text: fmt.Sprintf("%d more panics since the last message", suppressed)}
+	}
+
+	return jsonPayload(discordMessage{Embeds: []discordEmbed{embed}})
+}
+
+// DiscordOptions configures a DiscordSink.
+type DiscordOptions struct {
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// DiscordSink returns a Sink that posts each panic to a Discord webhook as an embed, with the panic value, the top application frame, and the time. To stay within the limits of Discord webhooks, messages go out at most one every 2 seconds, and the next message tells how many panics were held back. Every post happens as the panic is logged, so consider AsyncLogging.
+func DiscordSink(webhookURL string, opt DiscordOptions) Sink {
+	f := discordFormatter{limiter: newRateLimiter(1, discordInterval)}
+
+	return Sink{Out: newPostWriter(opt.Client, webhookURL, "application/json", "Discord"), Formatter: f}
+}
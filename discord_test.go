@@ -0,0 +1,70 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscordSink(t *testing.T) {
+	messages := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		messages <- b
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	r := New(Options{Sinks: []Sink{DiscordSink(srv.URL, DiscordOptions{Client: srv.Client()})}})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	var msg discordMessage
+	if err := json.Unmarshal(<-messages, &msg); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, len(msg.Embeds), 1)
+
+	embed := msg.Embeds[0]
+	expect(t, embed.Title, "Panic in GET /foo")
+	expect(t, embed.Description, "this did not work")
+	expect(t, embed.Color, discordColor)
+	_, err := time.Parse(time.RFC3339, embed.Timestamp)
+	expect(t, err, nil)
+	expect(t, embed.Fields[0].Name, "Top frame")
+	expectContainsTrue(t, embed.Fields[0].Value, "recovery_test.go")
+	expect(t, embed.Footer == nil, true)
+}
+
+func TestDiscordRateLimit(t *testing.T) {
+	f := DiscordSink("", DiscordOptions{}).Formatter
+	now := time.Now()
+
+	expect(t, len(f.Format(&PanicRecord{PanicInfo: &PanicInfo{Time: now, Fingerprint: "abc"}, Panic: "oops"})) > 0, true)
+
+	// The limit is of the webhook, whatever the panic.
+	expect(t, len(f.Format(&PanicRecord{PanicInfo: &PanicInfo{Time: now.Add(time.Second), Fingerprint: "abc"}, Panic: "oops"})), 0)
+	expect(t, len(f.Format(&PanicRecord{PanicInfo: &PanicInfo{Time: now.Add(time.Second), Fingerprint: "def"}, Panic: "other"})), 0)
+
+	var msg discordMessage
+	if err := json.Unmarshal(f.Format(&PanicRecord{PanicInfo: &PanicInfo{Time: now.Add(discordInterval)}, Panic: "oops"}), &msg); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, msg.Embeds[0].Footer.Text, "2 more panics since the last message")
+}
+
+func TestDiscordLimits(t *testing.T) {
+	f := DiscordSink("", DiscordOptions{}).Formatter
+
+	var msg discordMessage
+	if err := json.Unmarshal(f.Format(&PanicRecord{PanicInfo: &PanicInfo{}, Panic: strings.Repeat("é", 3000)}), &msg); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, len(msg.Embeds[0].Description), maxDiscordDescription)
+	expect(t, strings.HasSuffix(msg.Embeds[0].Description, "é"), true)
+}
@@ -0,0 +1,154 @@
+package recovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ecsVersion is the version of the Elastic Common Schema the FormatECS records follow.
+const ecsVersion = "8.11"
+
+// ecsRecord is the shape of a FormatECS log entry.
+type ecsRecord struct {
+	Timestamp string            `json:"@timestamp"`
+	Log       ecsLog            `json:"log"`
+	Message   string            `json:"message"`
+	ECS       ecsVersionField   `json:"ecs"`
+	Event     ecsEvent          `json:"event"`
+	Service   *ecsService       `json:"service,omitempty"`
+	Error     ecsError          `json:"error"`
+	HTTP      *ecsHTTP          `json:"http,omitempty"`
+	URL       *ecsURL           `json:"url,omitempty"`
+	Client    *ecsClient        `json:"client,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+type ecsLog struct {
+	Level  string        `json:"level"`
+	Origin *ecsLogOrigin `json:"origin,omitempty"`
+}
+
+type ecsLogOrigin struct {
+	File ecsFile `json:"file"`
+}
+
+type ecsFile struct {
+	Name string `json:"name"`
+	Line int    `json:"line,omitempty"`
+}
+
+type ecsVersionField struct {
+	Version string `json:"version"`
+}
+
+type ecsEvent struct {
+	Kind     string `json:"kind"`
+	Category string `json:"category"`
+	Type     string `json:"type"`
+}
+
+type ecsService struct {
+	Name string `json:"name"`
+}
+
+type ecsError struct {
+	Message    string `json:"message"`
+	Type       string `json:"type"`
+	StackTrace string `json:"stack_trace"`
+}
+
+type ecsHTTP struct {
+	Version  string           `json:"version,omitempty"`
+	Request  ecsHTTPRequest   `json:"request"`
+	Response *ecsHTTPResponse `json:"response,omitempty"`
+}
+
+type ecsHTTPRequest struct {
+	ID     string          `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Body   *ecsHTTPContent `json:"body,omitempty"`
+}
+
+type ecsHTTPContent struct {
+	Content string `json:"content"`
+}
+
+type ecsHTTPResponse struct {
+	StatusCode int `json:"status_code"`
+}
+
+type ecsURL struct {
+	Path     string `json:"path,omitempty"`
+	Original string `json:"original,omitempty"`
+}
+
+type ecsClient struct {
+	Address string `json:"address"`
+}
+
+// ecsFormatter renders a panic as a newline terminated Elastic Common Schema JSON object.
+type ecsFormatter struct {
+	r *Recovery
+}
+
+func (f ecsFormatter) Format(rec *PanicRecord) []byte {
+	doc := ecsRecord{
+		// ECS wants UTC timestamps with milliseconds, whatever the TimeFormat.
+		Timestamp: rec.Time.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		Log:       ecsLog{Level: rec.Severity.String()},
+		Message:   rec.Message + ": " + rec.Panic,
+		ECS:       ecsVersionField{Version: ecsVersion},
+		Event:     ecsEvent{Kind: "event", Category: "web", Type: "error"},
+		Error: ecsError{
+			Message:    rec.Panic,
+			Type:       fmt.Sprintf("%T", rec.Value),
+			StackTrace: string(rec.Stack),
+		},
+		Labels: map[string]string{},
+	}
+
+	if len(rec.Origin) > 0 {
+		file, line := splitOrigin(rec.Origin)
+		doc.Log.Origin = &ecsLogOrigin{File: ecsFile{Name: file, Line: line}}
+	}
+
+	if len(rec.Prefix) > 0 {
+		doc.Service = &ecsService{Name: rec.Prefix}
+	}
+
+	if len(rec.Method) > 0 {
+		doc.HTTP = &ecsHTTP{
+			Version: strings.TrimPrefix(rec.Proto, "HTTP/"),
+			Request: ecsHTTPRequest{ID: f.r.recordRequestID(rec), Method: rec.Method},
+		}
+		if len(rec.Body) > 0 {
+			doc.HTTP.Request.Body = &ecsHTTPContent{Content: string(rec.Body)}
+		}
+		if rec.Status > 0 {
+			doc.HTTP.Response = &ecsHTTPResponse{StatusCode: rec.Status}
+		}
+		doc.URL = &ecsURL{Path: rec.Path, Original: rec.URL}
+	}
+
+	if len(rec.RemoteAddr) > 0 {
+		doc.Client = &ecsClient{Address: rec.RemoteAddr}
+	}
+
+	// Whatever has no place in ECS goes in the labels.
+	for name, value := range map[string]string{
+		"fingerprint": rec.Fingerprint,
+		"route":       rec.Route,
+	} {
+		if len(value) > 0 {
+			doc.Labels[name] = value
+		}
+	}
+	if rec.Count > 0 {
+		doc.Labels["count"] = fmt.Sprint(rec.Count)
+	}
+	for name, value := range rec.Headers {
+		doc.Labels["header_"+strings.ToLower(strings.ReplaceAll(name, "-", "_"))] = value
+	}
+
+	return jsonLine(rec, doc)
+}
@@ -0,0 +1,75 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFormatECS(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Prefix:            "testapp",
+		Out:               buf,
+		Format:            FormatECS,
+		LogRequestDetails: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo?bar=1", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var doc struct {
+		Timestamp string `json:"@timestamp"`
+		Log       struct {
+			Level string `json:"level"`
+		} `json:"log"`
+		Message string `json:"message"`
+		Event   struct {
+			Kind string `json:"kind"`
+		} `json:"event"`
+		Service struct {
+			Name string `json:"name"`
+		} `json:"service"`
+		Error struct {
+			Message    string `json:"message"`
+			Type       string `json:"type"`
+			StackTrace string `json:"stack_trace"`
+		} `json:"error"`
+		HTTP struct {
+			Version string `json:"version"`
+			Request struct {
+				Method string `json:"method"`
+			} `json:"request"`
+			Response struct {
+				StatusCode int `json:"status_code"`
+			} `json:"response"`
+		} `json:"http"`
+		URL struct {
+			Path     string `json:"path"`
+			Original string `json:"original"`
+		} `json:"url"`
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a single ECS record, got error %v for [%s]", err, buf.String())
+	}
+
+	expectContainsTrue(t, doc.Timestamp, "Z")
+	expect(t, doc.Log.Level, "error")
+	expect(t, doc.Message, "Recovering from Panic: this did not work")
+	expect(t, doc.Event.Kind, "event")
+	expect(t, doc.Service.Name, "testapp")
+	expect(t, doc.Error.Message, "this did not work")
+	expect(t, doc.Error.Type, "string")
+	expectContainsTrue(t, doc.Error.StackTrace, "src/net/http/server.go")
+	expect(t, doc.HTTP.Version, "1.1")
+	expect(t, doc.HTTP.Request.Method, "GET")
+	expect(t, doc.HTTP.Response.StatusCode, http.StatusInternalServerError)
+	expect(t, doc.URL.Path, "/foo")
+	expect(t, doc.URL.Original, "/foo?bar=1")
+	expect(t, len(doc.Labels["fingerprint"]), 16)
+}
@@ -0,0 +1,181 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// defaultEmailSubject is the subject template of EmailOptions.
+const defaultEmailSubject = "{{if .Prefix}}[{{.Prefix}}] {{end}}Panic in {{.Method}} {{.Path}}: {{.Panic}}"
+
+// errEmailClosed is returned by writes to a closed EmailSink.
+var errEmailClosed = errors.New("recovery: email sink closed")
+
+// EmailOptions configures an EmailSink.
+type EmailOptions struct {
+	// Addr is the host and port of the SMTP server, like "smtp.example.com:587". STARTTLS is used when the server offers it.
+	Addr string
+	// Auth authenticates with the server, like smtp.PlainAuth. Default is nil (no authentication).
+	Auth smtp.Auth
+	// From is the sender address.
+	From string
+	// To are the recipient addresses.
+	To []string
+	// Subject is a text/template of the subject, executed with the *PanicRecord of the first panic of a mail. Default is "{{if .Prefix}}[{{.Prefix}}] {{end}}Panic in {{.Method}} {{.Path}}: {{.Panic}}".
+	Subject string
+	// Window, if above 0, batches the panics recovered within it into one mail, sent when it is over. Default is 0 (one mail per panic).
+	Window time.Duration
+}
+
+// emailFormatter renders a panic as its subject line, followed by the body of its mail.
+type emailFormatter struct {
+	subject *template.Template
+}
+
+func (f emailFormatter) Format(rec *PanicRecord) []byte {
+	var subject strings.Builder
+	if err := f.subject.Execute(&subject, rec); err != nil {
+		subject.Reset()
+		subject.WriteString(rec.Message)
+	}
+
+	var b bytes.Buffer
+	// Line breaks would end the header.
+	b.WriteString(strings.Join(strings.Fields(subject.String()), " "))
+	b.WriteByte('\n')
+	fmt.Fprintf(&b, "%s: %s\n\n", rec.Message, rec.Panic)
+	fmt.Fprintf(&b, "Time: %s\n", rec.Time.Format(time.RFC3339))
+	if len(rec.Method) > 0 {
+		fmt.Fprintf(&b, "Request: %s %s\n", rec.Method, rec.Path)
+	}
+	for _, field := range [][2]string{
+		{"Request ID", rec.RequestID},
+		{"Severity", rec.Severity.String()},
+		{"Fingerprint", rec.Fingerprint},
+		{"Origin", rec.Origin},
+	} {
+		if len(field[1]) > 0 {
+			fmt.Fprintf(&b, "%s: %s\n", field[0], field[1])
+		}
+	}
+	if rec.Count > 0 {
+		fmt.Fprintf(&b, "Count: %d\n", rec.Count)
+	}
+	b.WriteString(rec.requestText())
+	b.WriteByte('\n')
+	b.Write(rec.Stack)
+
+	return b.Bytes()
+}
+
+// emailWriter mails the records written to it, one per mail or batched per window.
+type emailWriter struct {
+	opt  EmailOptions
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	mu       sync.Mutex
+	subjects []string
+	bodies   [][]byte
+	timer    *time.Timer
+	closed   bool
+}
+
+// Write adds a record to the batch, or mails it right away without a Window.
+func (w *emailWriter) Write(p []byte) (int, error) {
+	subject, body, _ := strings.Cut(string(p), "\n")
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, errEmailClosed
+	}
+	w.subjects = append(w.subjects, subject)
+	w.bodies = append(w.bodies, []byte(body))
+	if w.opt.Window > 0 {
+		if w.timer == nil {
+			w.timer = time.AfterFunc(w.opt.Window, func() { w.Flush() })
+		}
+		w.mu.Unlock()
+		return len(p), nil
+	}
+	w.mu.Unlock()
+
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush mails the batched records right away, in one mail.
+func (w *emailWriter) Flush() error {
+	w.mu.Lock()
+	subjects, bodies := w.subjects, w.bodies
+	w.subjects, w.bodies = nil, nil
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+
+	if len(bodies) == 0 {
+		return nil
+	}
+
+	subject := subjects[0]
+	if len(subjects) > 1 {
+		subject += fmt.Sprintf(" (and %d more)", len(subjects)-1)
+	}
+
+	return w.send(w.opt.Addr, w.opt.Auth, w.opt.From, w.opt.To, w.message(subject, bodies))
+}
+
+// message renders a mail, with the bodies of its records one after another.
+func (w *emailWriter) message(subject string, bodies [][]byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", w.opt.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(w.opt.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+
+	for i, body := range bodies {
+		if i > 0 {
+			b.WriteString("\r\n----\r\n\r\n")
+		}
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n", "\r\n"))
+	}
+
+	return b.Bytes()
+}
+
+// Close mails what is left in the batch. Later writes fail.
+func (w *emailWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	return w.Flush()
+}
+
+// EmailSink returns a Sink that mails each panic through an SMTP server, for deployments without an error tracker. With a Window, the panics recovered within it go out in one mail, the subject of the first one telling how many more it holds. Errors of batched mails are dropped. The Out of the Sink is an io.Closer, closed by Recovery.Close, so close the Recovery on shutdown to not lose the last batch. It fails when the Subject template does not parse.
+func EmailSink(opt EmailOptions) (Sink, error) {
+	if len(opt.Subject) == 0 {
+		opt.Subject = defaultEmailSubject
+	}
+	subject, err := template.New("subject").Parse(opt.Subject)
+	if err != nil {
+		return Sink{}, err
+	}
+
+	w := &emailWriter{opt: opt, send: smtp.SendMail}
+	return Sink{Out: w, Formatter: emailFormatter{subject}}, nil
+}
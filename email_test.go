@@ -0,0 +1,130 @@
+package recovery
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mailbox records the mails of an EmailSink.
+type mailbox struct {
+	mu    sync.Mutex
+	addr  string
+	from  string
+	to    []string
+	mails []string
+}
+
+func (m *mailbox) send(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.addr, m.from, m.to = addr, from, to
+	m.mails = append(m.mails, string(msg))
+	return nil
+}
+
+func (m *mailbox) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.mails)
+}
+
+func newTestEmailSink(t *testing.T, opt EmailOptions) (Sink, *mailbox) {
+	sink, err := EmailSink(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	box := &mailbox{}
+	sink.Out.(*emailWriter).send = box.send
+	return sink, box
+}
+
+func TestEmailSink(t *testing.T) {
+	sink, box := newTestEmailSink(t, EmailOptions{
+		Addr: "smtp.example.com:587",
+		From: "app@example.com",
+		To:   []string{"ops@example.com", "dev@example.com"},
+	})
+
+	r := New(Options{Sinks: []Sink{sink}, Prefix: "myapp"})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expect(t, box.count(), 1)
+	expect(t, box.addr, "smtp.example.com:587")
+	expect(t, box.from, "app@example.com")
+	expect(t, len(box.to), 2)
+
+	mail := box.mails[0]
+	expectContainsTrue(t, mail, "From: app@example.com\r\n")
+	expectContainsTrue(t, mail, "To: ops@example.com, dev@example.com\r\n")
+	expectContainsTrue(t, mail, "Subject: [myapp] Panic in GET /foo: this did not work\r\n")
+	expectContainsTrue(t, mail, "\r\n\r\nRecovering from Panic: this did not work\r\n")
+	expectContainsTrue(t, mail, "Request: GET /foo\r\n")
+	expectContainsTrue(t, mail, "Fingerprint: ")
+	expectContainsTrue(t, mail, "recovery_test.go")
+	expectContainsFalse(t, strings.ReplaceAll(mail, "\r\n", ""), "\n")
+}
+
+func TestEmailSinkWindow(t *testing.T) {
+	sink, box := newTestEmailSink(t, EmailOptions{
+		To:      []string{"ops@example.com"},
+		Subject: "Panic: {{.Panic}}",
+		Window:  time.Hour,
+	})
+
+	r := New(Options{Sinks: []Sink{sink}})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	for i := 0; i < 3; i++ {
+		r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+	expect(t, box.count(), 0)
+
+	expect(t, sink.Out.(io.Closer).Close(), nil)
+	expect(t, box.count(), 1)
+	expectContainsTrue(t, box.mails[0], "Subject: Panic: this did not work (and 2 more)\r\n")
+	expect(t, strings.Count(box.mails[0], "Recovering from Panic: this did not work"), 3)
+
+	_, err := sink.Out.Write([]byte("x\n"))
+	expect(t, err, errEmailClosed)
+}
+
+func TestEmailSinkWindowTimer(t *testing.T) {
+	sink, box := newTestEmailSink(t, EmailOptions{Window: 10 * time.Millisecond})
+
+	r := New(Options{Sinks: []Sink{sink}})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	deadline := time.Now().Add(time.Second)
+	for box.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	expect(t, box.count(), 1)
+}
+
+func TestEmailSinkSubject(t *testing.T) {
+	_, err := EmailSink(EmailOptions{Subject: "{{.Panic"})
+	expect(t, err != nil, true)
+
+	sink, box := newTestEmailSink(t, EmailOptions{Subject: "{{.Panic}}"})
+	r := New(Options{Sinks: []Sink{sink}})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("line one\nline two")
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, box.mails[0], "Subject: line one line two\r\n")
+}
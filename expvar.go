@@ -0,0 +1,56 @@
+package recovery
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+)
+
+// expvarMu guards the lookup and publishing of the ExpvarName maps, which expvar does not allow twice.
+var expvarMu sync.Mutex
+
+// panicVars are the counters of an ExpvarName map: "total" and the "routes" map, keyed by route pattern.
+type panicVars struct {
+	vars   *expvar.Map
+	routes *expvar.Map
+}
+
+// newPanicVars publishes the map with the given name, or reuses the one another Recovery published. It panics when the name is taken by a variable that is not a map, like expvar.Publish does for a name in use.
+func newPanicVars(name string) *panicVars {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	var vars *expvar.Map
+	if v := expvar.Get(name); v != nil {
+		m, ok := v.(*expvar.Map)
+		if !ok {
+			panic("recovery: expvar " + name + " is not a map")
+		}
+		vars = m
+	} else {
+		vars = expvar.NewMap(name)
+	}
+
+	routes, ok := vars.Get("routes").(*expvar.Map)
+	if !ok {
+		routes = new(expvar.Map).Init()
+		vars.Set("routes", routes)
+	}
+	if vars.Get("total") == nil {
+		vars.Set("total", new(expvar.Int))
+	}
+
+	return &panicVars{vars: vars, routes: routes}
+}
+
+// countPanic adds a recovered panic to the ExpvarName counters. Panics of requests without a known route only count towards the total.
+func (r *Recovery) countPanic(req *http.Request) {
+	if r.vars == nil {
+		return
+	}
+
+	r.vars.vars.Add("total", 1)
+	if route := r.route(req); len(route) > 0 {
+		r.vars.routes.Add(route, 1)
+	}
+}
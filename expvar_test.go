@@ -0,0 +1,65 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExpvar(t *testing.T) {
+	r := New(Options{
+		Out:          bytes.NewBufferString(""),
+		ExpvarName:   "recovery_test_panics",
+		RoutePattern: func(req *http.Request) string { return req.URL.Query().Get("route") },
+	})
+
+	for _, target := range []string{"/items/1?route=/items/{id}", "/items/2?route=/items/{id}", "/users/1?route=/users/{id}", "/foo"} {
+		req, _ := http.NewRequest("GET", target, nil)
+		r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var vars struct {
+		Total  int            `json:"total"`
+		Routes map[string]int `json:"routes"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("recovery_test_panics").String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, vars.Total, 4)
+	expect(t, len(vars.Routes), 2)
+	expect(t, vars.Routes["/items/{id}"], 2)
+	expect(t, vars.Routes["/users/{id}"], 1)
+}
+
+func TestExpvarShared(t *testing.T) {
+	api := New(Options{Out: bytes.NewBufferString(""), ExpvarName: "recovery_test_shared"})
+	admin := New(Options{Out: bytes.NewBufferString(""), ExpvarName: "recovery_test_shared"})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	api.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	admin.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	vars := expvar.Get("recovery_test_shared").(*expvar.Map)
+	expect(t, vars.Get("total").String(), "2")
+}
+
+func TestExpvarNotMap(t *testing.T) {
+	expvar.NewInt("recovery_test_int")
+
+	defer func() {
+		expectContainsTrue(t, recover().(string), "recovery: expvar recovery_test_int is not a map")
+	}()
+	New(Options{ExpvarName: "recovery_test_int"})
+}
+
+func TestExpvarDisabled(t *testing.T) {
+	r := New(Options{Out: bytes.NewBufferString("")})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expect(t, r.vars == nil, true)
+}
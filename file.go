@@ -0,0 +1,114 @@
+package recovery
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an append-only log file that rotates once it would grow past a size limit, keeping a fixed number of backups next to it (path.1 being the newest). Use it as the Out of Options or a Sink so panic traces survive restarts without filling the disk. It is safe for concurrent use.
+type RotatingFile struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens, or creates, the log file at path. The file rotates before a write would take it past maxBytes, and up to maxBackups rotated files are kept. A record is never split, so a single record larger than maxBytes gets a file to itself. A maxBytes of 0 or less disables rotation.
+func NewRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends b to the file, rotating it first when b would not fit.
+func (f *RotatingFile) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(b)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(b)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups up by one, dropping the oldest, moves the current file to path.1, and starts a new one. Whatever fails, the file at path is opened again, so a rotation that could not happen costs the record at hand and not every record after it.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = f.shift()
+	}
+
+	if oerr := f.open(); err == nil {
+		err = oerr
+	}
+	return err
+}
+
+// shift moves the closed file out of the way, to path.1 when backups are kept.
+func (f *RotatingFile) shift() error {
+	if f.maxBackups <= 0 {
+		return os.Remove(f.path)
+	}
+
+	os.Remove(f.backup(f.maxBackups))
+	for i := f.maxBackups - 1; i > 0; i-- {
+		os.Rename(f.backup(i), f.backup(i+1))
+	}
+	return os.Rename(f.path, f.backup(1))
+}
+
+func (f *RotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+// Close closes the file. Later writes fail with os.ErrClosed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package recovery
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.log")
+
+	f, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, record := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect(t, readFile(t, path), "fourth\n")
+	expect(t, readFile(t, path+".1"), "third\n")
+	expect(t, readFile(t, path+".2"), "second\n")
+
+	_, err = os.Stat(path + ".3")
+	expect(t, os.IsNotExist(err), true)
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.log")
+	ioutil.WriteFile(path, []byte("before\n"), 0644)
+
+	f, err := NewRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("after\n"))
+	f.Close()
+
+	expect(t, readFile(t, path), "after\n")
+	expect(t, readFile(t, path+".1"), "before\n")
+
+	_, err = f.Write([]byte("closed\n"))
+	expect(t, err, os.ErrClosed)
+}
+
+func TestRotatingFileWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.log")
+
+	f, err := NewRotatingFile(path, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("first\n"))
+	f.Write([]byte("second\n"))
+
+	expect(t, readFile(t, path), "second\n")
+	_, err = os.Stat(path + ".1")
+	expect(t, os.IsNotExist(err), true)
+}
+
+func TestRotatingFileRotateError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.log")
+
+	f, err := NewRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// A directory in the way of the backup makes the rotation fail.
+	os.MkdirAll(filepath.Join(path+".1", "taken"), 0755)
+	f.Write([]byte("first\n"))
+	if _, err := f.Write([]byte("second\n")); err == nil {
+		t.Error("Expected the failed rotation to be reported")
+	}
+
+	// The file is open again, and rotates once the way is clear.
+	os.RemoveAll(path + ".1")
+	if _, err := f.Write([]byte("third\n")); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, readFile(t, path), "third\n")
+	expect(t, readFile(t, path+".1"), "first\n")
+}
+
+func TestRotatingFileAsOut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.log")
+
+	f, err := NewRotatingFile(path, 1024*1024, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := New(Options{
+		Out: f,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, strings.Contains(readFile(t, path), "Recovering from Panic: this did not work"), true)
+}
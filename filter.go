@@ -0,0 +1,101 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+)
+
+// defaultSkipPrefixes are the functions DefaultFrameFilter drops, on top of the runtime.
+var defaultSkipPrefixes = []string{
+	"net/http.",
+	"github.com/unrolled/recovery.(*Recovery)",
+}
+
+// DefaultFrameFilter keeps every frame but those of the Go runtime, net/http, and the recovery middleware itself, so the first frame left is the code that panicked. Use it as Options.FrameFilter.
+func DefaultFrameFilter(frame Frame) bool {
+	return !isRuntimeFrame(frame) && !hasAnyPrefix(frame.Func, defaultSkipPrefixes)
+}
+
+// SkipFrames returns a FrameFilter that drops the frames of functions starting with any of the prefixes, like "github.com/go-chi/chi/v5.", on top of what DefaultFrameFilter drops. It is how the frames of other middleware are left out.
+func SkipFrames(prefixes ...string) func(frame Frame) bool {
+	return func(frame Frame) bool {
+		return DefaultFrameFilter(frame) && !hasAnyPrefix(frame.Func, prefixes)
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// elidedFrames ends the frames of a goroutine cut short by MaxFrames or StackSize, like the runtime marks its own limit.
+const elidedFrames = "...additional frames elided...\n"
+
+// filterFrames applies the FrameFilter and MaxFrames to the frames of a goroutine, and reports whether MaxFrames cut them short.
+func (r *Recovery) filterFrames(frames []Frame) ([]Frame, bool) {
+	if r.opt.FrameFilter == nil && r.opt.MaxFrames <= 0 {
+		return frames, false
+	}
+
+	kept := make([]Frame, 0, len(frames))
+	for _, frame := range frames {
+		if r.opt.FrameFilter != nil && !r.opt.FrameFilter(frame) {
+			continue
+		}
+		if r.opt.MaxFrames > 0 && len(kept) == r.opt.MaxFrames {
+			return kept, true
+		}
+		kept = append(kept, frame)
+	}
+
+	return kept, false
+}
+
+// filterStack drops the frames the FrameFilter rejects from a full stack dump, and those of each goroutine past MaxFrames. Goroutine headers and anything else that is not a frame are kept as is.
+func (r *Recovery) filterStack(stack []byte) []byte {
+	if r.opt.FrameFilter == nil && r.opt.MaxFrames <= 0 {
+		return stack
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(stack))
+
+	kept := 0
+	lines := bytes.SplitAfter(stack, []byte("\n"))
+	for i := 0; i < len(lines); i++ {
+		fn := string(lines[i])
+		if strings.HasPrefix(fn, "goroutine ") {
+			kept = 0
+		} else if fn == elidedFrames && r.opt.MaxFrames > 0 && kept > r.opt.MaxFrames {
+			// The dump was cut short by StackSize too, past MaxFrames, which already marked it.
+			continue
+		} else if i+1 < len(lines) && len(fn) > 0 && fn[0] != '\t' {
+			loc := string(lines[i+1])
+			if file, line, ok := parseLocation(strings.TrimSpace(loc)); ok && len(loc) > 0 && loc[0] == '\t' {
+				i++
+				if r.opt.FrameFilter != nil && !r.opt.FrameFilter(Frame{Func: parseFunc(strings.TrimSuffix(fn, "\n")), File: file, Line: line}) {
+					continue
+				}
+
+				kept++
+				switch {
+				case r.opt.MaxFrames <= 0 || kept <= r.opt.MaxFrames:
+					buf.WriteString(fn)
+					buf.WriteString(loc)
+				case kept == r.opt.MaxFrames+1:
+					buf.WriteString(elidedFrames)
+				}
+				continue
+			}
+		}
+
+		buf.Write(lines[i])
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,106 @@
+package recovery
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDefaultFrameFilter(t *testing.T) {
+	expect(t, DefaultFrameFilter(Frame{Func: "main.handler"}), true)
+	expect(t, DefaultFrameFilter(Frame{Func: "panic"}), false)
+	expect(t, DefaultFrameFilter(Frame{Func: "runtime.gopanic"}), false)
+	expect(t, DefaultFrameFilter(Frame{Func: "net/http.HandlerFunc.ServeHTTP"}), false)
+	expect(t, DefaultFrameFilter(Frame{Func: "github.com/unrolled/recovery.(*Recovery).Handler.func1"}), false)
+
+	filter := SkipFrames("github.com/go-chi/chi/v5.")
+	expect(t, filter(Frame{Func: "main.handler"}), true)
+	expect(t, filter(Frame{Func: "github.com/go-chi/chi/v5.(*Mux).ServeHTTP"}), false)
+	expect(t, filter(Frame{Func: "net/http.serverHandler.ServeHTTP"}), false)
+}
+
+func TestFilterStack(t *testing.T) {
+	r := New(Options{FrameFilter: DefaultFrameFilter})
+
+	stack := string(r.filterStack([]byte(testStack)))
+	expect(t, strings.HasPrefix(stack, "goroutine 7 [running]:\n"), true)
+	expectContainsFalse(t, stack, "panic({")
+	expectContainsFalse(t, stack, "/usr/local/go/src/runtime/panic.go")
+	expectContainsTrue(t, stack, "main.main()\n\t/src/main.go:10 +0x20\n")
+	expectContainsTrue(t, stack, "github.com/unrolled/recovery.(*tt).m(0x4c39d3?)\n\t/root/module/zz_test.go:4 +0x38\n")
+}
+
+func TestFrameFilter(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:         buf,
+		FrameFilter: DefaultFrameFilter,
+	})
+
+	var info *PanicInfo
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		info, _ = FromContext(req.Context())
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "recovery_test.go")
+	expectContainsFalse(t, buf.String(), "src/net/http/server.go")
+	expectContainsFalse(t, buf.String(), "runtime/panic.go")
+	expect(t, strings.HasSuffix(info.Frames[0].File, "recovery_test.go"), true)
+
+	// Filtering leaves the fingerprint alone.
+	unfiltered := New(Options{Out: bytes.NewBufferString("")})
+	var fp string
+	unfiltered.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		other, _ := FromContext(req.Context())
+		fp = other.Fingerprint
+	})
+	unfiltered.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expect(t, info.Fingerprint, fp)
+}
+
+func TestMaxFrames(t *testing.T) {
+	r := New(Options{MaxFrames: 2})
+
+	stack := string(r.filterStack([]byte(testStack)))
+	// Two frames of goroutine 7, and the one of goroutine 1.
+	expect(t, strings.Count(stack, "\n\t"), 3)
+	expectContainsTrue(t, stack, "github.com/unrolled/recovery.TestPrintStack.func1(...)\n\t/root/module/zz_test.go:5\n"+elidedFrames)
+	expectContainsFalse(t, stack, "testing.tRunner")
+}
+
+func TestMaxFramesAfterFilter(t *testing.T) {
+	r := New(Options{
+		FrameFilter: DefaultFrameFilter,
+		MaxFrames:   3,
+	})
+
+	stack := string(r.filterStack([]byte(testStack)))
+	expectContainsFalse(t, stack, "panic({")
+	expectContainsTrue(t, stack, "testing.tRunner")
+	expectContainsTrue(t, stack, elidedFrames)
+	expectContainsFalse(t, stack, "testing.(*T).Run")
+}
+
+func TestMaxFramesPerGoroutine(t *testing.T) {
+	r := New(Options{MaxFrames: 1})
+
+	dump := "goroutine 1 [running]:\nmain.a()\n\t/src/a.go:1 +0x1\nmain.b()\n\t/src/b.go:2 +0x2\n\ngoroutine 2 [select]:\nmain.c()\n\t/src/c.go:3 +0x3\n"
+	expect(t, string(r.filterStack([]byte(dump))), "goroutine 1 [running]:\nmain.a()\n\t/src/a.go:1 +0x1\n"+elidedFrames+"\ngoroutine 2 [select]:\nmain.c()\n\t/src/c.go:3 +0x3\n")
+}
+
+func TestMaxFramesStackSize(t *testing.T) {
+	r := New(Options{MaxFrames: 1})
+
+	// A dump cut short by StackSize as well is marked once.
+	dump := "goroutine 1 [running]:\nmain.a()\n\t/src/a.go:1 +0x1\nmain.b()\n\t/src/b.go:2 +0x2\n" + elidedFrames
+	expect(t, string(r.filterStack([]byte(dump))), "goroutine 1 [running]:\nmain.a()\n\t/src/a.go:1 +0x1\n"+elidedFrames)
+
+	r = New(Options{MaxFrames: 5})
+	expect(t, string(r.filterStack([]byte(dump))), dump)
+}
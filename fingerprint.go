@@ -0,0 +1,121 @@
+package recovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fingerprintFrames is how many application frames, from where the panic was raised, make up a fingerprint.
+const fingerprintFrames = 3
+
+// fingerprint is a stable hash of the panic value type and the top application frames of its stack, the same for every occurrence of a bug whatever the panic message says. Frames are normalized first, down to their function without type arguments and the name of their file, with neither line numbers nor the directories a build machine keeps sources in, so the fingerprint also holds across restarts and deployments. A stack without application frames, like none at all with DisableStackCapture, would lump every panic of a type together, so the panic value and the route of the request are hashed in their place.
+func fingerprint(v interface{}, frames []Frame, route string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%T\n", v)
+	frames = appFrames(frames, fingerprintFrames)
+	for _, frame := range frames {
+		fmt.Fprintln(h, normalizeFunc(frame.Func), path.Base(frame.File))
+	}
+	if len(frames) == 0 {
+		fmt.Fprintf(h, "%v\n%s\n", v, route)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// origin returns where a panic was raised, as the trimmed path and line of the first application frame of its stack, like "github.com/me/app/handlers/user.go:42". It is blank when the stack has no such frame.
+func origin(frames []Frame) string {
+	frames = appFrames(frames, 1)
+	if len(frames) == 0 {
+		return ""
+	}
+
+	return trimmedPath(frames[0].Func, frames[0].File) + ":" + strconv.Itoa(frames[0].Line)
+}
+
+// splitOrigin splits an origin into its file and line.
+func splitOrigin(origin string) (string, int) {
+	i := strings.LastIndex(origin, ":")
+	if i < 0 {
+		return origin, 0
+	}
+
+	line, _ := strconv.Atoi(origin[i+1:])
+	return origin[:i], line
+}
+
+// normalizeFunc strips the type arguments of generic functions, which the runtime prints as "[...]".
+func normalizeFunc(fn string) string {
+	for {
+		i := strings.Index(fn, "[")
+		if i < 0 {
+			return fn
+		}
+		j := strings.Index(fn[i:], "]")
+		if j < 0 {
+			return fn
+		}
+		fn = fn[:i] + fn[i+j+1:]
+	}
+}
+
+// appFrames returns up to n frames of the code that panicked. The leading frames are the recovery middleware and the runtime raising the panic, and runtime frames are skipped throughout.
+func appFrames(frames []Frame, n int) []Frame {
+	start := 0
+	for start < len(frames) && (isRuntimeFrame(frames[start]) || strings.HasPrefix(frames[start].Func, "github.com/unrolled/recovery.(*Recovery)")) {
+		start++
+	}
+
+	var app []Frame
+	for _, frame := range frames[start:] {
+		if len(app) == n {
+			break
+		}
+		if !isRuntimeFrame(frame) {
+			app = append(app, frame)
+		}
+	}
+
+	return app
+}
+
+// isRuntimeFrame reports whether a frame belongs to the Go runtime, including the panic call itself.
+func isRuntimeFrame(frame Frame) bool {
+	return frame.Func == "panic" || strings.HasPrefix(frame.Func, "runtime.")
+}
+
+// fingerprintSet holds the fingerprints filed by this process, so only their first panic is filed.
+type fingerprintSet struct {
+	mu           sync.Mutex
+	fingerprints map[string]bool
+}
+
+// add records a fingerprint, and reports whether it is new.
+func (s *fingerprintSet) add(fingerprint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fingerprints[fingerprint] {
+		return false
+	}
+	// Forgetting is cheap, as the sinks look the issue of a fingerprint up before filing it.
+	if len(s.fingerprints) >= maxRateBuckets {
+		s.fingerprints = map[string]bool{}
+	}
+	s.fingerprints[fingerprint] = true
+
+	return true
+}
+
+// remove forgets a fingerprint that failed to be filed, so its next panic tries again.
+func (s *fingerprintSet) remove(fingerprint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.fingerprints, fingerprint)
+}
@@ -0,0 +1,152 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fingerprintOf recovers a panic from handler and returns its fingerprint.
+func fingerprintOf(handler http.Handler) string {
+	var value interface{}
+	var frames []Frame
+
+	r := New(Options{Out: ioutil.Discard})
+	r.OnPanic(func(req *http.Request, v interface{}, s []byte) {
+		info, _ := FromContext(req.Context())
+		value, frames = v, info.Frames
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+	return fingerprint(value, frames, "")
+}
+
+func failWith(message string) {
+	panic(errors.New(message))
+}
+
+func TestFingerprintStable(t *testing.T) {
+	first := fingerprintOf(myPanicHandler)
+	expect(t, len(first), 16)
+	expect(t, fingerprintOf(myPanicHandler), first)
+}
+
+func TestFingerprintIgnoresMessage(t *testing.T) {
+	handler := func(message string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			failWith(message)
+		})
+	}
+
+	expect(t, fingerprintOf(handler("user 1 not found")), fingerprintOf(handler("user 2 not found")))
+}
+
+func TestFingerprintDiffers(t *testing.T) {
+	otherHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("this did not work")
+	})
+
+	if fingerprintOf(myPanicHandler) == fingerprintOf(otherHandler) {
+		t.Error("Expected panics from different places to have different fingerprints")
+	}
+}
+
+func TestAppFrames(t *testing.T) {
+	frames := []Frame{
+		{Func: "github.com/unrolled/recovery.(*Recovery).captureStack"},
+		{Func: "github.com/unrolled/recovery.(*Recovery).Handler.func1.1.1"},
+		{Func: "panic"},
+		{Func: "runtime.panicmem"},
+		{Func: "runtime.sigpanic"},
+		{Func: "main.loadUser"},
+		{Func: "main.handler"},
+		{Func: "runtime.deferreturn"},
+		{Func: "net/http.HandlerFunc.ServeHTTP"},
+		{Func: "github.com/unrolled/recovery.(*Recovery).Handler.func1"},
+	}
+
+	app := appFrames(frames, 3)
+	expect(t, len(app), 3)
+	expect(t, app[0].Func, "main.loadUser")
+	expect(t, app[1].Func, "main.handler")
+	expect(t, app[2].Func, "net/http.HandlerFunc.ServeHTTP")
+}
+
+func TestNormalizeFunc(t *testing.T) {
+	expect(t, normalizeFunc("main.handler"), "main.handler")
+	expect(t, normalizeFunc("main.Map[...]"), "main.Map")
+	expect(t, normalizeFunc("main.(*Cache[...]).Get"), "main.(*Cache).Get")
+	expect(t, normalizeFunc("main.broken[x"), "main.broken[x")
+}
+
+func TestFingerprintIgnoresPaths(t *testing.T) {
+	stack := func(dir string, line int) []Frame {
+		return []Frame{{Func: "main.handler", File: dir + "/handlers/user.go", Line: line}}
+	}
+
+	expect(t, fingerprint("boom", stack("/home/ci/build", 42), ""), fingerprint("boom", stack("/srv/app", 44), ""))
+	if fingerprint("boom", stack("/srv/app", 42), "") == fingerprint(errors.New("boom"), stack("/srv/app", 42), "") {
+		t.Error("Expected panics of different types to have different fingerprints")
+	}
+}
+
+func TestFingerprintWithoutStack(t *testing.T) {
+	var fingerprints []string
+	r := New(Options{Out: ioutil.Discard, DisableStackCapture: true})
+	r.OnPanic(func(req *http.Request, v interface{}, s []byte) {
+		info, _ := FromContext(req.Context())
+		fingerprints = append(fingerprints, info.Fingerprint)
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, req *http.Request) { panic(errors.New("no user")) })
+	mux.HandleFunc("/orders/", func(w http.ResponseWriter, req *http.Request) { panic(errors.New("no order")) })
+	for _, path := range []string{"/users/1", "/users/2", "/orders/1"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		r.Handler(mux).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Without frames, the value and route tell the panics apart, and the same one on the same route still groups.
+	expect(t, fingerprints[0], fingerprints[1])
+	if fingerprints[0] == fingerprints[2] {
+		t.Error("Expected panics of other values and routes to have different fingerprints")
+	}
+	if fingerprint("boom", nil, "/a") == fingerprint("boom", nil, "/b") {
+		t.Error("Expected the route in the fingerprint of a panic without frames")
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	expect(t, origin(parseStack([]byte(testStack))), "github.com/unrolled/recovery/zz_test.go:4")
+	expect(t, origin(nil), "")
+
+	file, line := splitOrigin("github.com/me/app/handlers/user.go:42")
+	expect(t, file, "github.com/me/app/handlers/user.go")
+	expect(t, line, 42)
+}
+
+func TestOriginLogged(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:         buf,
+		Format:      FormatJSON,
+		FrameFilter: func(frame Frame) bool { return false },
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	var rec jsonRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected a single JSON record, got error %v for [%s]", err, buf.String())
+	}
+
+	// The origin is found before the FrameFilter drops every frame.
+	expectContainsTrue(t, rec.Origin, "github.com/unrolled/recovery/recovery_test.go:")
+}
@@ -0,0 +1,345 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LogFormat selects how a recovered panic is rendered to the log output.
+type LogFormat int
+
+const (
+	// FormatText is the classic "Recovering from Panic" line followed by the raw stack dump. This is the default.
+	FormatText LogFormat = iota
+	// FormatJSON renders each panic as a single-line JSON object.
+	FormatJSON
+	// FormatLogfmt renders each panic as a single line of logfmt key=value pairs, with the stack escaped.
+	FormatLogfmt
+	// FormatGELF renders each panic as a GELF 1.1 JSON message for Graylog, with the stack as the full_message and the request details as additional fields. See GELFSink.
+	FormatGELF
+	// FormatECS renders each panic as a single-line Elastic Common Schema JSON object, for Kibana and Elastic APM.
+	FormatECS
+	// FormatCloudEvents renders each panic as a CloudEvents 1.0 event in the JSON structured mode, with the FormatJSON entry as its data. See CloudEventsSink.
+	FormatCloudEvents
+	// FormatGCP renders each panic as a single-line Google Cloud structured log entry, with the stack in the message and the trace of the request, for Cloud Run and GKE.
+	FormatGCP
+)
+
+// PanicRecord is a recovered panic as it is logged. It is what a Formatter renders. Its RequestID is blank unless IncludeRequestID is set, so no sink reports an id the logs do not hold.
+type PanicRecord struct {
+	*PanicInfo
+	// Message describes the event, like "Recovering from Panic".
+	Message string
+	// Prefix is the configured Prefix, without brackets.
+	Prefix string
+	// Panic is the panic value as a string, capped to MaxPanicValueBytes.
+	Panic string
+	// Method is the request method.
+	Method string
+	// Path is the request path.
+	Path string
+	// URL is the request URI, with LogRequestDetails.
+	URL string
+	// Proto is the request protocol, like "HTTP/1.1", with LogRequestDetails.
+	Proto string
+	// RemoteAddr is the network address of the client, with LogRequestDetails.
+	RemoteAddr string
+	// Route is the route pattern that matched the request, when known, with LogRequestDetails.
+	Route string
+	// TraceID is the trace id of the request, from the TraceFunc or else its traceparent or X-Cloud-Trace-Context header. Blank when there is none.
+	TraceID string
+	// SpanID is the span id of the request, in hex, from the same source as TraceID.
+	SpanID string
+	// TraceSampled reports whether the trace of the request is sampled.
+	TraceSampled bool
+	// Headers holds the request headers listed in LogHeaders, by canonical name. Multiple values are joined with commas.
+	Headers map[string]string
+	// Source holds the lines of source around each application frame, with SourceContext. It is blank otherwise.
+	Source string
+	// Count, on the aggregated entries of DedupWindow, is how many duplicates the entry stands for. It is 0 on regular entries.
+	Count int
+	// Status is the status code of the response the client got, or 0 when none was sent, like when the connection was aborted or a panic handler panicked.
+	Status int
+}
+
+// Formatter renders a panic record into the bytes written to `Out`. Each record is written with a single call, so the output should include its own trailing newline.
+type Formatter interface {
+	Format(rec *PanicRecord) []byte
+}
+
+// formatter returns the built-in Formatter for a LogFormat. FormatText records get the prefix and flags of the given logger, or none when it is nil.
+func (r *Recovery) formatter(format LogFormat, logger *log.Logger) Formatter {
+	switch format {
+	case FormatJSON:
+		return jsonFormatter{r}
+	case FormatLogfmt:
+		return logfmtFormatter{r}
+	case FormatGELF:
+		return newGELFFormatter(r)
+	case FormatECS:
+		return ecsFormatter{r}
+	case FormatCloudEvents:
+		return cloudEventsFormatter{r}
+	case FormatGCP:
+		return gcpFormatter{r}
+	default:
+		return textFormatter{r, logger}
+	}
+}
+
+// timestamp renders t in the TimeFormat, RFC 3339 by default, converted to UTC with the UTC option.
+func (r *Recovery) timestamp(t time.Time) string {
+	if r.opt.UTC {
+		t = t.UTC()
+	}
+
+	layout := r.opt.TimeFormat
+	if len(layout) == 0 {
+		layout = time.RFC3339
+	}
+
+	return t.Format(layout)
+}
+
+// scratchLogger returns a logger writing to buf with the prefix and flags of base. The UTC option adds log.LUTC, and with a TimeFormat the date and time flags give way to a timestamp of t in that format.
+func (r *Recovery) scratchLogger(buf *bytes.Buffer, base *log.Logger, t time.Time) *log.Logger {
+	prefix, flags := base.Prefix(), base.Flags()
+	if r.opt.UTC {
+		flags |= log.LUTC
+	}
+
+	const timeFlags = log.Ldate | log.Ltime | log.Lmicroseconds
+	if len(r.opt.TimeFormat) > 0 && flags&timeFlags != 0 {
+		flags &^= timeFlags
+
+		// The timestamp goes where the log package puts the date, which is before the prefix only with log.Lmsgprefix.
+		if flags&log.Lmsgprefix != 0 {
+			buf.WriteString(r.timestamp(t) + " ")
+		} else {
+			prefix += r.timestamp(t) + " "
+		}
+	}
+
+	return log.New(buf, prefix, flags)
+}
+
+// recordRequestID is the request id to render, if IncludeRequestID asks for it.
+func (r *Recovery) recordRequestID(rec *PanicRecord) string {
+	if r.opt.IncludeRequestID {
+		return rec.RequestID
+	}
+
+	return ""
+}
+
+// textFormatter renders the classic output, with the prefix and flags of a logger.
+type textFormatter struct {
+	r      *Recovery
+	logger *log.Logger
+}
+
+func (f textFormatter) Format(rec *PanicRecord) []byte {
+	// The classic line only mentions the severity when it was classified as something else than an error.
+	var attrs []string
+	if rec.Severity != SeverityError {
+		attrs = append(attrs, "level="+rec.Severity.String())
+	}
+	if requestID := f.r.recordRequestID(rec); len(requestID) > 0 {
+		attrs = append(attrs, "request_id="+requestID)
+	}
+	if rec.Count > 0 {
+		attrs = append(attrs, "count="+strconv.Itoa(rec.Count))
+	}
+
+	message := rec.Message
+	if len(attrs) > 0 {
+		message += " (" + strings.Join(attrs, " ") + ")"
+	}
+
+	text := fmt.Sprintf("%s: %s\n%s%s", message, rec.Panic, rec.requestText(), rec.Stack)
+	if len(rec.Source) > 0 {
+		text += "Source:\n" + rec.Source
+	}
+	if f.logger == nil {
+		// The plugged in logger renders its own prefix and timestamp.
+		return []byte(text)
+	}
+
+	// A scratch logger renders the prefix, date, and file flags exactly like the given logger. Call depth 3 skips this method and its caller, so log.Lshortfile reports where the panic was logged.
+	buf := getBuffer()
+	f.r.scratchLogger(buf, f.logger, rec.Time).Output(3, text)
+
+	return putBuffer(buf)
+}
+
+// jsonRecord is the shape of a FormatJSON log entry.
+type jsonRecord struct {
+	Time        string            `json:"time"`
+	Level       string            `json:"level"`
+	Fingerprint string            `json:"fingerprint"`
+	Origin      string            `json:"origin,omitempty"`
+	Count       int               `json:"count,omitempty"`
+	Prefix      string            `json:"prefix,omitempty"`
+	Message     string            `json:"message"`
+	RequestID   string            `json:"request_id,omitempty"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	SpanID      string            `json:"span_id,omitempty"`
+	Method      string            `json:"method,omitempty"`
+	Path        string            `json:"path,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Proto       string            `json:"proto,omitempty"`
+	RemoteAddr  string            `json:"remote_addr,omitempty"`
+	Route       string            `json:"route,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	Panic       string            `json:"panic"`
+	Stack       interface{}       `json:"stack"`
+	Source      string            `json:"source,omitempty"`
+}
+
+// jsonLine renders v as a newline terminated JSON object for a log output. Strings always marshal, but never lose the record over it: the line falls back to the message and the panic value.
+func jsonLine(rec *PanicRecord, v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return []byte(rec.Message + ": " + rec.Panic + "\n")
+	}
+
+	return append(b, '\n')
+}
+
+// jsonPayload renders v as the newline terminated JSON body of a post. When it cannot be marshalled it returns nil, so nothing is posted, as no text in its place would be a valid payload.
+func jsonPayload(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return append(b, '\n')
+}
+
+// jsonFormatter renders a panic as a newline terminated JSON object.
+type jsonFormatter struct {
+	r *Recovery
+}
+
+func (f jsonFormatter) Format(rec *PanicRecord) []byte {
+	return jsonLine(rec, f.r.newJSONRecord(rec))
+}
+
+// newJSONRecord returns the FormatJSON entry of a record.
+func (r *Recovery) newJSONRecord(rec *PanicRecord) jsonRecord {
+	doc := jsonRecord{
+		Time:        r.timestamp(rec.Time),
+		Level:       rec.Severity.String(),
+		Fingerprint: rec.Fingerprint,
+		Origin:      rec.Origin,
+		Count:       rec.Count,
+		Prefix:      rec.Prefix,
+		Message:     rec.Message,
+		RequestID:   r.recordRequestID(rec),
+		TraceID:     rec.TraceID,
+		SpanID:      rec.SpanID,
+		Method:      rec.Method,
+		Path:        rec.Path,
+		URL:         rec.URL,
+		Proto:       rec.Proto,
+		RemoteAddr:  rec.RemoteAddr,
+		Route:       rec.Route,
+		Headers:     rec.Headers,
+		Body:        string(rec.Body),
+		Panic:       rec.Panic,
+		Stack:       string(rec.Stack),
+		Source:      rec.Source,
+	}
+	if r.opt.JSONStackAsArray {
+		doc.Stack = stackLines(rec.Stack)
+	}
+
+	return doc
+}
+
+// logfmtFormatter renders a panic as a newline terminated logfmt line.
+type logfmtFormatter struct {
+	r *Recovery
+}
+
+func (f logfmtFormatter) Format(rec *PanicRecord) []byte {
+	var b strings.Builder
+
+	var count string
+	if rec.Count > 0 {
+		count = strconv.Itoa(rec.Count)
+	}
+
+	pairs := [][2]string{
+		{"time", f.r.timestamp(rec.Time)},
+		{"level", rec.Severity.String()},
+		{"fingerprint", rec.Fingerprint},
+		{"origin", rec.Origin},
+		{"count", count},
+		{"prefix", rec.Prefix},
+		{"message", rec.Message},
+		{"request_id", f.r.recordRequestID(rec)},
+		{"trace_id", rec.TraceID},
+		{"span_id", rec.SpanID},
+		{"method", rec.Method},
+		{"path", rec.Path},
+		{"url", rec.URL},
+		{"proto", rec.Proto},
+		{"remote_addr", rec.RemoteAddr},
+		{"route", rec.Route},
+	}
+	for _, name := range rec.headerNames() {
+		pairs = append(pairs, [2]string{"header." + strings.ToLower(name), rec.Headers[name]})
+	}
+	pairs = append(pairs, [2]string{"body", string(rec.Body)}, [2]string{"panic", rec.Panic}, [2]string{"stack", string(rec.Stack)}, [2]string{"source", rec.Source})
+
+	for _, pair := range pairs {
+		// Optional fields are left out rather than written empty.
+		if len(pair[1]) == 0 && pair[0] != "message" && pair[0] != "panic" && pair[0] != "stack" {
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(pair[0])
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(pair[1]))
+	}
+	b.WriteByte('\n')
+
+	return []byte(b.String())
+}
+
+// logfmtValue quotes v when it is empty or holds anything beyond plain printable characters.
+func logfmtValue(v string) string {
+	if len(v) == 0 {
+		return `""`
+	}
+
+	for _, c := range v {
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == utf8.RuneError || !unicode.IsPrint(c) {
+			return strconv.Quote(v)
+		}
+	}
+
+	return v
+}
+
+// stackLines splits a stack dump into its lines, without the indentation and trailing blank line.
+func stackLines(stack []byte) []string {
+	lines := strings.Split(strings.TrimRight(string(stack), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimLeft(line, "\t")
+	}
+
+	return lines
+}
@@ -0,0 +1,260 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatJSON(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Prefix: "testapp",
+		Out:    buf,
+		Format: FormatJSON,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	var rec jsonRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected a single JSON record, got error %v for [%s]", err, buf.String())
+	}
+
+	expect(t, rec.Panic, "this did not work")
+	expect(t, rec.Prefix, "testapp")
+	expect(t, rec.Message, "Recovering from Panic")
+	expect(t, rec.Method, "GET")
+	expect(t, rec.Path, "/foo")
+	expectContainsTrue(t, rec.Stack.(string), "src/net/http/server.go")
+	expectContainsFalse(t, buf.String(), "Recovering from Panic:")
+}
+
+func TestJSONFallbacks(t *testing.T) {
+	rec := &PanicRecord{Message: "Recovering from Panic", Panic: "boom", PanicInfo: &PanicInfo{}}
+	bad := map[string]interface{}{"ch": make(chan int)}
+
+	// A log line keeps the record as text, while a post sends nothing rather than text declared as JSON.
+	expect(t, string(jsonLine(rec, bad)), "Recovering from Panic: boom\n")
+	expect(t, jsonPayload(bad) == nil, true)
+	expect(t, string(jsonPayload(map[string]string{"a": "b"})), `{"a":"b"}`+"\n")
+}
+
+func TestFormatJSONStackAsArray(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:              buf,
+		Format:           FormatJSON,
+		JSONStackAsArray: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var rec struct {
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected a single JSON record, got error %v for [%s]", err, buf.String())
+	}
+
+	expectContainsTrue(t, rec.Stack[0], "goroutine ")
+	found := false
+	for _, line := range rec.Stack {
+		expectContainsFalse(t, line, "\n")
+		if strings.HasPrefix(line, "/") && strings.Contains(line, "src/net/http/server.go") {
+			found = true
+		}
+	}
+	expect(t, found, true)
+}
+
+func TestMultipleFormats(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:     buf,
+		Formats: []LogFormat{FormatText, FormatJSON},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	// Text comes first, so it opens the output.
+	out := buf.String()
+	expectContainsTrue(t, out, "Recovering from Panic: this did not work")
+
+	// The JSON record is the final line.
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	var rec jsonRecord
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &rec); err != nil {
+		t.Fatalf("Expected the last line to be a JSON record, got error %v for [%s]", err, lines[len(lines)-1])
+	}
+
+	expect(t, rec.Panic, "this did not work")
+	if strings.Index(out, "Recovering from Panic:") > strings.Index(out, `{"time"`) {
+		t.Errorf("Expected the text record before the JSON record: [%s]", out)
+	}
+}
+
+func TestFormatLogfmt(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:    buf,
+		Format: FormatLogfmt,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	out := buf.String()
+	expect(t, strings.Count(out, "\n"), 1)
+	expectContainsTrue(t, out, ` message="Recovering from Panic" method=GET path=/foo panic="this did not work" stack="goroutine `)
+	expectContainsTrue(t, out, `src/net/http/server.go`)
+	expectContainsTrue(t, out, `\n\t`)
+	expectContainsFalse(t, out, "prefix=")
+}
+
+func TestLogfmtValue(t *testing.T) {
+	expect(t, logfmtValue(""), `""`)
+	expect(t, logfmtValue("plain"), "plain")
+	expect(t, logfmtValue("/a/b?c"), "/a/b?c")
+	expect(t, logfmtValue("two words"), `"two words"`)
+	expect(t, logfmtValue("a=b"), `"a=b"`)
+	expect(t, logfmtValue(`say "hi"`), `"say \"hi\""`)
+	expect(t, logfmtValue("line\nbreak"), `"line\nbreak"`)
+}
+
+type upperFormatter struct{}
+
+func (upperFormatter) Format(rec *PanicRecord) []byte {
+	return []byte(strings.ToUpper(rec.Method+" "+rec.Path+" "+rec.Panic) + "\n")
+}
+
+func TestCustomFormatter(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:       buf,
+		Formatter: upperFormatter{},
+		Formats:   []LogFormat{FormatText, FormatJSON},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, buf.String(), "GET /FOO THIS DID NOT WORK\n")
+}
+
+func TestPanicRecord(t *testing.T) {
+	var got *PanicRecord
+	r := New(Options{
+		Prefix:             "testapp",
+		MaxPanicValueBytes: 4,
+		Formatter: formatterFunc(func(rec *PanicRecord) []byte {
+			got = rec
+			return nil
+		}),
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, got.Message, "Recovering from Panic")
+	expect(t, got.Prefix, "testapp")
+	expect(t, got.Panic, "this"+truncatedMarker)
+	expect(t, got.Value, interface{}("this did not work"))
+	expect(t, got.Method, "POST")
+	expect(t, got.Path, "/foo")
+	expectContainsTrue(t, string(got.Stack), "src/net/http/server.go")
+}
+
+type formatterFunc func(rec *PanicRecord) []byte
+
+func (f formatterFunc) Format(rec *PanicRecord) []byte {
+	return f(rec)
+}
+
+func TestTimeFormatJSON(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:        buf,
+		Format:     FormatJSON,
+		TimeFormat: time.RFC3339Nano,
+		UTC:        true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var rec jsonRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected a single JSON record, got error %v for [%s]", err, buf.String())
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, rec.Time)
+	if err != nil {
+		t.Fatalf("Expected an RFC 3339 timestamp, got %q", rec.Time)
+	}
+	expect(t, ts.Location(), time.UTC)
+	expectContainsTrue(t, rec.Time, "Z")
+}
+
+func TestTimeFormatText(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Prefix:     "testapp",
+		Out:        buf,
+		TimeFormat: "2006-01-02T15:04:05.000Z07:00",
+		UTC:        true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	line := strings.SplitN(buf.String(), "\n", 2)[0]
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		t.Fatalf("Expected a prefix and timestamp, got %q", line)
+	}
+	expect(t, fields[0], "[testapp]")
+	if _, err := time.Parse("2006-01-02T15:04:05.000Z07:00", fields[1]); err != nil {
+		t.Errorf("Expected a custom timestamp, got %q", fields[1])
+	}
+	expectContainsTrue(t, line, "Recovering from Panic: this did not work")
+}
+
+func TestTimeFormatDefault(t *testing.T) {
+	r := New(Options{})
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("EST", -5*60*60))
+
+	expect(t, r.timestamp(ts), "2024-05-06T07:08:09-05:00")
+
+	r = New(Options{UTC: true})
+	expect(t, r.timestamp(ts), "2024-05-06T12:08:09Z")
+}
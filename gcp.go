@@ -0,0 +1,208 @@
+package recovery
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gcpErrorEventType marks a log entry as an error event for Cloud Error Reporting.
+const gcpErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// gcpRecord is the shape of a FormatGCP log entry, following the structured logging of Google Cloud.
+type gcpRecord struct {
+	Type           string             `json:"@type"`
+	ServiceContext *gcpServiceContext `json:"serviceContext,omitempty"`
+	Context        *gcpErrorContext   `json:"context,omitempty"`
+	Severity       string             `json:"severity"`
+	Message        string             `json:"message"`
+	Time           string             `json:"time"`
+	Trace          string             `json:"logging.googleapis.com/trace,omitempty"`
+	SpanID         string             `json:"logging.googleapis.com/spanId,omitempty"`
+	TraceSampled   bool               `json:"logging.googleapis.com/trace_sampled,omitempty"`
+	Labels         map[string]string  `json:"logging.googleapis.com/labels,omitempty"`
+	Source         *gcpSource         `json:"logging.googleapis.com/sourceLocation,omitempty"`
+	HTTPRequest    *gcpHTTPRequest    `json:"httpRequest,omitempty"`
+}
+
+type gcpServiceContext struct {
+	Service string `json:"service"`
+	Version string `json:"version,omitempty"`
+}
+
+// gcpErrorContext is the context of an error event, which Error Reporting shows with it.
+type gcpErrorContext struct {
+	HTTPRequest    *gcpErrorHTTPRequest `json:"httpRequest,omitempty"`
+	ReportLocation *gcpReportLocation   `json:"reportLocation,omitempty"`
+}
+
+type gcpErrorHTTPRequest struct {
+	Method             string `json:"method"`
+	URL                string `json:"url"`
+	UserAgent          string `json:"userAgent,omitempty"`
+	Referrer           string `json:"referrer,omitempty"`
+	ResponseStatusCode int    `json:"responseStatusCode,omitempty"`
+	RemoteIP           string `json:"remoteIp,omitempty"`
+}
+
+type gcpReportLocation struct {
+	FilePath     string `json:"filePath"`
+	LineNumber   int    `json:"lineNumber"`
+	FunctionName string `json:"functionName"`
+}
+
+type gcpSource struct {
+	File string `json:"file"`
+	Line string `json:"line,omitempty"`
+}
+
+type gcpHTTPRequest struct {
+	RequestMethod string `json:"requestMethod,omitempty"`
+	RequestURL    string `json:"requestUrl,omitempty"`
+	Status        int    `json:"status,omitempty"`
+	RemoteIP      string `json:"remoteIp,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// gcpFormatter renders a panic as a newline terminated Google Cloud structured log entry.
+type gcpFormatter struct {
+	r *Recovery
+}
+
+func (f gcpFormatter) Format(rec *PanicRecord) []byte {
+	// The stack goes in the message, where Cloud Logging and Error Reporting look for it. The @type has Error Reporting take the entry even when it does not recognize the stack.
+	doc := gcpRecord{
+		Type:     gcpErrorEventType,
+		Severity: gcpSeverity(rec.Severity),
+		Message:  rec.Message + ": " + rec.Panic + "\n\n" + string(rec.Stack),
+		Time:     rec.Time.UTC().Format(time.RFC3339Nano),
+		Labels:   map[string]string{},
+	}
+
+	if len(rec.TraceID) > 0 {
+		doc.Trace = rec.TraceID
+		if project := f.r.gcpProjectID(); len(project) > 0 {
+			doc.Trace = "projects/" + project + "/traces/" + rec.TraceID
+		}
+		doc.SpanID = rec.SpanID
+		doc.TraceSampled = rec.TraceSampled
+	}
+
+	if service := f.r.gcpService(); len(service) > 0 {
+		doc.ServiceContext = &gcpServiceContext{Service: service, Version: f.r.gcpServiceVersion()}
+	}
+
+	if len(rec.Origin) > 0 {
+		file, line := splitOrigin(rec.Origin)
+		doc.Source = &gcpSource{File: file, Line: strconv.Itoa(line)}
+	}
+
+	// Error Reporting groups events without a stack it can parse by where they were reported.
+	doc.Context = &gcpErrorContext{}
+	if app := appFrames(rec.Frames, 1); len(app) > 0 {
+		doc.Context.ReportLocation = &gcpReportLocation{
+			FilePath:     trimmedPath(app[0].Func, app[0].File),
+			LineNumber:   app[0].Line,
+			FunctionName: app[0].Func,
+		}
+	}
+
+	url := rec.URL
+	if len(url) == 0 {
+		url = rec.Path
+	}
+	if len(rec.Method) > 0 {
+		doc.HTTPRequest = &gcpHTTPRequest{
+			RequestMethod: rec.Method,
+			RequestURL:    url,
+			Status:        rec.Status,
+			RemoteIP:      remoteIP(rec.RemoteAddr),
+			Protocol:      rec.Proto,
+		}
+		doc.Context.HTTPRequest = &gcpErrorHTTPRequest{
+			Method:             rec.Method,
+			URL:                url,
+			UserAgent:          rec.Headers["User-Agent"],
+			Referrer:           rec.Headers["Referer"],
+			ResponseStatusCode: rec.Status,
+			RemoteIP:           remoteIP(rec.RemoteAddr),
+		}
+	}
+
+	for name, value := range map[string]string{
+		"fingerprint": rec.Fingerprint,
+		"prefix":      rec.Prefix,
+		"request_id":  f.r.recordRequestID(rec),
+		"route":       rec.Route,
+		"body":        string(rec.Body),
+	} {
+		if len(value) > 0 {
+			doc.Labels[name] = value
+		}
+	}
+	if rec.Count > 0 {
+		doc.Labels["count"] = strconv.Itoa(rec.Count)
+	}
+	for name, value := range rec.Headers {
+		doc.Labels["header_"+strings.ToLower(strings.ReplaceAll(name, "-", "_"))] = value
+	}
+
+	return jsonLine(rec, doc)
+}
+
+// remoteIP returns the host of a remote address, without its port.
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return addr
+}
+
+// gcpProjectID returns the GCPProjectID, or the project of the GOOGLE_CLOUD_PROJECT environment variable.
+func (r *Recovery) gcpProjectID() string {
+	if len(r.opt.GCPProjectID) > 0 {
+		return r.opt.GCPProjectID
+	}
+
+	return os.Getenv("GOOGLE_CLOUD_PROJECT")
+}
+
+// gcpService returns the GCPService, or the Cloud Run service of the K_SERVICE environment variable, or else the Prefix.
+func (r *Recovery) gcpService() string {
+	if len(r.opt.GCPService) > 0 {
+		return r.opt.GCPService
+	}
+	if service := os.Getenv("K_SERVICE"); len(service) > 0 {
+		return service
+	}
+
+	return r.opt.Prefix
+}
+
+// gcpServiceVersion returns the GCPServiceVersion, or the Cloud Run revision of the K_REVISION environment variable.
+func (r *Recovery) gcpServiceVersion() string {
+	if len(r.opt.GCPServiceVersion) > 0 {
+		return r.opt.GCPServiceVersion
+	}
+
+	return os.Getenv("K_REVISION")
+}
+
+// gcpSeverity maps a Severity to the LogSeverity of Google Cloud.
+func gcpSeverity(s Severity) string {
+	switch s {
+	case SeverityDebug:
+		return "DEBUG"
+	case SeverityInfo:
+		return "INFO"
+	case SeverityWarning:
+		return "WARNING"
+	case SeverityCritical:
+		return "CRITICAL"
+	default:
+		return "ERROR"
+	}
+}
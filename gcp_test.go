@@ -0,0 +1,106 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormatGCP(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:               buf,
+		Format:            FormatGCP,
+		GCPProjectID:      "my-project",
+		LogRequestDetails: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var doc gcpRecord
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a single GCP entry, got error %v for [%s]", err, buf.String())
+	}
+
+	expect(t, doc.Severity, "ERROR")
+	expect(t, strings.HasPrefix(doc.Message, "Recovering from Panic: this did not work\n\ngoroutine "), true)
+	expectContainsTrue(t, doc.Message, "src/net/http/server.go")
+	expect(t, doc.Trace, "projects/my-project/traces/105445aa7843bc8bf206b12000100000")
+	expect(t, doc.SpanID, "0000000000000001")
+	expect(t, doc.TraceSampled, true)
+	expect(t, doc.HTTPRequest.RequestMethod, "GET")
+	expect(t, doc.HTTPRequest.RequestURL, "/foo")
+	expect(t, doc.HTTPRequest.Status, http.StatusInternalServerError)
+	expect(t, doc.HTTPRequest.RemoteIP, "10.0.0.1")
+	expect(t, len(doc.Labels["fingerprint"]), 16)
+	expectContainsTrue(t, buf.String(), `"logging.googleapis.com/trace":`)
+}
+
+func TestFormatGCPProjectFromEnv(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "env-project")
+
+	r := New(Options{})
+	expect(t, r.gcpProjectID(), "env-project")
+}
+
+func TestGCPSeverity(t *testing.T) {
+	expect(t, gcpSeverity(SeverityDebug), "DEBUG")
+	expect(t, gcpSeverity(SeverityInfo), "INFO")
+	expect(t, gcpSeverity(SeverityWarning), "WARNING")
+	expect(t, gcpSeverity(SeverityError), "ERROR")
+	expect(t, gcpSeverity(SeverityCritical), "CRITICAL")
+}
+
+func TestFormatGCPErrorReporting(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:               buf,
+		Format:            FormatGCP,
+		GCPService:        "checkout",
+		GCPServiceVersion: "v1.2.3",
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var doc gcpRecord
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a single GCP entry, got error %v for [%s]", err, buf.String())
+	}
+
+	expect(t, doc.Type, gcpErrorEventType)
+	expect(t, doc.ServiceContext.Service, "checkout")
+	expect(t, doc.ServiceContext.Version, "v1.2.3")
+	expect(t, doc.Context.HTTPRequest.Method, "GET")
+	expect(t, doc.Context.HTTPRequest.URL, "/foo")
+	expect(t, doc.Context.HTTPRequest.UserAgent, "curl/8.0")
+	expect(t, doc.Context.HTTPRequest.ResponseStatusCode, http.StatusInternalServerError)
+	expect(t, doc.Context.ReportLocation.FilePath, "github.com/unrolled/recovery/recovery_test.go")
+	expect(t, strings.HasPrefix(doc.Context.ReportLocation.FunctionName, "github.com/unrolled/recovery.init.func"), true)
+	expect(t, doc.Context.ReportLocation.LineNumber > 0, true)
+}
+
+func TestGCPServiceFromEnv(t *testing.T) {
+	t.Setenv("K_SERVICE", "env-service")
+	t.Setenv("K_REVISION", "env-service-00001")
+
+	r := New(Options{Prefix: "myapp"})
+	expect(t, r.gcpService(), "env-service")
+	expect(t, r.gcpServiceVersion(), "env-service-00001")
+
+	t.Setenv("K_SERVICE", "")
+	expect(t, r.gcpService(), "myapp")
+}
@@ -0,0 +1,195 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// gelfChunkSize is the largest GELF payload sent in one UDP datagram, which fits the MTU of most networks.
+	gelfChunkSize = 1420
+	// gelfMaxChunks is how many chunks Graylog puts back together.
+	gelfMaxChunks = 128
+)
+
+// errGELFTooLarge is returned for a UDP message that does not fit in gelfMaxChunks chunks.
+var errGELFTooLarge = errors.New("recovery: GELF message too large")
+
+// gelfFormatter renders a panic as a GELF 1.1 message.
+type gelfFormatter struct {
+	r    *Recovery
+	host string
+}
+
+func newGELFFormatter(r *Recovery) gelfFormatter {
+	return gelfFormatter{r, hostname()}
+}
+
+// hostname returns the host name reported to log and error tracking services, or "localhost" when it is unknown.
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil || len(host) == 0 {
+		return "localhost"
+	}
+
+	return host
+}
+
+func (f gelfFormatter) Format(rec *PanicRecord) []byte {
+	doc := map[string]interface{}{
+		"version":       "1.1",
+		"host":          f.host,
+		"short_message": rec.Message + ": " + rec.Panic,
+		"full_message":  string(rec.Stack),
+		"timestamp":     json.Number(strconv.FormatFloat(float64(rec.Time.UnixNano())/1e9, 'f', 3, 64)),
+		"level":         gelfLevel(rec.Severity),
+	}
+
+	for name, value := range f.r.opt.GELFFields {
+		doc["_"+name] = value
+	}
+
+	fields := [][2]string{
+		{"fingerprint", rec.Fingerprint},
+		{"origin", rec.Origin},
+		{"prefix", rec.Prefix},
+		{"request_id", f.r.recordRequestID(rec)},
+		{"method", rec.Method},
+		{"path", rec.Path},
+		{"url", rec.URL},
+		{"proto", rec.Proto},
+		{"remote_addr", rec.RemoteAddr},
+		{"route", rec.Route},
+		{"body", string(rec.Body)},
+		{"panic", rec.Panic},
+	}
+	for _, name := range rec.headerNames() {
+		fields = append(fields, [2]string{"header_" + strings.ToLower(strings.ReplaceAll(name, "-", "_")), rec.Headers[name]})
+	}
+	for _, field := range fields {
+		if len(field[1]) > 0 {
+			doc["_"+field[0]] = field[1]
+		}
+	}
+	if rec.Count > 0 {
+		doc["_count"] = rec.Count
+	}
+	if rec.Status > 0 {
+		doc["_status"] = rec.Status
+	}
+
+	return jsonPayload(doc)
+}
+
+// gelfLevel maps a Severity to the syslog level GELF uses.
+func gelfLevel(s Severity) int {
+	switch s {
+	case SeverityDebug:
+		return 7
+	case SeverityInfo:
+		return 6
+	case SeverityWarning:
+		return 4
+	case SeverityCritical:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// GELFWriter sends each write to Graylog as one GELF message. Over UDP, messages beyond a datagram are chunked; over TCP, they are terminated with a null byte.
+type GELFWriter struct {
+	mu   sync.Mutex
+	conn net.Conn
+	udp  bool
+}
+
+// NewGELFWriter dials a Graylog GELF input. The network is "udp" or "tcp" and addr is its host and port, like "graylog:12201".
+func NewGELFWriter(network, addr string) (*GELFWriter, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	_, udp := conn.(*net.UDPConn)
+
+	return &GELFWriter{conn: conn, udp: udp}, nil
+}
+
+// Write sends p, without its trailing newline, as one GELF message.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	message := bytes.TrimSuffix(p, []byte("\n"))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if w.udp {
+		err = w.writeChunks(message)
+	} else {
+		_, err = w.conn.Write(append(message[:len(message):len(message)], 0))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// writeChunks sends message in one datagram, or chunked with the GELF chunk header when it is larger.
+func (w *GELFWriter) writeChunks(message []byte) error {
+	if len(message) <= gelfChunkSize {
+		_, err := w.conn.Write(message)
+		return err
+	}
+
+	count := (len(message) + gelfChunkSize - 1) / gelfChunkSize
+	if count > gelfMaxChunks {
+		return errGELFTooLarge
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * gelfChunkSize
+		if end > len(message) {
+			end = len(message)
+		}
+
+		chunk := make([]byte, 0, 12+end-i*gelfChunkSize)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, message[i*gelfChunkSize:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the connection to Graylog.
+func (w *GELFWriter) Close() error {
+	return w.conn.Close()
+}
+
+// GELFSink dials a Graylog GELF input and returns a Sink that sends each panic record to it as a FormatGELF message. The network is "udp" or "tcp". The Out of the Sink is the *GELFWriter, to close on shutdown.
+func GELFSink(network, addr string) (Sink, error) {
+	w, err := NewGELFWriter(network, addr)
+	if err != nil {
+		return Sink{}, err
+	}
+
+	return Sink{Out: w, Format: FormatGELF}, nil
+}
@@ -0,0 +1,159 @@
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatGELF(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Prefix:     "testapp",
+		Out:        buf,
+		Format:     FormatGELF,
+		GELFFields: map[string]string{"env": "staging"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a single GELF message, got error %v for [%s]", err, buf.String())
+	}
+
+	expect(t, doc["version"], "1.1")
+	expect(t, doc["short_message"], "Recovering from Panic: this did not work")
+	expectContainsTrue(t, doc["full_message"].(string), "src/net/http/server.go")
+	expect(t, doc["level"], float64(3))
+	expect(t, doc["_env"], "staging")
+	expect(t, doc["_prefix"], "testapp")
+	expect(t, doc["_method"], "GET")
+	expect(t, doc["_path"], "/foo")
+	expect(t, doc["_status"], float64(http.StatusInternalServerError))
+	expect(t, len(doc["host"].(string)) > 0, true)
+	expect(t, doc["timestamp"].(float64) > 0, true)
+}
+
+func TestGELFLevel(t *testing.T) {
+	expect(t, gelfLevel(SeverityDebug), 7)
+	expect(t, gelfLevel(SeverityInfo), 6)
+	expect(t, gelfLevel(SeverityWarning), 4)
+	expect(t, gelfLevel(SeverityError), 3)
+	expect(t, gelfLevel(SeverityCritical), 2)
+}
+
+func TestGELFSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen for GELF messages: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := GELFSink("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Out.(*GELFWriter).Close()
+
+	r := New(Options{
+		Sinks: []Sink{sink},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// The stack makes the message larger than one chunk.
+	var message []byte
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n < 12 || buf[0] != 0x1e || buf[1] != 0x0f {
+			message = append(message, buf[:n]...)
+			break
+		}
+		message = append(message, buf[12:n]...)
+		if int(buf[10]) == int(buf[11])-1 {
+			break
+		}
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(message, &doc); err != nil {
+		t.Fatalf("Expected a GELF message, got error %v for [%s]", err, message)
+	}
+	expect(t, doc["short_message"], "Recovering from Panic: this did not work")
+}
+
+func TestGELFSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen for GELF messages: %v", err)
+	}
+	defer ln.Close()
+
+	sink, err := GELFSink("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Out.(*GELFWriter).Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := New(Options{
+		Sinks: []Sink{sink},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	message, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(message[:len(message)-1], &doc); err != nil {
+		t.Fatalf("Expected a GELF message, got error %v for [%s]", err, message)
+	}
+	expect(t, doc["short_message"], "Recovering from Panic: this did not work")
+	expectContainsFalse(t, string(message), "\n")
+}
+
+func TestGELFWriterTooLarge(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen for GELF messages: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := NewGELFWriter("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	_, err = w.Write([]byte(strings.Repeat("x", gelfChunkSize*gelfMaxChunks+1)))
+	expect(t, err, errGELFTooLarge)
+}
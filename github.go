@@ -0,0 +1,191 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultGitHubURL is the GitHub REST API endpoint.
+	defaultGitHubURL = "https://api.github.com"
+	// maxGitHubTitle keeps issue titles readable in lists; GitHub takes 256 characters.
+	maxGitHubTitle = 120
+	// maxGitHubBody is the longest issue or comment body GitHub takes.
+	maxGitHubBody = 65536
+)
+
+// GitHubOptions configures a GitHubSink.
+type GitHubOptions struct {
+	// Token is a token allowed to read and write the issues of the repository.
+	Token string
+	// Repo is the repository the issues are filed in, like "owner/name".
+	Repo string
+	// Labels are added to the issues opened. Default is nil (no labels).
+	Labels []string
+	// BaseURL is the REST API endpoint, like "https://github.example.com/api/v3" for GitHub Enterprise Server. Default is "https://api.github.com".
+	BaseURL string
+	// Client, if set, sends the requests, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// githubReport is what the githubFormatter hands the githubWriter for one new fingerprint.
+type githubReport struct {
+	Fingerprint string `json:"fingerprint"`
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+}
+
+// githubFormatter renders the first panic of each fingerprint as a githubReport, and nothing for the ones after.
+type githubFormatter struct {
+	seen *fingerprintSet
+}
+
+func (f githubFormatter) Format(rec *PanicRecord) []byte {
+	if !f.seen.add(rec.Fingerprint) {
+		return nil
+	}
+
+	title := "Panic: " + rec.Panic
+	if len(rec.Method) > 0 {
+		where := rec.Route
+		if len(where) == 0 {
+			where = rec.Method + " " + rec.Path
+		}
+		title = "Panic in " + where + ": " + rec.Panic
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "```\n%s\n```\n\n", rec.Panic)
+	b.WriteString("| | |\n|---|---|\n")
+	for _, field := range [][2]string{
+		{"Time", rec.Time.UTC().Format(time.RFC3339)},
+		{"Request", strings.TrimSpace(rec.Method + " " + rec.Path)},
+		{"Route", rec.Route},
+		{"Request ID", rec.RequestID},
+		{"Severity", rec.Severity.String()},
+		{"Origin", rec.Origin},
+		{"Fingerprint", rec.Fingerprint},
+	} {
+		if len(field[1]) > 0 {
+			fmt.Fprintf(&b, "| %s | `%s` |\n", field[0], strings.ReplaceAll(field[1], "|", `\|`))
+		}
+	}
+	if request := rec.requestText(); len(request) > 0 {
+		fmt.Fprintf(&b, "\n```\n%s```\n", request)
+	}
+	fmt.Fprintf(&b, "\n```\n%s\n```\n", bytes.TrimSpace(rec.Stack))
+
+	report := githubReport{
+		Fingerprint: rec.Fingerprint,
+		Title:       truncate(strings.Join(strings.Fields(title), " "), maxGitHubTitle),
+		Body:        truncate(b.String(), maxGitHubBody),
+	}
+
+	return jsonPayload(report)
+}
+
+// githubWriter files the reports written to it, commenting on the issue of their fingerprint when there is one, and opening one otherwise.
+type githubWriter struct {
+	opt  GitHubOptions
+	seen *fingerprintSet
+}
+
+func (w *githubWriter) Write(p []byte) (int, error) {
+	var report githubReport
+	if err := json.Unmarshal(p, &report); err != nil {
+		return 0, err
+	}
+
+	if err := w.file(report); err != nil {
+		w.seen.remove(report.Fingerprint)
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// file comments on the issue of the report, or opens it.
+func (w *githubWriter) file(report githubReport) error {
+	if len(report.Fingerprint) > 0 {
+		var found struct {
+			Items []struct {
+				Number int `json:"number"`
+			} `json:"items"`
+		}
+		q := fmt.Sprintf("%q repo:%s is:issue in:body", report.Fingerprint, w.opt.Repo)
+		if err := w.do(http.MethodGet, "/search/issues?q="+url.QueryEscape(q), nil, &found); err != nil {
+			return err
+		}
+		if len(found.Items) > 0 {
+			path := fmt.Sprintf("/repos/%s/issues/%d/comments", w.opt.Repo, found.Items[0].Number)
+			return w.do(http.MethodPost, path, map[string]interface{}{"body": report.Body}, nil)
+		}
+	}
+
+	issue := map[string]interface{}{"title": report.Title, "body": report.Body}
+	if len(w.opt.Labels) > 0 {
+		issue["labels"] = w.opt.Labels
+	}
+
+	return w.do(http.MethodPost, "/repos/"+w.opt.Repo+"/issues", issue, nil)
+}
+
+// do sends one API request with a JSON body, when in is not nil, and decodes the JSON response into out, when it is not nil.
+func (w *githubWriter) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, w.opt.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+w.opt.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := w.opt.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		io.Copy(io.Discard, res.Body)
+		return fmt.Errorf("recovery: GitHub endpoint returned %s", res.Status)
+	}
+	if out == nil {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// GitHubSink returns a Sink that files a GitHub issue the first time a panic fingerprint is seen, with the panic value, the request, and the stack, for internal tools that have no error tracker. When an issue holding the fingerprint exists already, like one filed before a restart, the panic is added to it as a comment instead, closed issues included, so regressions show up on their old issue. Later panics of a fingerprint are not filed again by the process. Every request happens as the panic is logged, so consider AsyncLogging.
+func GitHubSink(opt GitHubOptions) Sink {
+	if len(opt.BaseURL) == 0 {
+		opt.BaseURL = defaultGitHubURL
+	}
+	opt.BaseURL = strings.TrimSuffix(opt.BaseURL, "/")
+	if opt.Client == nil {
+		opt.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	seen := &fingerprintSet{fingerprints: map[string]bool{}}
+	return Sink{Out: &githubWriter{opt: opt, seen: seen}, Formatter: githubFormatter{seen}}
+}
@@ -0,0 +1,114 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// githubServer fakes the issues of one repository, with a search matching the bodies holding the query.
+type githubServer struct {
+	mu       sync.Mutex
+	issues   []map[string]interface{}
+	comments map[string][]string
+	auth     string
+	fail     bool
+}
+
+func (s *githubServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auth = req.Header.Get("Authorization")
+	if s.fail {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	var in map[string]interface{}
+	if req.Method == http.MethodPost {
+		b, _ := io.ReadAll(req.Body)
+		json.Unmarshal(b, &in)
+	}
+
+	switch {
+	case req.URL.Path == "/search/issues":
+		fingerprint := strings.Trim(strings.Fields(req.URL.Query().Get("q"))[0], `"`)
+		items := []map[string]interface{}{}
+		for i, issue := range s.issues {
+			if strings.Contains(issue["body"].(string), fingerprint) {
+				items = append(items, map[string]interface{}{"number": i + 1})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	case req.URL.Path == "/repos/owner/app/issues":
+		s.issues = append(s.issues, in)
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasSuffix(req.URL.Path, "/comments"):
+		s.comments[req.URL.Path] = append(s.comments[req.URL.Path], in["body"].(string))
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGitHubSink(t *testing.T) {
+	gh := &githubServer{comments: map[string][]string{}}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	opt := GitHubOptions{Token: "secret", Repo: "owner/app", Labels: []string{"panic"}, BaseURL: srv.URL + "/"}
+	r := New(Options{Sinks: []Sink{GitHubSink(opt)}, LogRequestDetails: true, IncludeRequestID: true})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	// The second panic has a known fingerprint, so only the first is filed.
+	expect(t, len(gh.issues), 1)
+	expect(t, len(gh.comments), 0)
+	expect(t, gh.auth, "Bearer secret")
+
+	issue := gh.issues[0]
+	expect(t, issue["title"], "Panic in GET /foo: this did not work")
+	expect(t, issue["labels"].([]interface{})[0], "panic")
+	body := issue["body"].(string)
+	expectContainsTrue(t, body, "this did not work")
+	expectContainsTrue(t, body, "| Request ID | `abc123` |")
+	expectContainsTrue(t, body, "Request: GET /foo")
+	expectContainsTrue(t, body, "recovery_test.go")
+
+	// A new process does not know the fingerprint, and comments on its issue.
+	r = New(Options{Sinks: []Sink{GitHubSink(opt)}})
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expect(t, len(gh.issues), 1)
+	expect(t, len(gh.comments["/repos/owner/app/issues/1/comments"]), 1)
+}
+
+func TestGitHubSinkError(t *testing.T) {
+	gh := &githubServer{comments: map[string][]string{}, fail: true}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	sink := GitHubSink(GitHubOptions{Token: "secret", Repo: "owner/app", BaseURL: srv.URL})
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Fingerprint: "abc"}, Panic: "oops"}
+
+	_, err := sink.Out.Write(sink.Formatter.Format(rec))
+	expect(t, err.Error(), "recovery: GitHub endpoint returned 502 Bad Gateway")
+
+	// A fingerprint failing to be filed is tried again on its next panic.
+	gh.fail = false
+	p := sink.Formatter.Format(rec)
+	expect(t, len(p) > 0, true)
+	if _, err := sink.Out.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, gh.issues[0]["title"], "Panic: oops")
+	expect(t, len(sink.Formatter.Format(rec)), 0)
+}
@@ -0,0 +1,149 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultHoneybadgerURL is the Honeybadger API.
+const defaultHoneybadgerURL = "https://api.honeybadger.io"
+
+// HoneybadgerOptions configures a HoneybadgerSink.
+type HoneybadgerOptions struct {
+	// APIKey is the API key of the project.
+	APIKey string
+	// Environment is the environment the notices are reported in, like "production". Default is blank.
+	Environment string
+	// BaseURL is the API endpoint, like "https://eu-api.honeybadger.io" for the EU region. Default is "https://api.honeybadger.io".
+	BaseURL string
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// honeybadgerNotice is the payload of a Honeybadger notice.
+type honeybadgerNotice struct {
+	Notifier honeybadgerNotifier `json:"notifier"`
+	Error    honeybadgerError    `json:"error"`
+	Request  honeybadgerRequest  `json:"request"`
+	Server   honeybadgerServer   `json:"server"`
+}
+
+type honeybadgerNotifier struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Version  string `json:"version"`
+	Language string `json:"language"`
+}
+
+type honeybadgerError struct {
+	Class       string             `json:"class"`
+	Message     string             `json:"message"`
+	Backtrace   []honeybadgerFrame `json:"backtrace"`
+	Fingerprint string             `json:"fingerprint,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+}
+
+type honeybadgerFrame struct {
+	Number string `json:"number"`
+	File   string `json:"file"`
+	Method string `json:"method"`
+}
+
+type honeybadgerRequest struct {
+	URL     string            `json:"url,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+	CGIData map[string]string `json:"cgi_data,omitempty"`
+	Context map[string]string `json:"context,omitempty"`
+}
+
+type honeybadgerServer struct {
+	EnvironmentName string `json:"environment_name,omitempty"`
+	Hostname        string `json:"hostname"`
+}
+
+// honeybadgerFormatter renders a panic as a Honeybadger notice.
+type honeybadgerFormatter struct {
+	environment string
+	host        string
+}
+
+func (f honeybadgerFormatter) Format(rec *PanicRecord) []byte {
+	// Honeybadger lists the innermost call first, like the frames are.
+	backtrace := make([]honeybadgerFrame, 0, len(rec.Frames))
+	for _, frame := range rec.Frames {
+		backtrace = append(backtrace, honeybadgerFrame{Number: strconv.Itoa(frame.Line), File: frame.File, Method: frame.Func})
+	}
+
+	notice := honeybadgerNotice{
+		Notifier: honeybadgerNotifier{
+			Name:     "unrolled/recovery",
+			URL:      "https://github.com/unrolled/recovery",
+			Version:  "1",
+			Language: "go",
+		},
+		Error: honeybadgerError{
+			Class:       fmt.Sprintf("%T", rec.Value),
+			Message:     rec.Panic,
+			Backtrace:   backtrace,
+			Fingerprint: rec.Fingerprint,
+			Tags:        []string{"panic", rec.Severity.String()},
+		},
+		Request: honeybadgerRequest{
+			Params:  map[string]string{},
+			CGIData: map[string]string{},
+			Context: map[string]string{},
+		},
+		Server: honeybadgerServer{EnvironmentName: f.environment, Hostname: f.host},
+	}
+
+	notice.Request.URL = rec.URL
+	if len(notice.Request.URL) == 0 {
+		notice.Request.URL = rec.Path
+	}
+
+	// The logged URL has the ScrubParams redacted already.
+	if u, err := url.ParseRequestURI(rec.URL); err == nil {
+		for name, values := range u.Query() {
+			notice.Request.Params[name] = strings.Join(values, ",")
+		}
+	}
+
+	for name, value := range map[string]string{
+		"REQUEST_METHOD":  rec.Method,
+		"SERVER_PROTOCOL": rec.Proto,
+		"REMOTE_ADDR":     remoteIP(rec.RemoteAddr),
+	} {
+		if len(value) > 0 {
+			notice.Request.CGIData[name] = value
+		}
+	}
+	for name, value := range rec.Headers {
+		notice.Request.CGIData["HTTP_"+strings.ToUpper(strings.ReplaceAll(name, "-", "_"))] = value
+	}
+
+	for name, value := range map[string]string{
+		"request_id": rec.RequestID,
+		"route":      rec.Route,
+		"origin":     rec.Origin,
+		"trace_id":   rec.TraceID,
+		"body":       string(rec.Body),
+	} {
+		if len(value) > 0 {
+			notice.Request.Context[name] = value
+		}
+	}
+
+	return jsonPayload(notice)
+}
+
+// HoneybadgerSink returns a Sink that reports each panic to Honeybadger as a notice, with the backtrace of the goroutine that panicked, the request params and headers, scrubbed like the logs are, and the request ID and route as context. Notices are grouped by the panic fingerprint. Every post happens as the panic is logged, so consider AsyncLogging.
+func HoneybadgerSink(opt HoneybadgerOptions) Sink {
+	w := newPostWriter(opt.Client, apiEndpoint(opt.BaseURL, defaultHoneybadgerURL, "/v1/notices"), "application/json", "Honeybadger")
+	w.header.Set("X-API-Key", opt.APIKey)
+	w.header.Set("Accept", "application/json")
+
+	return Sink{Out: w, Formatter: honeybadgerFormatter{opt.Environment, hostname()}}
+}
@@ -0,0 +1,62 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHoneybadgerSink(t *testing.T) {
+	notices := make(chan []byte, 1)
+	var key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key = req.Header.Get("X-API-Key")
+		b, _ := io.ReadAll(req.Body)
+		notices <- b
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	sink := HoneybadgerSink(HoneybadgerOptions{APIKey: "secret", Environment: "staging", BaseURL: srv.URL, Client: srv.Client()})
+
+	r := New(Options{
+		Sinks:             []Sink{sink},
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+		IncludeRequestID:  true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo?page=2&token=abc", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var notice honeybadgerNotice
+	if err := json.Unmarshal(<-notices, &notice); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, key, "secret")
+
+	expect(t, notice.Error.Class, "string")
+	expect(t, notice.Error.Message, "this did not work")
+	expect(t, len(notice.Error.Fingerprint) > 0, true)
+	expect(t, notice.Server.EnvironmentName, "staging")
+	expect(t, notice.Request.Params["page"], "2")
+	expect(t, notice.Request.Params["token"], "[REDACTED]")
+	expect(t, notice.Request.CGIData["REQUEST_METHOD"], "GET")
+	expect(t, notice.Request.CGIData["HTTP_USER_AGENT"], "curl/8.0")
+	expect(t, notice.Request.Context["request_id"], "abc123")
+
+	var found bool
+	for _, frame := range notice.Error.Backtrace {
+		if strings.HasSuffix(frame.File, "recovery_test.go") {
+			found = len(frame.Number) > 0
+			break
+		}
+	}
+	expect(t, found, true)
+}
@@ -0,0 +1,85 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultHoneycombURL is the Honeycomb API.
+const defaultHoneycombURL = "https://api.honeycomb.io"
+
+// HoneycombOptions configures a HoneycombSink.
+type HoneycombOptions struct {
+	// APIKey is an ingest key of the environment.
+	APIKey string
+	// Dataset is the dataset the events are sent to.
+	Dataset string
+	// BaseURL is the API endpoint, like "https://api.eu1.honeycomb.io" for the EU region. Default is "https://api.honeycomb.io".
+	BaseURL string
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// honeycombEvent is an event of a Honeycomb batch.
+type honeycombEvent struct {
+	Time string                 `json:"time"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// honeycombFormatter renders a panic as a batch of one Honeycomb event, the fields named after the OpenTelemetry conventions, so they line up with the spans of the requests.
+type honeycombFormatter struct {
+	host string
+}
+
+func (f honeycombFormatter) Format(rec *PanicRecord) []byte {
+	data := map[string]interface{}{
+		"name":                 "panic",
+		"error":                true,
+		"exception.type":       fmt.Sprintf("%T", rec.Value),
+		"exception.message":    rec.Panic,
+		"exception.stacktrace": string(rec.Stack),
+		"recovery.severity":    rec.Severity.String(),
+		"host.name":            f.host,
+	}
+	for name, value := range map[string]string{
+		"http.request.method":  rec.Method,
+		"url.path":             rec.Path,
+		"url.full":             rec.URL,
+		"http.route":           rec.Route,
+		"client.address":       remoteIP(rec.RemoteAddr),
+		"recovery.request_id":  rec.RequestID,
+		"recovery.fingerprint": rec.Fingerprint,
+		"recovery.origin":      rec.Origin,
+	} {
+		if len(value) > 0 {
+			data[name] = value
+		}
+	}
+	if rec.Status > 0 {
+		data["http.response.status_code"] = rec.Status
+	}
+	if rec.Count > 0 {
+		data["recovery.count"] = rec.Count
+	}
+
+	// With the ids of the request span, the event shows on its trace as a span event.
+	if len(rec.TraceID) > 0 {
+		data["trace.trace_id"] = rec.TraceID
+		if len(rec.SpanID) > 0 {
+			data["trace.parent_id"] = rec.SpanID
+			data["meta.annotation_type"] = "span_event"
+		}
+	}
+
+	return jsonPayload([]honeycombEvent{{Time: rec.Time.UTC().Format(time.RFC3339Nano), Data: data}})
+}
+
+// HoneycombSink returns a Sink that sends each panic to a Honeycomb dataset as an event, with the panic value and type, the stack, the fingerprint, and the request, so panics can be queried alongside the latency of the requests. Fields are named after the OpenTelemetry conventions, like "exception.message" and "http.route". When the request is traced, the event carries the trace and span ids, and shows on the trace as a span event of the request span. Every post happens as the panic is logged, so consider AsyncLogging.
+func HoneycombSink(opt HoneycombOptions) Sink {
+	w := newPostWriter(opt.Client, apiEndpoint(opt.BaseURL, defaultHoneycombURL, "/1/batch/"+url.PathEscape(opt.Dataset)), "application/json", "Honeycomb")
+	w.header.Set("X-Honeycomb-Team", opt.APIKey)
+
+	return Sink{Out: w, Formatter: honeycombFormatter{hostname()}}
+}
@@ -0,0 +1,80 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHoneycombSink(t *testing.T) {
+	batches := make(chan []byte, 1)
+	var key, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key = req.Header.Get("X-Honeycomb-Team")
+		path = req.URL.Path
+		b, _ := io.ReadAll(req.Body)
+		batches <- b
+		w.Write([]byte(`[{"status":202}]`))
+	}))
+	defer srv.Close()
+
+	expect(t, HoneycombSink(HoneycombOptions{Dataset: "api panics"}).Out.(*postWriter).endpoint, "https://api.honeycomb.io/1/batch/api%20panics")
+	sink := HoneycombSink(HoneycombOptions{APIKey: "secret", Dataset: "api panics", BaseURL: srv.URL + "/", Client: srv.Client()})
+
+	r := New(Options{
+		Sinks:             []Sink{sink},
+		LogRequestDetails: true,
+		IncludeRequestID:  true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var events []honeycombEvent
+	if err := json.Unmarshal(<-batches, &events); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, key, "secret")
+	expect(t, path, "/1/batch/api panics")
+	expect(t, len(events), 1)
+
+	data := events[0].Data
+	expect(t, len(events[0].Time) > 0, true)
+	expect(t, data["name"], "panic")
+	expect(t, data["error"], true)
+	expect(t, data["exception.type"], "string")
+	expect(t, data["exception.message"], "this did not work")
+	expectContainsTrue(t, data["exception.stacktrace"].(string), "recovery_test.go")
+	expect(t, data["http.request.method"], "GET")
+	expect(t, data["url.path"], "/foo")
+	expect(t, data["http.response.status_code"], float64(http.StatusInternalServerError))
+	expect(t, data["recovery.request_id"], "abc123")
+	expect(t, data["recovery.severity"], "error")
+	expect(t, len(data["recovery.fingerprint"].(string)) > 0, true)
+	expect(t, data["trace.trace_id"], "4bf92f3577b34da6a3ce929d0e0e4736")
+	expect(t, data["trace.parent_id"], "00f067aa0ba902b7")
+	expect(t, data["meta.annotation_type"], "span_event")
+}
+
+func TestHoneycombFormatterUntraced(t *testing.T) {
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Value: 42}, Panic: "42", Method: "POST", Path: "/bar"}
+
+	var events []honeycombEvent
+	if err := json.Unmarshal(honeycombFormatter{"web-1"}.Format(rec), &events); err != nil {
+		t.Fatal(err)
+	}
+
+	data := events[0].Data
+	expect(t, data["exception.type"], "int")
+	expect(t, data["host.name"], "web-1")
+	for _, name := range []string{"trace.trace_id", "trace.parent_id", "meta.annotation_type", "http.route", "http.response.status_code", "recovery.count"} {
+		if _, ok := data[name]; ok {
+			t.Errorf("Expected no %s field - Got %v", name, data[name])
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// maxJiraSummary is the longest summary Jira takes.
+	maxJiraSummary = 255
+	// maxJiraDescription is the longest description Jira takes.
+	maxJiraDescription = 32767
+)
+
+// JiraOptions configures a JiraSink.
+type JiraOptions struct {
+	// BaseURL is the address of the Jira site, like "https://acme.atlassian.net".
+	BaseURL string
+	// Email is the account of the Token on Jira Cloud, which authenticates with the email and an API token. Leave it blank on Jira Data Center, whose Token is a personal access token.
+	Email string
+	// Token is an API token, or a personal access token without an Email.
+	Token string
+	// Project is the key of the project the tickets are filed in, like "OPS".
+	Project string
+	// IssueType is the type of the tickets. Default is "Bug".
+	IssueType string
+	// Labels are added to the tickets. Default is nil (no labels).
+	Labels []string
+	// FingerprintField is the id of the custom text field holding the panic fingerprint of a ticket, like "customfield_10050", which decides whether a fingerprint has a ticket already. Default is blank (a "recovery-<fingerprint>" label instead).
+	FingerprintField string
+	// Client, if set, sends the requests, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// jiraReport is what the jiraFormatter hands the jiraWriter for one new fingerprint.
+type jiraReport struct {
+	Fingerprint string `json:"fingerprint"`
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Stack       string `json:"stack"`
+}
+
+// jiraFormatter renders the first panic of each fingerprint as a jiraReport, and nothing for the ones after.
+type jiraFormatter struct {
+	seen *fingerprintSet
+}
+
+func (f jiraFormatter) Format(rec *PanicRecord) []byte {
+	if !f.seen.add(rec.Fingerprint) {
+		return nil
+	}
+
+	summary := "Panic: " + rec.Panic
+	if len(rec.Method) > 0 {
+		where := rec.Route
+		if len(where) == 0 {
+			where = rec.Method + " " + rec.Path
+		}
+		summary = "Panic in " + where + ": " + rec.Panic
+	}
+
+	// The description is wiki markup, which noformat blocks keep out of.
+	var b strings.Builder
+	fmt.Fprintf(&b, "{noformat}%s{noformat}\n\n", rec.Panic)
+	for _, field := range [][2]string{
+		{"Time", rec.Time.UTC().Format(time.RFC3339)},
+		{"Request", strings.TrimSpace(rec.Method + " " + rec.Path)},
+		{"Route", rec.Route},
+		{"Request ID", rec.RequestID},
+		{"Severity", rec.Severity.String()},
+		{"Origin", rec.Origin},
+		{"Fingerprint", rec.Fingerprint},
+	} {
+		if len(field[1]) > 0 {
+			fmt.Fprintf(&b, "*%s:* {{%s}}\n", field[0], field[1])
+		}
+	}
+	if request := rec.requestText(); len(request) > 0 {
+		fmt.Fprintf(&b, "\n{noformat}\n%s{noformat}\n", request)
+	}
+	if frames := appFrames(rec.Frames, chatFrames); len(frames) > 0 {
+		b.WriteString("\n{noformat}\n")
+		for _, frame := range frames {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Func, frame.File, frame.Line)
+		}
+		b.WriteString("{noformat}\nThe full stack is attached.\n")
+	}
+
+	report := jiraReport{
+		Fingerprint: rec.Fingerprint,
+		Summary:     truncate(strings.Join(strings.Fields(summary), " "), maxJiraSummary),
+		Description: truncate(b.String(), maxJiraDescription),
+		Stack:       string(rec.Stack),
+	}
+
+	return jsonPayload(report)
+}
+
+// jiraWriter files a ticket for each report written to it, unless the project holds one for its fingerprint already.
+type jiraWriter struct {
+	opt  JiraOptions
+	seen *fingerprintSet
+}
+
+func (w *jiraWriter) Write(p []byte) (int, error) {
+	var report jiraReport
+	if err := json.Unmarshal(p, &report); err != nil {
+		return 0, err
+	}
+
+	if err := w.file(report); err != nil {
+		w.seen.remove(report.Fingerprint)
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// file creates the ticket of the report, with the stack attached, when the fingerprint has none.
+func (w *jiraWriter) file(report jiraReport) error {
+	labels := append([]string(nil), w.opt.Labels...)
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": w.opt.Project},
+		"issuetype":   map[string]string{"name": w.opt.IssueType},
+		"summary":     report.Summary,
+		"description": report.Description,
+	}
+
+	if len(report.Fingerprint) > 0 {
+		jql := fmt.Sprintf("project = %q AND labels = %q", w.opt.Project, "recovery-"+report.Fingerprint)
+		if id, ok := strings.CutPrefix(w.opt.FingerprintField, "customfield_"); ok {
+			jql = fmt.Sprintf("project = %q AND cf[%s] ~ %q", w.opt.Project, id, report.Fingerprint)
+			fields[w.opt.FingerprintField] = report.Fingerprint
+		} else {
+			labels = append(labels, "recovery-"+report.Fingerprint)
+		}
+
+		var found struct {
+			Issues []struct {
+				Key string `json:"key"`
+			} `json:"issues"`
+		}
+		search := map[string]interface{}{"jql": jql, "fields": []string{"key"}, "maxResults": 1}
+		if err := w.do(http.MethodPost, w.searchPath(), search, &found); err != nil {
+			return err
+		}
+		if len(found.Issues) > 0 {
+			return nil
+		}
+	}
+	if len(labels) > 0 {
+		fields["labels"] = labels
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := w.do(http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return err
+	}
+	if len(report.Stack) == 0 {
+		return nil
+	}
+
+	return w.attach(created.Key, "stack.txt", []byte(report.Stack))
+}
+
+// searchPath is the JQL search endpoint, which Jira Cloud moved.
+func (w *jiraWriter) searchPath() string {
+	if len(w.opt.Email) > 0 {
+		return "/rest/api/2/search/jql"
+	}
+
+	return "/rest/api/2/search"
+}
+
+// attach uploads a file to a ticket.
+func (w *jiraWriter) attach(key, name string, content []byte) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+	part.Write(content)
+	mw.Close()
+
+	req, err := w.request(http.MethodPost, "/rest/api/2/issue/"+key+"/attachments", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	// Uploads are refused without it, as a guard against cross-site requests.
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	return w.send(req, nil)
+}
+
+// do sends one API request with a JSON body, and decodes the JSON response into out, when it is not nil.
+func (w *jiraWriter) do(method, path string, in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := w.request(method, path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return w.send(req, out)
+}
+
+// request returns an authenticated API request.
+func (w *jiraWriter) request(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, w.opt.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if len(w.opt.Email) > 0 {
+		req.SetBasicAuth(w.opt.Email, w.opt.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+w.opt.Token)
+	}
+
+	return req, nil
+}
+
+// send sends a request, and decodes the JSON response into out, when it is not nil.
+func (w *jiraWriter) send(req *http.Request, out interface{}) error {
+	res, err := w.opt.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		io.Copy(io.Discard, res.Body)
+		return fmt.Errorf("recovery: Jira endpoint returned %s", res.Status)
+	}
+	if out == nil {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// JiraSink returns a Sink that files a Jira ticket the first time a panic fingerprint is seen, in the Project, with the Labels, the panic value, the request, and the top application frames, and the full stack attached as stack.txt. The fingerprint is kept in the FingerprintField of the ticket, and no ticket is filed for a fingerprint that has one already, like one filed before a restart. Later panics of a fingerprint are not filed again by the process. Every request happens as the panic is logged, so consider AsyncLogging.
+func JiraSink(opt JiraOptions) Sink {
+	opt.BaseURL = strings.TrimSuffix(opt.BaseURL, "/")
+	if len(opt.IssueType) == 0 {
+		opt.IssueType = "Bug"
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	seen := &fingerprintSet{fingerprints: map[string]bool{}}
+	return Sink{Out: &jiraWriter{opt: opt, seen: seen}, Formatter: jiraFormatter{seen}}
+}
@@ -0,0 +1,157 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// jiraServer fakes a Jira project, with a search matching the tickets holding the fingerprint of the query.
+type jiraServer struct {
+	mu          sync.Mutex
+	issues      []map[string]interface{}
+	attachments map[string]string
+	searches    []string
+	paths       []string
+	user, token string
+}
+
+func (s *jiraServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paths = append(s.paths, req.URL.Path)
+	s.user, s.token, _ = req.BasicAuth()
+
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/rest/api/2/search"):
+		var search struct {
+			JQL string `json:"jql"`
+		}
+		json.NewDecoder(req.Body).Decode(&search)
+		s.searches = append(s.searches, search.JQL)
+
+		issues := []map[string]string{}
+		for i, issue := range s.issues {
+			b, _ := json.Marshal(issue)
+			fields := strings.Fields(search.JQL)
+			if strings.Contains(string(b), strings.TrimPrefix(strings.Trim(fields[len(fields)-1], `"`), "recovery-")) {
+				issues = append(issues, map[string]string{"key": "OPS-" + strconv.Itoa(i+1)})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues})
+	case req.URL.Path == "/rest/api/2/issue":
+		var in struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		json.NewDecoder(req.Body).Decode(&in)
+		s.issues = append(s.issues, in.Fields)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"key": "OPS-" + strconv.Itoa(len(s.issues))})
+	case strings.HasSuffix(req.URL.Path, "/attachments"):
+		if req.Header.Get("X-Atlassian-Token") != "no-check" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		file, header, err := req.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(file)
+		s.attachments[req.URL.Path+"/"+header.Filename] = string(b)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestJiraSink(t *testing.T) {
+	jira := &jiraServer{attachments: map[string]string{}}
+	srv := httptest.NewServer(jira)
+	defer srv.Close()
+
+	opt := JiraOptions{
+		BaseURL:          srv.URL + "/",
+		Email:            "bot@example.com",
+		Token:            "secret",
+		Project:          "OPS",
+		Labels:           []string{"panic"},
+		FingerprintField: "customfield_10050",
+	}
+	r := New(Options{Sinks: []Sink{JiraSink(opt)}, LogRequestDetails: true, IncludeRequestID: true})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	// The second panic has a known fingerprint, so only the first is filed.
+	expect(t, len(jira.issues), 1)
+	expect(t, jira.user, "bot@example.com")
+	expect(t, jira.token, "secret")
+	expect(t, jira.paths[0], "/rest/api/2/search/jql")
+
+	issue := jira.issues[0]
+	fingerprint := issue["customfield_10050"].(string)
+	expect(t, len(fingerprint) > 0, true)
+	expect(t, jira.searches[0], `project = "OPS" AND cf[10050] ~ "`+fingerprint+`"`)
+	expect(t, issue["summary"], "Panic in GET /foo: this did not work")
+	expect(t, issue["issuetype"].(map[string]interface{})["name"], "Bug")
+	expect(t, issue["project"].(map[string]interface{})["key"], "OPS")
+	expect(t, len(issue["labels"].([]interface{})), 1)
+	description := issue["description"].(string)
+	expectContainsTrue(t, description, "{noformat}this did not work{noformat}")
+	expectContainsTrue(t, description, "*Request ID:* {{abc123}}")
+	expectContainsTrue(t, description, "Request: GET /foo")
+	expectContainsTrue(t, jira.attachments["/rest/api/2/issue/OPS-1/attachments/stack.txt"], "recovery_test.go")
+
+	// A new process does not know the fingerprint, but finds its ticket.
+	r = New(Options{Sinks: []Sink{JiraSink(opt)}})
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expect(t, len(jira.searches), 2)
+	expect(t, len(jira.issues), 1)
+}
+
+func TestJiraSinkLabels(t *testing.T) {
+	jira := &jiraServer{attachments: map[string]string{}}
+	srv := httptest.NewServer(jira)
+	defer srv.Close()
+
+	sink := JiraSink(JiraOptions{BaseURL: srv.URL, Token: "pat", Project: "OPS", IssueType: "Task"})
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Fingerprint: "abc"}, Panic: "oops"}
+
+	if _, err := sink.Out.Write(sink.Formatter.Format(rec)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a FingerprintField, a label holds the fingerprint, and Data Center is searched the old way.
+	expect(t, jira.paths[0], "/rest/api/2/search")
+	expect(t, jira.searches[0], `project = "OPS" AND labels = "recovery-abc"`)
+	expect(t, jira.issues[0]["labels"].([]interface{})[0], "recovery-abc")
+	expect(t, jira.issues[0]["issuetype"].(map[string]interface{})["name"], "Task")
+	expect(t, jira.issues[0]["summary"], "Panic: oops")
+	// Records without a stack attach nothing.
+	expect(t, len(jira.attachments), 0)
+}
+
+func TestJiraSinkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	sink := JiraSink(JiraOptions{BaseURL: srv.URL, Token: "pat", Project: "OPS"})
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Fingerprint: "abc"}, Panic: "oops"}
+
+	_, err := sink.Out.Write(sink.Formatter.Format(rec))
+	expect(t, err.Error(), "recovery: Jira endpoint returned 401 Unauthorized")
+
+	// A fingerprint failing to be filed is tried again on its next panic.
+	expect(t, len(sink.Formatter.Format(rec)) > 0, true)
+}
@@ -0,0 +1,61 @@
+package recovery
+
+import (
+	"errors"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// panicMapping pairs a panic value type with the response it should produce.
+type panicMapping struct {
+	typ        reflect.Type
+	statusCode int
+	body       string
+}
+
+// matches reports whether v is of the mapped type, or is an error wrapping one.
+func (m panicMapping) matches(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+
+	if reflect.TypeOf(v) == m.typ {
+		return true
+	}
+
+	// Only types usable as an errors.As target can be found in a chain.
+	err, ok := v.(error)
+	if !ok || (m.typ.Kind() != reflect.Interface && !m.typ.Implements(errorType)) {
+		return false
+	}
+
+	return errors.As(err, reflect.New(m.typ).Interface())
+}
+
+// MapPanic registers a response for panics whose value has the same type as target (or, for errors, wraps one). Instead of calling the panic handler, Recovery responds like the default panic handler does, with statusCode and body as the message, so ContentNegotiation, ResponseHeaders, and IncludeRequestID apply. A blank body uses the message of LocalizedMessages for the client, or the standard status text. Mappings are checked in the order they were added and should be registered before serving requests.
+//
+//	r.MapPanic(NotFoundError{}, http.StatusNotFound, "")
+//	r.MapPanic(&AuthError{}, http.StatusUnauthorized, "Please sign in")
+func (r *Recovery) MapPanic(target interface{}, statusCode int, body string) {
+	if target == nil {
+		panic("recovery: MapPanic target must not be nil")
+	}
+
+	r.mappings = append(r.mappings, panicMapping{
+		typ:        reflect.TypeOf(target),
+		statusCode: statusCode,
+		body:       body,
+	})
+}
+
+// mappingFor returns the first registered mapping matching v.
+func (r *Recovery) mappingFor(v interface{}) (panicMapping, bool) {
+	for _, m := range r.mappings {
+		if m.matches(v) {
+			return m, true
+		}
+	}
+
+	return panicMapping{}, false
+}
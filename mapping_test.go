@@ -0,0 +1,105 @@
+package recovery
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type notFoundError struct{ name string }
+
+func (e notFoundError) Error() string { return e.name + " not found" }
+
+type unauthorized struct{}
+
+func panicWith(v interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(v)
+	})
+}
+
+func TestMapPanic(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+	r.MapPanic(notFoundError{}, http.StatusNotFound, "")
+	r.MapPanic(&unauthorized{}, http.StatusUnauthorized, "Please sign in")
+
+	cases := []struct {
+		value  interface{}
+		status int
+		body   string
+	}{
+		{notFoundError{"user"}, http.StatusNotFound, http.StatusText(http.StatusNotFound)},
+		{fmt.Errorf("loading: %w", notFoundError{"user"}), http.StatusNotFound, http.StatusText(http.StatusNotFound)},
+		{&unauthorized{}, http.StatusUnauthorized, "Please sign in"},
+		{unauthorized{}, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)},
+		{"plain string", http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)},
+	}
+
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		r.Handler(panicWith(c.value)).ServeHTTP(res, req)
+
+		expect(t, res.Code, c.status)
+		expect(t, strings.TrimSpace(res.Body.String()), c.body)
+	}
+}
+
+func TestMapPanicSkipsPanicHandler(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+	r.MapPanic(notFoundError{}, http.StatusNotFound, "gone")
+
+	called := false
+	r.SetPanicHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(panicWith(notFoundError{"page"})).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusNotFound)
+	expect(t, called, false)
+}
+
+func TestMapPanicDefaultResponse(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		ContentNegotiation: true,
+		IncludeRequestID:   true,
+		ResponseHeaders:    http.Header{"Cache-Control": {"no-store"}},
+		LocalizedMessages:  map[string]string{"fr": "Une erreur est survenue"},
+	})
+	r.MapPanic(notFoundError{}, http.StatusNotFound, "No such page")
+	r.MapPanic(&unauthorized{}, http.StatusUnauthorized, "")
+
+	// The mapped status and body go out like the default response, negotiated and with its headers.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(panicWith(notFoundError{"page"})).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusNotFound)
+	expect(t, res.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	expect(t, res.Header().Get("Cache-Control"), "no-store")
+	expect(t, res.Header().Get("X-Request-ID"), "abc123")
+	expect(t, strings.TrimSpace(res.Body.String()), `{"error":"No such page","request_id":"abc123"}`)
+
+	// A blank body is localized.
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept-Language", "fr")
+	r.Handler(panicWith(&unauthorized{})).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusUnauthorized)
+	expect(t, res.Header().Get("Content-Language"), "fr")
+	expectContainsTrue(t, res.Body.String(), "Une erreur est survenue")
+}
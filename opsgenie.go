@@ -0,0 +1,101 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	// defaultOpsgenieURL is the Opsgenie API.
+	defaultOpsgenieURL = "https://api.opsgenie.com"
+
+	// The limits of an alert.
+	maxOpsgenieMessage     = 130
+	maxOpsgenieAlias       = 512
+	maxOpsgenieDescription = 15000
+)
+
+// opsgenieAlert is the payload of an Opsgenie create alert request.
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias,omitempty"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Details     map[string]string `json:"details"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+}
+
+// opsgeniePriorities are the default alert priorities of each Severity.
+var opsgeniePriorities = map[Severity]string{
+	SeverityDebug:    "P5",
+	SeverityInfo:     "P4",
+	SeverityWarning:  "P3",
+	SeverityError:    "P2",
+	SeverityCritical: "P1",
+}
+
+// opsgenieFormatter renders a panic as an Opsgenie alert.
+type opsgenieFormatter struct {
+	priorities map[Severity]string
+	host       string
+}
+
+func (f opsgenieFormatter) Format(rec *PanicRecord) []byte {
+	priority, ok := f.priorities[rec.Severity]
+	if !ok {
+		priority = opsgeniePriorities[rec.Severity]
+	}
+	if len(priority) == 0 {
+		priority = opsgeniePriorities[SeverityError]
+	}
+
+	message := fmt.Sprintf("%T: %s", rec.Value, rec.Panic)
+	if len(rec.Method) > 0 {
+		message = fmt.Sprintf("Panic in %s %s: %s", rec.Method, rec.Path, rec.Panic)
+	}
+	alert := opsgenieAlert{
+		Message:     truncate(message, maxOpsgenieMessage),
+		Alias:       truncate(rec.Fingerprint, maxOpsgenieAlias),
+		Description: truncate(rec.Panic+"\n\n"+rec.requestText()+string(rec.Stack), maxOpsgenieDescription),
+		Tags:        []string{"panic", rec.Severity.String()},
+		Details:     map[string]string{},
+		Entity:      rec.Route,
+		Source:      f.host,
+		Priority:    priority,
+	}
+
+	for name, value := range map[string]string{
+		"request_id":  rec.RequestID,
+		"origin":      rec.Origin,
+		"trace_id":    rec.TraceID,
+		"fingerprint": rec.Fingerprint,
+	} {
+		if len(value) > 0 {
+			alert.Details[name] = value
+		}
+	}
+
+	return jsonPayload(alert)
+}
+
+// OpsgenieOptions configures an OpsgenieSink.
+type OpsgenieOptions struct {
+	// APIKey is the key of an API integration.
+	APIKey string
+	// Priorities map the severity of a panic to an alert priority, "P1" to "P5". Severities missing from it keep the default, from P1 for SeverityCritical down to P5 for SeverityDebug. Default is nil.
+	Priorities map[Severity]string
+	// BaseURL is the API endpoint, like "https://api.eu.opsgenie.com" for the EU region. Default is "https://api.opsgenie.com".
+	BaseURL string
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// OpsgenieSink returns a Sink that creates an Opsgenie alert for each panic, with the panic value, the request, and the stack. The fingerprint is the alias of the alerts, so repeated panics raise the count of the open alert instead of opening new ones. The priority of an alert follows the severity of the panic. Every post happens as the panic is logged, so consider AsyncLogging.
+func OpsgenieSink(opt OpsgenieOptions) Sink {
+	w := newPostWriter(opt.Client, apiEndpoint(opt.BaseURL, defaultOpsgenieURL, "/v2/alerts"), "application/json", "Opsgenie")
+	w.header.Set("Authorization", "GenieKey "+opt.APIKey)
+
+	return Sink{Out: w, Formatter: opsgenieFormatter{opt.Priorities, hostname()}}
+}
@@ -0,0 +1,75 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpsgenieSink(t *testing.T) {
+	alerts := make(chan []byte, 1)
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth = req.Header.Get("Authorization")
+		b, _ := io.ReadAll(req.Body)
+		alerts <- b
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sink := OpsgenieSink(OpsgenieOptions{APIKey: "key123", BaseURL: srv.URL, Client: srv.Client()})
+
+	r := New(Options{Sinks: []Sink{sink}, IncludeRequestID: true})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	var alert opsgenieAlert
+	if err := json.Unmarshal(<-alerts, &alert); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, auth, "GenieKey key123")
+	expect(t, alert.Message, "Panic in GET /foo: this did not work")
+	expect(t, len(alert.Alias) > 0, true)
+	expect(t, alert.Details["fingerprint"], alert.Alias)
+	expect(t, alert.Details["request_id"], "abc123")
+	expect(t, alert.Priority, "P2")
+	expect(t, strings.Join(alert.Tags, ","), "panic,error")
+	expect(t, len(alert.Source) > 0, true)
+	expectContainsTrue(t, alert.Description, "this did not work")
+	expectContainsTrue(t, alert.Description, "recovery_test.go")
+}
+
+func TestOpsgeniePriorities(t *testing.T) {
+	f := OpsgenieSink(OpsgenieOptions{APIKey: "key123", Priorities: map[Severity]string{SeverityError: "P1", SeverityWarning: "P5"}}).Formatter
+
+	for severity, priority := range map[Severity]string{
+		SeverityCritical: "P1",
+		SeverityError:    "P1",
+		SeverityWarning:  "P5",
+		SeverityInfo:     "P4",
+		SeverityDebug:    "P5",
+	} {
+		var alert opsgenieAlert
+		if err := json.Unmarshal(f.Format(&PanicRecord{PanicInfo: &PanicInfo{Severity: severity}, Panic: "oops"}), &alert); err != nil {
+			t.Fatal(err)
+		}
+		expect(t, alert.Priority, priority)
+	}
+}
+
+func TestOpsgenieMessageLimit(t *testing.T) {
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Value: "x"}, Panic: strings.Repeat("x", 500)}
+
+	var alert opsgenieAlert
+	if err := json.Unmarshal(opsgenieFormatter{host: "web-1"}.Format(rec), &alert); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, len(alert.Message) <= maxOpsgenieMessage, true)
+	expectContainsTrue(t, alert.Message, "string: xxx")
+	expect(t, alert.Alias, "")
+}
@@ -0,0 +1,153 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPagerDutyURL is the PagerDuty Events API.
+	defaultPagerDutyURL = "https://events.pagerduty.com"
+	// maxPagerDutySummary is the longest summary PagerDuty takes.
+	maxPagerDutySummary = 1024
+)
+
+// pagerDutyEvent is the payload of a PagerDuty trigger event.
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key,omitempty"`
+	Payload     pagerDutyPayload `json:"payload"`
+	Client      string           `json:"client"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component,omitempty"`
+	Class         string            `json:"class"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+// pagerDutyCounter counts the panics of every fingerprint in the current window.
+type pagerDutyCounter struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+// add counts a panic with the given fingerprint at now, and returns the count of the window.
+func (c *pagerDutyCounter) add(key string, now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.buckets[key]
+	if !ok || now.Sub(b.start) >= c.window {
+		if len(c.buckets) >= maxRateBuckets {
+			for k, old := range c.buckets {
+				if now.Sub(old.start) >= c.window {
+					delete(c.buckets, k)
+				}
+			}
+		}
+		b = &rateBucket{start: now}
+		c.buckets[key] = b
+	}
+	b.count++
+
+	return b.count
+}
+
+// pagerDutyFormatter renders a panic as a PagerDuty trigger event, once its fingerprint reached the threshold of the window.
+type pagerDutyFormatter struct {
+	routingKey string
+	threshold  int
+	counter    *pagerDutyCounter
+	host       string
+}
+
+func (f pagerDutyFormatter) Format(rec *PanicRecord) []byte {
+	if f.counter != nil && f.counter.add(rec.Fingerprint, rec.Time) < f.threshold {
+		return nil
+	}
+
+	class := fmt.Sprintf("%T", rec.Value)
+	summary := class + ": " + rec.Panic
+	if len(rec.Method) > 0 {
+		summary = fmt.Sprintf("Panic in %s %s: %s", rec.Method, rec.Path, rec.Panic)
+	}
+	event := pagerDutyEvent{
+		RoutingKey:  f.routingKey,
+		EventAction: "trigger",
+		DedupKey:    rec.Fingerprint,
+		Payload: pagerDutyPayload{
+			Summary:       truncate(summary, maxPagerDutySummary),
+			Source:        f.host,
+			Severity:      pagerDutySeverity(rec.Severity),
+			Timestamp:     rec.Time.UTC().Format(time.RFC3339Nano),
+			Component:     rec.Route,
+			Class:         class,
+			CustomDetails: map[string]string{},
+		},
+		Client: "github.com/unrolled/recovery",
+	}
+
+	for name, value := range map[string]string{
+		"request_id": rec.RequestID,
+		"origin":     rec.Origin,
+		"trace_id":   rec.TraceID,
+		"stack":      string(rec.Stack),
+	} {
+		if len(value) > 0 {
+			event.Payload.CustomDetails[name] = value
+		}
+	}
+
+	return jsonPayload(event)
+}
+
+// pagerDutySeverity maps a Severity to a PagerDuty event severity, which has no debug level.
+func pagerDutySeverity(s Severity) string {
+	switch s {
+	case SeverityDebug, SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "error"
+	}
+}
+
+// PagerDutyOptions configures a PagerDutySink.
+type PagerDutyOptions struct {
+	// RoutingKey is the integration key of a service.
+	RoutingKey string
+	// Threshold is how many panics with the same fingerprint trigger an incident within the Window. Default is 0 (every panic triggers).
+	Threshold int
+	// Window is the time the Threshold is counted over. Default is one minute.
+	Window time.Duration
+	// BaseURL is the Events API endpoint, like "https://events.eu.pagerduty.com" for the EU service region. Default is "https://events.pagerduty.com".
+	BaseURL string
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// PagerDutySink returns a Sink that triggers a PagerDuty incident through the Events API v2 once the panics with the same fingerprint reach the threshold within the window. The fingerprint is the dedup_key of the events, so repeated panics update one incident instead of opening new ones, and every panic over the threshold is sent. Every post happens as the panic is logged, so consider AsyncLogging.
+func PagerDutySink(opt PagerDutyOptions) Sink {
+	f := pagerDutyFormatter{routingKey: opt.RoutingKey, threshold: opt.Threshold, host: hostname()}
+	if opt.Threshold > 1 {
+		if opt.Window <= 0 {
+			opt.Window = time.Minute
+		}
+		f.counter = &pagerDutyCounter{window: opt.Window, buckets: map[string]*rateBucket{}}
+	}
+
+	return Sink{Out: newPostWriter(opt.Client, apiEndpoint(opt.BaseURL, defaultPagerDutyURL, "/v2/enqueue"), "application/json", "PagerDuty"), Formatter: f}
+}
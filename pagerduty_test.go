@@ -0,0 +1,74 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPagerDutySink(t *testing.T) {
+	events := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		events <- b
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sink := PagerDutySink(PagerDutyOptions{RoutingKey: "key123", BaseURL: srv.URL, Client: srv.Client()})
+
+	r := New(Options{Sinks: []Sink{sink}, IncludeRequestID: true})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	var event pagerDutyEvent
+	if err := json.Unmarshal(<-events, &event); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, event.RoutingKey, "key123")
+	expect(t, event.EventAction, "trigger")
+	expect(t, len(event.DedupKey) > 0, true)
+	expect(t, event.Payload.Summary, "Panic in GET /foo: this did not work")
+	expect(t, event.Payload.Severity, "error")
+	expect(t, event.Payload.Class, "string")
+	expect(t, len(event.Payload.Source) > 0, true)
+	expect(t, event.Payload.CustomDetails["request_id"], "abc123")
+	expectContainsTrue(t, event.Payload.CustomDetails["stack"], "recovery_test.go")
+}
+
+func TestPagerDutyThreshold(t *testing.T) {
+	f := PagerDutySink(PagerDutyOptions{RoutingKey: "key123", Threshold: 3, Window: time.Minute}).Formatter
+	now := time.Now()
+
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Time: now, Fingerprint: "abc"}, Panic: "oops"}
+	expect(t, len(f.Format(rec)), 0)
+	expect(t, len(f.Format(rec)), 0)
+
+	// Other fingerprints count on their own.
+	expect(t, len(f.Format(&PanicRecord{PanicInfo: &PanicInfo{Time: now, Fingerprint: "def"}, Panic: "oops"})), 0)
+
+	// The third panic of the window triggers, and so does every one after.
+	var event pagerDutyEvent
+	if err := json.Unmarshal(f.Format(rec), &event); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, event.DedupKey, "abc")
+	expect(t, len(f.Format(rec)) > 0, true)
+
+	// A new window starts counting again.
+	rec.Time = now.Add(time.Minute)
+	expect(t, len(f.Format(rec)), 0)
+}
+
+func TestPagerDutySeverity(t *testing.T) {
+	expect(t, pagerDutySeverity(SeverityDebug), "info")
+	expect(t, pagerDutySeverity(SeverityInfo), "info")
+	expect(t, pagerDutySeverity(SeverityWarning), "warning")
+	expect(t, pagerDutySeverity(SeverityError), "error")
+	expect(t, pagerDutySeverity(SeverityCritical), "critical")
+}
@@ -0,0 +1,27 @@
+package recovery
+
+import (
+	"bytes"
+	"sync"
+)
+
+// bufferPool holds the scratch buffers records are formatted in.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty scratch buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a scratch buffer to the pool and a copy of its bytes, which outlive it. Oversized buffers are let go rather than pinned.
+func putBuffer(buf *bytes.Buffer) []byte {
+	b := append([]byte(nil), buf.Bytes()...)
+	if buf.Cap() <= 64*1024 {
+		bufferPool.Put(buf)
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package recovery
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPutBuffer(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("first")
+	b := putBuffer(buf)
+
+	// The copy outlives the pooled buffer.
+	again := getBuffer()
+	again.WriteString("second")
+	expect(t, string(b), "first")
+	putBuffer(again)
+}
+
+func TestRenderFramesCopiesOut(t *testing.T) {
+	r := New(Options{})
+	st := r.captureStack()
+
+	first := r.renderFrames(st.id, st.frames, false, false, false)
+	want := string(first)
+	r.renderFrames(st.id, st.frames, false, true, true)
+
+	expect(t, string(first), want)
+	expect(t, cap(first) < r.opt.StackSize, true)
+}
+
+func BenchmarkCaptureStack(b *testing.B) {
+	r := New(Options{})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.panicStack(&PanicInfo{Value: "boom"}, r.captureStack(), "")
+	}
+}
+
+// BenchmarkRenderFrames and BenchmarkRenderFramesUnpooled render the same stack, with a pooled scratch buffer and with a new one per panic like before pooling, to compare their allocations.
+func BenchmarkRenderFrames(b *testing.B) {
+	r := New(Options{})
+	st := r.captureStack()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.renderFrames(st.id, st.frames, false, false, false)
+	}
+}
+
+func BenchmarkRenderFramesUnpooled(b *testing.B) {
+	r := New(Options{})
+	st := r.captureStack()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		renderStack(&buf, st.id, st.frames, r.opt.StackSize, false)
+		_ = buf.Bytes()
+	}
+}
+
+func BenchmarkFormatText(b *testing.B) {
+	r := New(Options{Out: io.Discard})
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	info := &PanicInfo{Value: "boom"}
+	r.panicStack(info, r.captureStack(), "")
+	rec := r.newRecord(req, "Recovering from Panic", info, http.StatusInternalServerError)
+	f := r.formatter(FormatText, r.Logger)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		f.Format(rec)
+	}
+}
+
+func BenchmarkPanicStorm(b *testing.B) {
+	r := New(Options{Out: io.Discard})
+	handler := r.Handler(myPanicHandler)
+	req, _ := http.NewRequest("GET", "/foo", nil)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package recovery
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// retryQueueSize is how many posts wait for a retry at most. Posts failing past it are not retried.
+const retryQueueSize = 64
+
+// postWriter posts each write to an HTTP endpoint as one request, without its trailing newline.
+type postWriter struct {
+	client      *http.Client
+	endpoint    string
+	contentType string
+	// header holds extra request headers, like an API key.
+	header http.Header
+	// name names the endpoint in errors.
+	name string
+	// retries is how many times a post failing with a network error, 429, or 5xx is tried again, waiting retryDelay first, doubled for each retry after.
+	retries    int
+	retryDelay time.Duration
+	// sign, if set, adds the signature of a body to the headers of its request.
+	sign func(body []byte, header http.Header)
+	// notef, set by New, reports the posts whose retries all failed.
+	notef func(format string, v ...interface{})
+
+	// queue runs the retries in order on a goroutine of its own, started by the first retry, so the backoff never holds the lock records are written under.
+	mu    sync.Mutex
+	queue *asyncQueue
+}
+
+// apiEndpoint joins the path of an API to its base URL, or to defaultBase when base is blank, so EU regions and self-hosted installs can be posted to.
+func apiEndpoint(base, defaultBase, path string) string {
+	if len(base) == 0 {
+		base = defaultBase
+	}
+
+	return strings.TrimSuffix(base, "/") + path
+}
+
+func newPostWriter(client *http.Client, endpoint, contentType, name string) *postWriter {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &postWriter{client: client, endpoint: endpoint, contentType: contentType, header: http.Header{}, name: name}
+}
+
+// Write posts p once. A post worth a retry is handed to the retry queue, and counts as written; other failures are returned.
+func (w *postWriter) Write(p []byte) (int, error) {
+	body := bytes.TrimSuffix(p, []byte("\n"))
+
+	retry, err := w.post(body)
+	if err == nil {
+		return len(p), nil
+	}
+	if !retry || w.retries <= 0 {
+		return 0, err
+	}
+
+	// The caller may reuse p once Write returns.
+	body = append([]byte(nil), body...)
+	if !w.retryQueue(true).offer(func() { w.retry(body, err) }) {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// retry posts body again, after the first attempt failed with err, and notes the post when every retry failed too.
+func (w *postWriter) retry(body []byte, err error) {
+	retry := true
+	delay := w.retryDelay
+	for attempt := 0; attempt < w.retries && retry; attempt++ {
+		time.Sleep(delay)
+		delay *= 2
+
+		if retry, err = w.post(body); err == nil {
+			return
+		}
+	}
+
+	if w.notef != nil {
+		w.notef("Error writing panic record: %s", err)
+	}
+}
+
+// retryQueue returns the retry queue, started first when create is set, or nil.
+func (w *postWriter) retryQueue(create bool) *asyncQueue {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.queue == nil && create {
+		w.queue = newAsyncQueue(retryQueueSize)
+	}
+
+	return w.queue
+}
+
+// flush blocks until the retries queued so far are done.
+func (w *postWriter) flush() {
+	if q := w.retryQueue(false); q != nil {
+		q.flush()
+	}
+}
+
+// close waits for the queued retries, and stops the retry queue. Posts failing afterwards are not retried.
+func (w *postWriter) close() {
+	if q := w.retryQueue(false); q != nil {
+		q.close()
+	}
+}
+
+// post sends one request, and reports whether a failure is worth a retry.
+func (w *postWriter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for name, values := range w.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", w.contentType)
+	if w.sign != nil {
+		w.sign(body, req.Header)
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		retry := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+		return retry, fmt.Errorf("recovery: %s endpoint returned %s", w.name, res.Status)
+	}
+
+	return false, nil
+}
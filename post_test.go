@@ -0,0 +1,39 @@
+package recovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostWriter(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header
+	}))
+	defer srv.Close()
+
+	w := newPostWriter(srv.Client(), srv.URL, "application/json", "Test")
+	w.header.Set("X-Api-Key", "secret")
+
+	n, err := w.Write([]byte("{}\n"))
+	expect(t, err, nil)
+	expect(t, n, 3)
+	expect(t, header.Get("Content-Type"), "application/json")
+	expect(t, header.Get("X-Api-Key"), "secret")
+}
+
+func TestAPIEndpoint(t *testing.T) {
+	expect(t, apiEndpoint("", "https://api.example.com", "/v1/notices"), "https://api.example.com/v1/notices")
+	expect(t, apiEndpoint("https://eu.example.com/", "https://api.example.com", "/v1/notices"), "https://eu.example.com/v1/notices")
+}
+
+func TestPostWriterStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	_, err := newPostWriter(srv.Client(), srv.URL, "application/json", "Test").Write([]byte("{}\n"))
+	expect(t, err.Error(), "recovery: Test endpoint returned 401 Unauthorized")
+}
@@ -0,0 +1,66 @@
+package recovery
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// Problem is the template for the RFC 7807 documents written by ProblemJSONHandler.
+type Problem struct {
+	// Type is a URI reference identifying the problem type. Default is "about:blank".
+	Type string
+	// Title is a short, human-readable summary of the problem. Default is the status text of Status.
+	Title string
+	// Status is the HTTP status code of the response. Default is 500.
+	Status int
+	// Detail is an optional human-readable explanation. Default is blank (omitted).
+	Detail string
+	// TraceIDHeader names the request header whose value is copied into the document as "trace_id". Default is blank (no trace id).
+	TraceIDHeader string
+}
+
+// problemDocument is the serialized form of a Problem.
+type problemDocument struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance"`
+	TraceID  string `json:"trace_id,omitempty"`
+}
+
+// ProblemJSONHandler returns a panic handler that answers with an application/problem+json document built from tmpl. The "instance" member is the request path.
+//
+//	r.SetPanicHandler(recovery.ProblemJSONHandler(recovery.Problem{
+//	    Type:          "https://example.com/problems/internal",
+//	    TraceIDHeader: "X-Trace-ID",
+//	}))
+func ProblemJSONHandler(tmpl Problem) http.Handler {
+	if tmpl.Status == 0 {
+		tmpl.Status = http.StatusInternalServerError
+	}
+	if len(tmpl.Type) == 0 {
+		tmpl.Type = "about:blank"
+	}
+	if len(tmpl.Title) == 0 {
+		tmpl.Title = http.StatusText(tmpl.Status)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		doc := problemDocument{
+			Type:     tmpl.Type,
+			Title:    tmpl.Title,
+			Status:   tmpl.Status,
+			Detail:   tmpl.Detail,
+			Instance: req.URL.Path,
+		}
+		if len(tmpl.TraceIDHeader) > 0 {
+			doc.TraceID = req.Header.Get(tmpl.TraceIDHeader)
+		}
+
+		body, _ := json.Marshal(doc)
+		writeResponse(w, tmpl.Status, mimeProblemJSON, append(body, '\n'))
+	})
+}
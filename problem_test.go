@@ -0,0 +1,71 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemJSONHandlerDefaults(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+	r.SetPanicHandler(ProblemJSONHandler(Problem{}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/orders/42", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get("Content-Type"), "application/problem+json")
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a JSON body, got error %v for [%s]", err, res.Body.String())
+	}
+
+	expect(t, doc["type"], interface{}("about:blank"))
+	expect(t, doc["title"], interface{}(http.StatusText(http.StatusInternalServerError)))
+	expect(t, doc["status"], interface{}(float64(http.StatusInternalServerError)))
+	expect(t, doc["instance"], interface{}("/orders/42"))
+	_, hasDetail := doc["detail"]
+	expect(t, hasDetail, false)
+	_, hasTrace := doc["trace_id"]
+	expect(t, hasTrace, false)
+}
+
+func TestProblemJSONHandlerTemplate(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+	r.SetPanicHandler(ProblemJSONHandler(Problem{
+		Type:          "https://example.com/problems/unavailable",
+		Title:         "Try again later",
+		Status:        http.StatusServiceUnavailable,
+		Detail:        "The service hit an unexpected error.",
+		TraceIDHeader: "X-Trace-ID",
+	}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/orders/42", nil)
+	req.Header.Set("X-Trace-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusServiceUnavailable)
+
+	var doc problemDocument
+	if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a JSON body, got error %v for [%s]", err, res.Body.String())
+	}
+
+	expect(t, doc, problemDocument{
+		Type:     "https://example.com/problems/unavailable",
+		Title:    "Try again later",
+		Status:   http.StatusServiceUnavailable,
+		Detail:   "The service hit an unexpected error.",
+		Instance: "/orders/42",
+		TraceID:  "abc123",
+	})
+}
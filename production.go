@@ -0,0 +1,41 @@
+package recovery
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// hardenedHeaders are set on every response in Production mode.
+var hardenedHeaders = map[string]string{
+	"Cache-Control":          "no-store, no-cache, must-revalidate",
+	"Pragma":                 "no-cache",
+	"Expires":                "0",
+	"Connection":             "close",
+	"Content-Type":           "text/plain; charset=utf-8",
+	"X-Content-Type-Options": "nosniff",
+}
+
+// writeHardened sends the generic response for the status the panic handler set, a 500 if it set none, to w. Nothing else of the buffered response is kept: an escaped or encoded panic value is as much a leak as a plain one, and no audit of the body can catch them all. Only the ResponseHeaders and, with IncludeRequestID, the request id go along.
+func (r *Recovery) writeHardened(w http.ResponseWriter, req *http.Request, hw *bufferedWriter) {
+	status := hw.status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	body := http.StatusText(status) + "\n"
+	dst := w.Header()
+	for name, values := range r.opt.ResponseHeaders {
+		dst[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	if info, ok := FromContext(req.Context()); ok && r.opt.IncludeRequestID {
+		dst.Set(r.opt.RequestIDHeader, info.RequestID)
+		body += "Request ID: " + info.RequestID + "\n"
+	}
+	for name, value := range hardenedHeaders {
+		dst.Set(name, value)
+	}
+	dst.Set("Content-Length", strconv.Itoa(len(body)))
+
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
@@ -0,0 +1,180 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProductionStripsPanicValue(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:        buf,
+		Production: true,
+	})
+	r.SetPanicHandlerFunc(func(w http.ResponseWriter, req *http.Request, panicVal interface{}, stack []byte) {
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(w, "oops: %v", panicVal)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusBadGateway)
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusBadGateway))
+	expectContainsFalse(t, buf.String(), "oops")
+}
+
+func TestProductionStripsStack(t *testing.T) {
+	r := New(Options{
+		Out:        ioutil.Discard,
+		Production: true,
+	})
+	r.SetPanicHandlerFunc(func(w http.ResponseWriter, req *http.Request, panicVal interface{}, stack []byte) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<pre>"))
+		w.Write(stack)
+		w.Write([]byte("</pre>"))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	expectContainsFalse(t, res.Body.String(), "goroutine")
+}
+
+func TestProductionGenericResponses(t *testing.T) {
+	r := New(Options{
+		Out:             ioutil.Discard,
+		Production:      true,
+		ResponseHeaders: http.Header{"X-Service": {"api"}},
+	})
+	r.SetPanicHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Leak", "details")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"try again"}`))
+	}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	// Only the status of the handler is kept.
+	expect(t, res.Code, http.StatusServiceUnavailable)
+	expect(t, res.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	expect(t, res.Header().Get("X-Leak"), "")
+	expect(t, res.Header().Get("X-Service"), "api")
+	expect(t, res.Body.String(), http.StatusText(http.StatusServiceUnavailable)+"\n")
+
+	// Hardening headers are always set.
+	expect(t, res.Header().Get("Cache-Control"), "no-store, no-cache, must-revalidate")
+	expect(t, res.Header().Get("Pragma"), "no-cache")
+	expect(t, res.Header().Get("Connection"), "close")
+}
+
+func TestProductionStripsEscapedPanicValue(t *testing.T) {
+	for name, handler := range map[string]PanicHandlerFunc{
+		"json": func(w http.ResponseWriter, req *http.Request, panicVal interface{}, stack []byte) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": panicVal})
+		},
+		"html": func(w http.ResponseWriter, req *http.Request, panicVal interface{}, stack []byte) {
+			template.Must(template.New("page").Parse("<p>{{.}}</p>")).Execute(w, panicVal)
+		},
+	} {
+		r := New(Options{
+			Out:        ioutil.Discard,
+			Production: true,
+		})
+		r.SetPanicHandlerFunc(handler)
+
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(`<bad> password 'hunter2' "quoted"`)
+		})).ServeHTTP(res, req)
+
+		if body := res.Body.String(); strings.Contains(body, "hunter2") {
+			t.Errorf("Expected the %s handler not to leak the panic value - Got [%s]", name, body)
+		}
+	}
+}
+
+func TestProductionRequestID(t *testing.T) {
+	r := New(Options{
+		Out:              ioutil.Discard,
+		Production:       true,
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Header().Get("X-Request-ID"), "abc123")
+	expect(t, res.Body.String(), http.StatusText(http.StatusInternalServerError)+"\nRequest ID: abc123\n")
+}
+
+func TestProductionOverridesDevelopment(t *testing.T) {
+	r := New(Options{
+		Out:         ioutil.Discard,
+		Development: true,
+		Production:  true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusInternalServerError))
+}
+
+func TestProductionPanickingPanicHandler(t *testing.T) {
+	r := New(Options{
+		Out:        ioutil.Discard,
+		Production: true,
+	})
+	r.SetPanicHandlerFunc(func(w http.ResponseWriter, req *http.Request, panicVal interface{}, stack []byte) {
+		w.Write([]byte("half written"))
+		panic("handler broke")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusInternalServerError))
+}
+
+func TestProductionStripsTruncatedPanicValue(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		Production:         true,
+		MaxPanicValueBytes: 8,
+	})
+	r.SetPanicHandlerFunc(func(w http.ResponseWriter, req *http.Request, panicVal interface{}, stack []byte) {
+		fmt.Fprintf(w, "%v", panicVal)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret-token-abcdefghijkl")
+	})).ServeHTTP(res, req)
+
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusInternalServerError))
+}
@@ -0,0 +1,126 @@
+package recovery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// profilePatterns match the names of the profile files Recovery writes, the time coming right after the first dash.
+var profilePatterns = []string{"goroutine-*.txt", "heap-*.pb.gz"}
+
+// profiler allows one round of profiles per ProfileInterval, and tracks the rounds being written.
+type profiler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+	writing  sync.WaitGroup
+}
+
+func (p *profiler) allow(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.last.IsZero() && now.Sub(p.last) < p.interval {
+		return false
+	}
+	p.last = now
+
+	return true
+}
+
+// writeProfiles writes the enabled profiles of a panic to the ProfileDir, at most once per ProfileInterval, and notes where they went. They are written on a goroutine of their own, as a heap profile forces a garbage collection, which the request has no reason to wait for.
+func (r *Recovery) writeProfiles(info *PanicInfo) {
+	if r.profiler == nil || !r.profiler.allow(info.Time) {
+		return
+	}
+
+	r.profiler.writing.Add(1)
+	go func() {
+		defer r.profiler.writing.Done()
+
+		r.writeProfileRound(info)
+		r.pruneProfiles()
+	}()
+}
+
+// waitProfiles blocks until the profiles being written are done.
+func (r *Recovery) waitProfiles() {
+	if r.profiler != nil {
+		r.profiler.writing.Wait()
+	}
+}
+
+// writeProfileRound writes every enabled profile of a panic.
+func (r *Recovery) writeProfileRound(info *PanicInfo) {
+	if r.opt.GoroutineProfile {
+		// The debug=2 form is the full stack of every goroutine, like an unrecovered panic prints.
+		r.writeProfile(info, "goroutine", 2, "txt")
+	}
+
+	if r.opt.HeapProfile {
+		// The heap profile reflects the last garbage collection, so run one to capture the memory at the time of the panic.
+		runtime.GC()
+		r.writeProfile(info, "heap", 0, "pb.gz")
+	}
+}
+
+// writeProfile writes the named pprof profile to a file of the ProfileDir named after the profile, the time, and the fingerprint of the panic.
+func (r *Recovery) writeProfile(info *PanicInfo, name string, debug int, ext string) {
+	profile := pprof.Lookup(name)
+	if profile == nil {
+		return
+	}
+
+	path := filepath.Join(r.opt.ProfileDir, fmt.Sprintf("%s-%s-%s.%s", name, info.Time.Format("20060102T150405.000"), info.Fingerprint, ext))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		r.notef("Error writing %s profile: %s", name, err)
+		return
+	}
+
+	err = profile.WriteTo(f, debug)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		r.notef("Error writing %s profile: %s", name, err)
+		return
+	}
+
+	r.notef("Wrote %s profile to %s", name, path)
+}
+
+// pruneProfiles removes the oldest profiles from the ProfileDir until at most MaxProfiles are left. Only the files named like the ones Recovery writes are counted.
+func (r *Recovery) pruneProfiles() {
+	var files []string
+	for _, pattern := range profilePatterns {
+		matches, _ := filepath.Glob(filepath.Join(r.opt.ProfileDir, pattern))
+		files = append(files, matches...)
+	}
+	if len(files) <= r.opt.MaxProfiles {
+		return
+	}
+
+	// The names sort by time once the profile name in front is dropped.
+	sort.Slice(files, func(i, j int) bool {
+		return profileStamp(files[i]) < profileStamp(files[j])
+	})
+	for _, file := range files[:len(files)-r.opt.MaxProfiles] {
+		if err := os.Remove(file); err != nil {
+			r.notef("Error removing profile: %s", err)
+		}
+	}
+}
+
+// profileStamp returns the name of a profile file from the time on.
+func profileStamp(path string) string {
+	name := filepath.Base(path)
+	return name[strings.Index(name, "-")+1:]
+}
@@ -0,0 +1,156 @@
+package recovery
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGoroutineProfile(t *testing.T) {
+	dir := t.TempDir()
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:              buf,
+		ProfileDir:       dir,
+		GoroutineProfile: true,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Flush()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "goroutine-*.txt"))
+	expect(t, len(files), 1)
+	expectContainsTrue(t, buf.String(), "Wrote goroutine profile to "+files[0])
+
+	b, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectContainsTrue(t, string(b), "goroutine ")
+	expectContainsTrue(t, string(b), "[running]")
+
+	// A second panic within the interval writes no profile.
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Flush()
+	files, _ = filepath.Glob(filepath.Join(dir, "goroutine-*.txt"))
+	expect(t, len(files), 1)
+}
+
+func TestHeapProfile(t *testing.T) {
+	dir := t.TempDir()
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:              buf,
+		ProfileDir:       dir,
+		GoroutineProfile: true,
+		HeapProfile:      true,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "heap-*.pb.gz"))
+	expect(t, len(files), 1)
+	expectContainsTrue(t, buf.String(), "Wrote heap profile to "+files[0])
+
+	// The profile is gzipped protobuf.
+	b, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b, true)
+
+	// Both profiles share the interval.
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	expect(t, len(files), 2)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Flush()
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	expect(t, len(files), 2)
+}
+
+func TestGoroutineProfileError(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:              buf,
+		ProfileDir:       filepath.Join(t.TempDir(), "missing"),
+		GoroutineProfile: true,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Flush()
+
+	expectContainsTrue(t, buf.String(), "Error writing goroutine profile: ")
+}
+
+func TestProfilerAllow(t *testing.T) {
+	p := &profiler{interval: time.Minute}
+	now := time.Now()
+
+	expect(t, p.allow(now), true)
+	expect(t, p.allow(now.Add(30*time.Second)), false)
+	expect(t, p.allow(now.Add(time.Minute)), true)
+}
+
+func TestProfilesOffRequest(t *testing.T) {
+	dir := t.TempDir()
+	r := New(Options{
+		Out:              &blockingWriter{release: make(chan struct{})},
+		Sinks:            []Sink{{Out: ioutil.Discard}},
+		ProfileDir:       dir,
+		GoroutineProfile: true,
+	})
+
+	// The request is answered while the profile is still being written, as its note is held up by the output.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	out := r.opt.Out.(*blockingWriter)
+	close(out.release)
+	r.Flush()
+	expectContainsTrue(t, out.String(), "Wrote goroutine profile to ")
+}
+
+func TestMaxProfiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"goroutine-20240101T000000.000-a.txt",
+		"heap-20240101T000001.000-a.pb.gz",
+		"goroutine-20240101T000002.000-b.txt",
+		"notes.txt",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := New(Options{
+		Out:              ioutil.Discard,
+		ProfileDir:       dir,
+		GoroutineProfile: true,
+		MaxProfiles:      2,
+	})
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Flush()
+
+	// The two latest profiles are kept, and the files Recovery did not write are left alone.
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	expect(t, len(files), 3)
+	files, _ = filepath.Glob(filepath.Join(dir, "goroutine-20240101T000002.000-b.txt"))
+	expect(t, len(files), 1)
+	files, _ = filepath.Glob(filepath.Join(dir, "notes.txt"))
+	expect(t, len(files), 1)
+}
@@ -0,0 +1,71 @@
+package recovery
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRateBuckets is how many fingerprints the rate limiter tracks before it prunes the expired ones.
+const maxRateBuckets = 1024
+
+// rateLimiter caps how many panics with the same fingerprint are logged per window.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+// rateBucket counts the panics of one fingerprint in the current window.
+type rateBucket struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: map[string]*rateBucket{},
+	}
+}
+
+// allow reports whether a panic with the given fingerprint may be logged at now. When a new window starts, it also returns how many panics were suppressed in the previous one.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateBuckets {
+			l.prune(now)
+		}
+		b = &rateBucket{start: now}
+		l.buckets[key] = b
+	}
+
+	suppressed := 0
+	if now.Sub(b.start) >= l.window {
+		suppressed = b.suppressed
+		*b = rateBucket{start: now}
+	}
+
+	if b.count >= l.limit {
+		b.suppressed++
+		return false, suppressed
+	}
+
+	b.count++
+	return true, suppressed
+}
+
+// prune forgets the fingerprints whose window is over. Their suppressed counts go unreported.
+func (l *rateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.start) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
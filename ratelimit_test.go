@@ -0,0 +1,99 @@
+package recovery
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, time.Minute)
+	start := time.Now()
+
+	ok, suppressed := l.allow("a", start)
+	expect(t, ok, true)
+	expect(t, suppressed, 0)
+	ok, _ = l.allow("a", start.Add(time.Second))
+	expect(t, ok, true)
+	ok, _ = l.allow("a", start.Add(2*time.Second))
+	expect(t, ok, false)
+	ok, _ = l.allow("a", start.Add(3*time.Second))
+	expect(t, ok, false)
+
+	// Other fingerprints have their own budget.
+	ok, _ = l.allow("b", start.Add(3*time.Second))
+	expect(t, ok, true)
+
+	ok, suppressed = l.allow("a", start.Add(time.Minute))
+	expect(t, ok, true)
+	expect(t, suppressed, 2)
+
+	ok, suppressed = l.allow("a", start.Add(time.Minute+time.Second))
+	expect(t, ok, true)
+	expect(t, suppressed, 0)
+}
+
+func TestRateLimiterPrunes(t *testing.T) {
+	l := newRateLimiter(1, time.Minute)
+	start := time.Now()
+
+	for i := 0; i < maxRateBuckets; i++ {
+		l.allow(strings.Repeat("x", i+1), start)
+	}
+	expect(t, len(l.buckets), maxRateBuckets)
+
+	l.allow("late", start.Add(time.Minute))
+	expect(t, len(l.buckets), 1)
+}
+
+func TestRateLimit(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:             buf,
+		RateLimit:       2,
+		RateLimitWindow: 50 * time.Millisecond,
+	})
+
+	hooks := 0
+	r.OnPanic(func(req *http.Request, v interface{}, stack []byte) {
+		hooks++
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	for i := 0; i < 5; i++ {
+		res := httptest.NewRecorder()
+		r.Handler(myPanicHandler).ServeHTTP(res, req)
+		expect(t, res.Code, http.StatusInternalServerError)
+	}
+
+	expect(t, hooks, 5)
+	expect(t, strings.Count(buf.String(), "Recovering from Panic"), 2)
+
+	time.Sleep(60 * time.Millisecond)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), "Suppressed 3 duplicates of panic: this did not work")
+	expect(t, strings.Count(buf.String(), "Recovering from Panic"), 3)
+}
+
+func TestRateLimitDedupKeyFunc(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:       buf,
+		RateLimit: 1,
+		DedupKeyFunc: func(info PanicInfo) string {
+			return "same"
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(panicWith("first")).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	// Both panics share the key, so they share a budget too.
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: first")
+	expectContainsFalse(t, buf.String(), "this did not work")
+}
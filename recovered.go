@@ -0,0 +1,63 @@
+package recovery
+
+import (
+	"fmt"
+	"io"
+)
+
+// Recovered is a recovered panic as an error, so error tooling downstream can treat it like any other. It unwraps to the panic value when that is an error, prints its frames with %+v, and has a StackTrace method returning the program counters of its frames. The panic handler and hooks get it with RecoveredFromContext.
+type Recovered struct {
+	// Value is the value that was passed to panic.
+	Value interface{}
+	// Stack is where the panic was raised, innermost first.
+	Stack []Frame
+}
+
+// Recovered returns the panic as a *Recovered error.
+func (info *PanicInfo) Recovered() *Recovered {
+	return &Recovered{Value: info.Value, Stack: info.Frames}
+}
+
+// Error returns "panic: " followed by the panic value.
+func (e *Recovered) Error() string {
+	if e.Value == nil {
+		return "panic: runtime error: panic called with nil argument"
+	}
+
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error, for errors.Is and errors.As.
+func (e *Recovered) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Format prints the error with %s and %v, quoted with %q, and followed by every frame with %+v.
+func (e *Recovered) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		io.WriteString(s, e.Error())
+		if s.Flag('+') {
+			for _, frame := range e.Stack {
+				fmt.Fprintf(s, "\n%s\n\t%s:%d", frame.Func, frame.File, frame.Line)
+			}
+		}
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// StackTrace returns the program counters of the frames, every value one past the call, like an errors.Frame of github.com/pkg/errors. Frames without a PC are left out. The root module has no dependencies, so the result is a plain []uintptr, which reporters reading stack traces by reflection, like Sentry, accept, but it does not satisfy the stackTracer interface of pkg/errors: wrap the error with the recoverypkgerrors module for that.
+func (e *Recovered) StackTrace() []uintptr {
+	pcs := make([]uintptr, 0, len(e.Stack))
+	for _, frame := range e.Stack {
+		if frame.PC != 0 {
+			pcs = append(pcs, frame.PC+1)
+		}
+	}
+
+	return pcs
+}
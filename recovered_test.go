@@ -0,0 +1,85 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRecovered(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{Out: buf})
+
+	var rec *Recovered
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		if info, ok := FromContext(req.Context()); ok {
+			rec = info.Recovered()
+		}
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var err error = rec
+	expect(t, err.Error(), "panic: this did not work")
+	expect(t, fmt.Sprintf("%v", err), "panic: this did not work")
+	expect(t, fmt.Sprintf("%q", err), `"panic: this did not work"`)
+	expect(t, errors.Unwrap(err), nil)
+
+	verbose := fmt.Sprintf("%+v", err)
+	expect(t, strings.HasPrefix(verbose, "panic: this did not work\n"), true)
+	expectContainsTrue(t, verbose, "recovery_test.go:")
+
+	pcs := rec.StackTrace()
+	expect(t, len(pcs) > 0, true)
+	for _, pc := range pcs {
+		if fn := runtime.FuncForPC(pc - 1); fn == nil {
+			t.Errorf("Expected a function for pc %x", pc)
+		}
+	}
+}
+
+func TestRecoveredUnwrap(t *testing.T) {
+	info := &PanicInfo{Value: io.ErrUnexpectedEOF}
+	err := error(info.Recovered())
+
+	expect(t, errors.Is(err, io.ErrUnexpectedEOF), true)
+	expect(t, err.Error(), "panic: unexpected EOF")
+
+	info = &PanicInfo{}
+	expect(t, info.Recovered().Error(), "panic: runtime error: panic called with nil argument")
+}
+
+func TestRecoveredFromContext(t *testing.T) {
+	r := New(Options{Out: ioutil.Discard})
+
+	var inHandler, inHook *Recovered
+	r.SetPanicHandlerFunc(func(w http.ResponseWriter, req *http.Request, v interface{}, stack []byte) {
+		inHandler, _ = RecoveredFromContext(req.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		inHook, _ = RecoveredFromContext(req.Context())
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if inHandler == nil || inHook == nil {
+		t.Fatal("Expected the recovered panic in the panic handler and the hook")
+	}
+	expect(t, inHook, inHandler)
+	expect(t, inHook.Error(), "panic: this did not work")
+	expect(t, len(inHook.Stack) > 0, true)
+
+	_, ok := RecoveredFromContext(req.Context())
+	expect(t, ok, false)
+}
@@ -1,19 +1,48 @@
 package recovery
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"reflect"
+	"regexp"
 	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
+// truncatedMarker is appended to panic values that were cut short by MaxPanicValueBytes.
+const truncatedMarker = "...(truncated)"
+
 // Options is a struct for specifying configuration parameters for the Recovery middleware.
 type Options struct {
 	// IncludeFullStack if set to true, will dump the complete stack instead of the single goroutine that panicked. Default is false (single goroutine only).
 	IncludeFullStack bool
-	// StackSize sets how large the []byte buffer is for the stack dump. Default is 8192.
+	// FrameFilter, if set, decides which frames of the stack are kept in the logs, the PanicInfo, and the debug page, like DefaultFrameFilter or SkipFrames. It returns false for the frames to drop. Default is nil (every frame).
+	FrameFilter func(frame Frame) bool
+	// SourceContext, if above 0, adds that many lines of source around each application frame to the log output, and sets how many the Development page shows. The source files have to be on disk, which production binaries often lack. Default is 0 (no source in the logs, 3 lines on the page).
+	SourceContext int
+	// TrimPaths if set to true, will shorten the file paths of the logged stack to ones that are the same on every build machine, without the module cache directory and module versions, like "github.com/unrolled/recovery/recovery.go". The Frames of the PanicInfo keep the full paths, to read the source from. Default is false.
+	TrimPaths bool
+	// CleanStack if set to true, will log only the function, file, and line of each frame of the stack, without goroutine headers, argument values, and program counter offsets. Default is false.
+	CleanStack bool
+	// DisableStackCapture if set to true, will skip capturing the stack altogether, so only the panic value and request details are logged. Default is false.
+	DisableStackCapture bool
+	// MaxFrames, if above 0, caps how many frames of each goroutine are kept in the stack, after the FrameFilter. The frames past it are replaced with a single "...additional frames elided..." line, so the output is bounded without being cut mid-line. Default is 0 (no cap).
+	MaxFrames int
+	// StackSize sets how large the []byte buffer is for the stack dump. A dump of the goroutine that panicked is cut after the last frame that fits, and ends with an "...additional frames elided..." line. Default is 8192.
 	StackSize int
+	// MaxStackSize, if above StackSize, has the stack buffer doubled until the dump fits or it reaches this size, so deep stacks and IncludeFullStack dumps are not cut short. Default is 0 (a fixed StackSize).
+	MaxStackSize int
 	// Prefix is the outputted keyword in front of the log message. Logger automatically wraps the prefix in square brackets (ie. [myApp] ) unless the `DisableAutoBrackets` is set to true. A blank value will not have brackets added. Default is blank (with no brackets).
 	Prefix string
 	// DisableAutoBrackets if set to true, will remove the prefix and square brackets. Default is false.
@@ -22,13 +51,184 @@ type Options struct {
 	Out io.Writer
 	// OutputFlags defines the logging properties. See http://golang.org/pkg/log/#pkg-constants. To disable all flags, set this to `-1`. Defaults to log.LstdFlags (2009/01/23 01:23:23).
 	OutputFlags int
+	// MaxPanicValueBytes caps the length of the stringified panic value in the log output. Values longer than this are cut short and suffixed with a truncation marker. Default is 0 (no cap).
+	MaxPanicValueBytes int
+	// TimeFormat is the layout of the timestamps of FormatJSON and FormatLogfmt records, like time.RFC3339Nano. When set, it also replaces the date and time of the OutputFlags in FormatText records and notes. Default is blank (time.RFC3339 for JSON and logfmt, the OutputFlags for text).
+	TimeFormat string
+	// UTC if set to true, will write every timestamp in UTC instead of local time. Default is false.
+	UTC bool
+	// Format is how each panic is rendered to `Out`: FormatText, FormatJSON, FormatLogfmt, FormatGELF, FormatECS, FormatCloudEvents, or FormatGCP. Default is FormatText.
+	Format LogFormat
+	// Formats lists several formats each panic is rendered in, in order, to `Out`. Every format produces a single write so records never interleave. When set, Format is ignored. Default is nil (just Format).
+	Formats []LogFormat
+	// JSONStackAsArray if set to true, will write the stack of FormatJSON records as an array of lines instead of one string. Default is false.
+	JSONStackAsArray bool
+	// Formatter, if set, renders every panic record written to `Out`, for full control over its shape. When set, Format and Formats are ignored. Default is nil (the built-in formats).
+	Formatter Formatter
+	// GELFFields are additional fields added to every FormatGELF message, like the application name or environment. Names go without the leading underscore, and "id" is reserved by GELF. Default is nil.
+	GELFFields map[string]string
+	// CloudEventsSource is the source of every FormatCloudEvents event, a URI reference identifying the application. Default is "/" followed by the Prefix, or "/recovery" without one.
+	CloudEventsSource string
+	// CloudEventsType is the type of every FormatCloudEvents event. Default is "com.github.unrolled.recovery.panic".
+	CloudEventsType string
+	// GCPProjectID is the Google Cloud project the traces of FormatGCP entries belong to. Default is blank (the GOOGLE_CLOUD_PROJECT environment variable).
+	GCPProjectID string
+	// GCPService names the service FormatGCP entries are reported under in Cloud Error Reporting. Default is blank (the K_SERVICE environment variable of Cloud Run, or else the Prefix).
+	GCPService string
+	// GCPServiceVersion is the version of the service FormatGCP entries are reported under in Cloud Error Reporting. Default is blank (the K_REVISION environment variable of Cloud Run).
+	GCPServiceVersion string
+	// Sinks lists several destinations each panic is written to, each with its own format and flags, like text to stderr and JSON to a file. When set, Format, Formats, and Formatter are ignored and `Out` is only written to by sinks without an `Out` of their own. Default is nil (just `Out`).
+	Sinks []Sink
+	// ShouldRecover, if set, decides whether a panic value is recovered. When it returns false the value is re-panicked untouched, for upstream supervisors that rely on specific panics propagating. Default is nil (recover everything).
+	ShouldRecover func(recovered interface{}) bool
+	// Severity, if set, classifies a panic value into a Severity, which is reflected in the log output and stored on the PanicInfo for hooks and reporters. Default is nil (every panic is SeverityError).
+	Severity func(recovered interface{}) Severity
+	// Repanic if set to true, will re-panic with the original value once the panic is logged, the response written, and the hooks run. Useful when an outer supervisor must still see the crash. Default is false.
+	Repanic bool
+	// ContentNegotiation if set to true, will have the default panic handler answer in JSON, XML, HTML, or plain text depending on the request's Accept header. Default is false (always plain text).
+	ContentNegotiation bool
+	// XMLRootName is the root element of the XML error document served to XML clients when ContentNegotiation is on. Default is "error".
+	XMLRootName string
+	// ErrorTemplate, if set, renders the HTML error page of the default panic handler. It is executed with an ErrorPageData. Default is nil (a bare built-in page).
+	ErrorTemplate *template.Template
+	// ErrorTemplateDetails if set to true, will fill in the panic value and stack of the ErrorPageData. Only enable this during development. Default is false.
+	ErrorTemplateDetails bool
+	// Development if set to true, will have the default panic handler serve a debug page with the panic value, stack frames, source snippets, and request details. Never enable this in production. Default is false.
+	Development bool
+	// Production if set to true, will guarantee no panic details reach the client. Whatever the panic handler writes is buffered and replaced with the generic status text, keeping only its status code, so not even an escaped or encoded panic value gets out. Responses also get no-cache headers and `Connection: close`. Development and ErrorTemplateDetails are ignored. Default is false.
+	Production bool
+	// ResponseStatus is the status code written by the default panic handler. Default is 500.
+	ResponseStatus int
+	// ResponseHeaders are set on the response by the default panic handler before it is written, like `Retry-After` or `Cache-Control: no-store`. Default is nil (no extra headers).
+	ResponseHeaders http.Header
+	// AbortConnection if set to true, will abort the connection instead of writing an error response, so the client sees a reset rather than a 500. The panic is still logged and the hooks run. This takes precedence over Repanic. Default is false.
+	AbortConnection bool
+	// LocalizedMessages maps language tags (like "fr" or "pt-BR") to the error message the default panic handler writes for clients preferring that language, per the Accept-Language header. Default is nil (always the English status text).
+	LocalizedMessages map[string]string
+	// RequestIDHeader is the request header a request id is read from. Requests without one get a generated id. Default is "X-Request-ID".
+	RequestIDHeader string
+	// RequestIDFunc, if set, returns the request id assigned by another middleware, like the one of a router or tracing library, so the panic records carry the same id as its logs. An empty result falls back to the request context and RequestIDHeader. Default is nil.
+	RequestIDFunc func(req *http.Request) string
+	// TraceFunc, if set, returns the trace and span ids of the request, in hex, and whether its trace is sampled, like from the span of a tracing library on the request context. A blank trace id falls back to the traceparent and X-Cloud-Trace-Context headers. Default is nil.
+	TraceFunc func(req *http.Request) (traceID, spanID string, sampled bool)
+	// IncludeRequestID if set to true, will add the request id to the log record and the default error response (both the body and the RequestIDHeader response header), so a user-reported error can be matched to its stack trace. Default is false.
+	IncludeRequestID bool
+	// LogRequestDetails if set to true, will add the request URL, protocol, remote address, and route pattern to the log record, so a stack trace can be tied to the request that triggered it. Default is false (just the method and path).
+	LogRequestDetails bool
+	// LogHeaders lists the request headers added to the log record, like "User-Agent" or "Referer". Default is nil (no headers).
+	LogHeaders []string
+	// RedactHeaders lists the request headers whose values are replaced with "[REDACTED]" wherever request headers are logged or shown, so credentials never reach log aggregation. Set an empty, non nil list to show every header verbatim. Default is Authorization, Cookie, and X-Api-Key.
+	RedactHeaders []string
+	// ScrubParams lists query parameter name fragments, matched case insensitively, whose values are replaced with "[REDACTED]" in the logged URL. Set an empty, non nil list to log query strings verbatim. Default is password, passwd, secret, token, api_key, and apikey.
+	ScrubParams []string
+	// ScrubPatterns are replaced with "[REDACTED]" wherever they match the logged path, URL, headers, and panic value, like EmailPattern for personal data. Default is nil.
+	ScrubPatterns []*regexp.Regexp
+	// CaptureBodyBytes if above 0, will keep up to this many bytes of the request body as the handler reads it, and add them to the log record and PanicInfo, since many panics are caused by malformed payloads. The ScrubPatterns apply. Default is 0 (no capture).
+	CaptureBodyBytes int
+	// RoutePattern, if set, returns the route pattern logged with LogRequestDetails and counted with ExpvarName, for routers that keep it on the request context. Default is nil (the http.ServeMux pattern, from Go 1.23 on).
+	RoutePattern func(req *http.Request) string
+	// BufferResponse if set to true, will buffer the whole response and only send it once the handler returns. On panic the partial output is thrown away, so the error response is never appended to half a body. Buffered responses cannot be streamed. Default is false.
+	BufferResponse bool
+	// StreamPolicy decides what happens when a handler panics after it started streaming (flushed part of the response): StreamLogOnly, StreamAbort, or StreamSSEError, which only writes its event to a text/event-stream response. The panic is logged either way. Default is StreamLogOnly.
+	StreamPolicy StreamPolicy
+	// RateLimit, if above 0, caps how many panics with the same stack fingerprint are logged per RateLimitWindow, so a crash looping endpoint does not flood the logs with identical stack traces. The number of suppressed duplicates is logged once the next window starts. Responses and hooks are not affected. Default is 0 (log every panic).
+	RateLimit int
+	// RateLimitWindow is the window of RateLimit. Default is one minute.
+	RateLimitWindow time.Duration
+	// DedupWindow, if above 0, collapses panics with the same fingerprint into one aggregated entry per window. The first panic is logged right away, and the duplicates that follow within the window are logged as a single entry with their count once it is over. Default is 0 (no deduplication).
+	DedupWindow time.Duration
+	// DedupKeyFunc, if set, returns the key under which panics are deduplicated and rate limited, instead of the fingerprint. Default is nil (PanicInfo.Fingerprint).
+	DedupKeyFunc func(info PanicInfo) string
+	// ProfileDir is the directory the profiles enabled by GoroutineProfile and HeapProfile are written to when a panic is recovered. Default is blank (no profiles).
+	ProfileDir string
+	// GoroutineProfile if set to true, will write the pprof goroutine profile, with the full stack of every goroutine, to the ProfileDir, to diagnose the deadlocks and leaks a panic comes with. Default is false.
+	GoroutineProfile bool
+	// HeapProfile if set to true, will write the pprof heap profile to the ProfileDir, to analyze the memory at the time of a panic, like one caused by a huge allocation. It runs a garbage collection first. Default is false.
+	HeapProfile bool
+	// ProfileInterval is the least time between two rounds of profiles, as writing them is costly. Default is 1 minute.
+	ProfileInterval time.Duration
+	// MaxProfiles caps how many profiles are kept in the ProfileDir. Once a round goes over it, the oldest ones are removed. Default is 10.
+	MaxProfiles int
+	// Slog, if set, receives every panic as a structured entry at the level matching its severity (error by default), with the panic, stack, method, and path as attributes. It replaces `Out`, Formats, and the logger flags; sinks with an `Out` of their own are still written to. Default is nil.
+	Slog *slog.Logger
+	// Logger, if set, receives every rendered panic record and note through Printf instead of `Out`, so logrus, zap, zerolog, or an in-house logger can be plugged in directly. The logger adds its own prefix and timestamps, so Prefix and OutputFlags are not applied to FormatText records. Default is nil.
+	Logger Logger
+	// AsyncLogging if set to true, will hand panic records to a background goroutine through a bounded queue, so the error response goes out right away even when the log sink is slow. Records are dropped when the queue is full. Call Flush or Close before exiting to not lose queued records. Default is false.
+	AsyncLogging bool
+	// AsyncQueueSize is how many records the AsyncLogging queue holds. Default is 1024.
+	AsyncQueueSize int
+	// ExpvarName, if set, is the name of an expvar map counting the recovered panics, in total and per route pattern, so they show up under /debug/vars. Recoveries with the same name share the map. Default is blank (no expvar).
+	ExpvarName string
+}
+
+// Sink is a destination for panic records, with its own format. See Options.Sinks.
+type Sink struct {
+	// Out is where the records are written. Default is nil (the `Out` or Logger of the Options).
+	Out io.Writer
+	// Format is how records are rendered: FormatText, FormatJSON, FormatLogfmt, FormatGELF, FormatECS, FormatCloudEvents, or FormatGCP. Default is FormatText.
+	Format LogFormat
+	// Formatter, if set, renders the records instead of Format.
+	Formatter Formatter
+	// OutputFlags defines the logging properties of FormatText records, like Options.OutputFlags. Default is log.LstdFlags.
+	OutputFlags int
+}
+
+// sink is a configured Sink. A nil out is the main output.
+type sink struct {
+	out       io.Writer
+	formatter Formatter
+	// mu serializes the writes to out, shared with the sinks writing to the same output. It is nil without an out.
+	mu *sync.Mutex
+}
+
+// Logger is the minimal logger accepted by Options.Logger. The standard *log.Logger, logrus, and zerolog satisfy it as is.
+type Logger interface {
+	Printf(format string, v ...interface{})
 }
 
+// PanicLogger is implemented by a Logger that logs panic records as structured entries, like the adapters for zap, logrus, and zerolog. Such a Logger gets each record as is instead of a rendered line, with its RequestID blank unless IncludeRequestID is set, while notes still go through Printf.
+type PanicLogger interface {
+	LogPanic(rec *PanicRecord)
+}
+
+// LoggerFunc adapts a Printf-style function, like the Errorf method of a zap SugaredLogger, to a Logger.
+type LoggerFunc func(format string, v ...interface{})
+
+// Printf calls f(format, v...).
+func (f LoggerFunc) Printf(format string, v ...interface{}) {
+	f(format, v...)
+}
+
+// outputFlags resolves an OutputFlags value, where -1 disables all flags and 0 means log.LstdFlags.
+func outputFlags(flags int) int {
+	switch flags {
+	case -1:
+		return 0
+	case 0:
+		return log.LstdFlags
+	default:
+		return flags
+	}
+}
+
+// PanicHandlerFunc writes the error response for a recovered panic. It receives the recovered value and the captured stack.
+type PanicHandlerFunc func(w http.ResponseWriter, r *http.Request, panicVal interface{}, stack []byte)
+
 // Recovery is a HTTP middleware that catches any panics and serves a proper error response.
 type Recovery struct {
 	*log.Logger
 	opt          Options
-	panicHandler http.Handler
+	panicHandler atomic.Value // PanicHandlerFunc
+	hooks        []func(*http.Request, interface{}, []byte)
+	mappings     []panicMapping
+	sinks        []sink
+	outMu        sync.Mutex
+	async        *asyncQueue
+	posters      []*postWriter
+	limiter      *rateLimiter
+	deduper      *deduper
+	profiler     *profiler
+	vars         *panicVars
 }
 
 // New returns a new Recovery instance.
@@ -45,6 +245,81 @@ func New(opts ...Options) *Recovery {
 		o.StackSize = 8 * 1024
 	}
 
+	// Request id header.
+	if len(o.RequestIDHeader) == 0 {
+		o.RequestIDHeader = "X-Request-ID"
+	}
+
+	// XML root element.
+	if len(o.XMLRootName) == 0 {
+		o.XMLRootName = "error"
+	}
+
+	// Localized messages are matched on lower case tags.
+	if len(o.LocalizedMessages) > 0 {
+		messages := make(map[string]string, len(o.LocalizedMessages))
+		for tag, message := range o.LocalizedMessages {
+			messages[strings.ToLower(tag)] = message
+		}
+		o.LocalizedMessages = messages
+	}
+
+	// Response status.
+	if o.ResponseStatus == 0 {
+		o.ResponseStatus = http.StatusInternalServerError
+	}
+
+	// Redacted headers, matched on canonical names.
+	if o.RedactHeaders == nil {
+		o.RedactHeaders = defaultRedactHeaders
+	}
+	redact := make([]string, 0, len(o.RedactHeaders))
+	for _, name := range o.RedactHeaders {
+		redact = append(redact, http.CanonicalHeaderKey(name))
+	}
+	o.RedactHeaders = redact
+
+	// Scrubbed query parameters, matched on lower case names.
+	if o.ScrubParams == nil {
+		o.ScrubParams = defaultScrubParams
+	}
+	scrub := make([]string, 0, len(o.ScrubParams))
+	for _, fragment := range o.ScrubParams {
+		scrub = append(scrub, strings.ToLower(fragment))
+	}
+	o.ScrubParams = scrub
+
+	// Rate limit window.
+	if o.RateLimitWindow <= 0 {
+		o.RateLimitWindow = time.Minute
+	}
+
+	// Profile interval.
+	if o.ProfileInterval <= 0 {
+		o.ProfileInterval = time.Minute
+	}
+
+	// Profile count.
+	if o.MaxProfiles <= 0 {
+		o.MaxProfiles = 10
+	}
+
+	// Async queue size.
+	if o.AsyncQueueSize <= 0 {
+		o.AsyncQueueSize = 1024
+	}
+
+	// CloudEvents source and type.
+	if len(o.CloudEventsSource) == 0 {
+		o.CloudEventsSource = "/recovery"
+		if len(o.Prefix) > 0 {
+			o.CloudEventsSource = "/" + o.Prefix
+		}
+	}
+	if len(o.CloudEventsType) == 0 {
+		o.CloudEventsType = "com.github.unrolled.recovery.panic"
+	}
+
 	// Determine prefix.
 	prefix := o.Prefix
 	if len(prefix) > 0 && o.DisableAutoBrackets == false {
@@ -60,46 +335,505 @@ func New(opts ...Options) *Recovery {
 		output = os.Stderr
 	}
 
-	// Determine output flags.
-	flags := log.LstdFlags
-	if o.OutputFlags == -1 {
-		flags = 0
-	} else if o.OutputFlags != 0 {
-		flags = o.OutputFlags
+	r := &Recovery{
+		Logger: log.New(output, prefix, outputFlags(o.OutputFlags)),
+		opt:    o,
+	}
+	r.panicHandler.Store(PanicHandlerFunc(r.defaultPanicHandler))
+
+	// Text records sent to a plugged in Logger get neither prefix nor flags.
+	mainLogger := r.Logger
+	if o.Logger != nil {
+		mainLogger = nil
+	}
+
+	// Determine sinks.
+	if len(o.Sinks) > 0 {
+		for _, s := range o.Sinks {
+			formatter := s.Formatter
+			if formatter == nil {
+				logger := log.New(io.Discard, prefix, outputFlags(s.OutputFlags))
+				if s.Out == nil && o.Logger != nil {
+					logger = nil
+				}
+				formatter = r.formatter(s.Format, logger)
+			}
+			r.sinks = append(r.sinks, sink{out: s.Out, formatter: formatter, mu: r.outputLock(s.Out, output)})
+
+			// The retries of a post run after the record is written, so their failure is noted then.
+			if w, ok := s.Out.(*postWriter); ok {
+				w.notef = r.notef
+				r.posters = append(r.posters, w)
+			}
+		}
+	} else if o.Formatter != nil {
+		r.sinks = []sink{{formatter: o.Formatter}}
+	} else if len(o.Formats) > 0 {
+		for _, format := range o.Formats {
+			r.sinks = append(r.sinks, sink{formatter: r.formatter(format, mainLogger)})
+		}
+	} else {
+		r.sinks = []sink{{formatter: r.formatter(o.Format, mainLogger)}}
+	}
+
+	if o.AsyncLogging {
+		r.async = newAsyncQueue(o.AsyncQueueSize)
+	}
+
+	if o.RateLimit > 0 {
+		r.limiter = newRateLimiter(o.RateLimit, o.RateLimitWindow)
 	}
 
-	return &Recovery{
-		Logger:       log.New(output, prefix, flags),
-		opt:          o,
-		panicHandler: http.HandlerFunc(defaultPanicHandler),
+	if o.DedupWindow > 0 {
+		r.deduper = newDeduper(o.DedupWindow, r.writeRecord)
 	}
+
+	if len(o.ProfileDir) > 0 && (o.GoroutineProfile || o.HeapProfile) {
+		r.profiler = &profiler{interval: o.ProfileInterval}
+	}
+
+	if len(o.ExpvarName) > 0 {
+		r.vars = newPanicVars(o.ExpvarName)
+	}
+
+	return r
 }
 
 // Handler wraps an HTTP handler and recovers any panics from up stream.
 func (r *Recovery) Handler(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, req *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				r.panicHandler.ServeHTTP(w, req)
+		var nilStack *capturedStack
+		completed := false
 
-				stack := make([]byte, r.opt.StackSize)
-				stack = stack[:runtime.Stack(stack, r.opt.IncludeFullStack)]
+		// Only the handler reads the body, so tee what it reads rather than buffering the request up front.
+		var body *bodyCapture
+		if r.opt.CaptureBodyBytes > 0 && req.Body != nil && req.Body != http.NoBody {
+			body = &bodyCapture{ReadCloser: req.Body, limit: r.opt.CaptureBodyBytes}
+			req.Body = body
+		}
 
-				r.Printf("Recovering from Panic: %s\n%s", err, stack)
-			}
+		tracked := &trackingWriter{ResponseWriter: w}
+		out := tracked.wrap()
+		var buffered *bufferedWriter
+		if r.opt.BufferResponse {
+			buffered = newBufferedWriter()
+			out = buffered
+		}
+
+		func() {
+			defer func() {
+				if completed {
+					return
+				}
+
+				err := recover()
+				if err == nil {
+					// Either panic(nil) where it is not wrapped in *runtime.PanicNilError (GODEBUG=panicnil=1), or runtime.Goexit. Only a recovered panic returns from this function, so keep the stack and decide below.
+					nilStack = r.captureStack()
+					return
+				}
+
+				r.recoverPanic(tracked, req, body, err, nil)
+			}()
+
+			next.ServeHTTP(out, req)
+			completed = true
 		}()
 
-		next.ServeHTTP(w, req)
+		if completed && buffered != nil {
+			buffered.writeTo(tracked)
+		}
+
+		if nilStack != nil {
+			r.recoverPanic(tracked, req, body, nil, nilStack)
+		}
 	}
 
 	return http.HandlerFunc(fn)
 }
 
-// SetPanicHandler sets the handler to call when Recovery encounters a panic.
+// ServeHTTP recovers any panics of next, so Recovery can be used as a negroni middleware, or in any stack calling its middleware with the next handler.
+func (r *Recovery) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	r.Handler(next).ServeHTTP(w, req)
+}
+
+// recoverPanic handles a recovered panic value. A nil stack is captured on the spot, which must then happen while still unwinding the panic.
+func (r *Recovery) recoverPanic(w *trackingWriter, req *http.Request, body *bodyCapture, err interface{}, st *capturedStack) {
+	// http.ErrAbortHandler is how net/http aborts a response on purpose. There is no client left to answer, so hand it back to the server which tears down the connection quietly.
+	if err == http.ErrAbortHandler {
+		panic(err)
+	}
+
+	if r.opt.ShouldRecover != nil && !r.opt.ShouldRecover(err) {
+		panic(err)
+	}
+
+	if st == nil {
+		st = r.captureStack()
+	}
+
+	info := &PanicInfo{
+		Value:     err,
+		Time:      time.Now(),
+		RequestID: r.requestID(req),
+		Severity:  r.severity(err),
+		Body:      r.capturedBody(req, body),
+	}
+	stack := r.panicStack(info, st, r.route(req))
+	req = req.WithContext(newContext(req.Context(), info))
+
+	abort := r.opt.AbortConnection
+	switch {
+	case abort:
+	case w.streaming:
+		abort = r.endStream(w)
+	case w.committed:
+		// Writing now would only trigger "superfluous WriteHeader" warnings and corrupt what the client already has.
+		r.notef("Response already committed, skipping the panic handler")
+	default:
+		r.respond(w.wrap(), req, err, stack)
+	}
+	var rec *PanicRecord
+	if r.allowLog(info) {
+		rec = r.newRecord(req, "Recovering from Panic", info, w.status)
+		if r.deduper == nil || r.deduper.first(r.dedupKey(info), req.Context(), rec) {
+			r.writeRecord(req.Context(), rec)
+		}
+	}
+
+	r.writeProfiles(info)
+	r.countPanic(req)
+
+	if len(r.hooks) > 0 {
+		// A copy of the logged record, which DedupWindow may still update.
+		var hookRec PanicRecord
+		if rec != nil {
+			hookRec = *r.loggerRecord(rec)
+		} else {
+			hookRec = *r.loggerRecord(r.newRecord(req, "Recovering from Panic", info, w.status))
+		}
+		req = req.WithContext(context.WithValue(req.Context(), panicRecordKey, &hookRec))
+	}
+	for _, hook := range r.hooks {
+		hook(req, err, stack)
+	}
+
+	if abort {
+		// net/http closes the connection (or resets the HTTP/2 stream) without writing anything.
+		panic(http.ErrAbortHandler)
+	}
+
+	if r.opt.Repanic {
+		panic(err)
+	}
+}
+
+// allowLog applies the RateLimit to a panic, logging how many duplicates were suppressed in the previous window.
+func (r *Recovery) allowLog(info *PanicInfo) bool {
+	if r.limiter == nil {
+		return true
+	}
+
+	ok, suppressed := r.limiter.allow(r.dedupKey(info), info.Time)
+	if suppressed > 0 {
+		r.notef("Suppressed %d duplicates of panic: %s", suppressed, r.loggedPanicValue(info.Value))
+	}
+
+	return ok
+}
+
+// captureStack captures the stack of the current goroutine, its frames from runtime.Callers. With IncludeFullStack, the runtime.Stack dump is taken too, as only it sees the other goroutines.
+func (r *Recovery) captureStack() *capturedStack {
+	if r.opt.DisableStackCapture {
+		return &capturedStack{}
+	}
+
+	callers := callerFrames()
+	st := &capturedStack{id: goroutineID(), frames: make([]Frame, len(callers))}
+	for i, caller := range callers {
+		st.frames[i] = Frame{Func: caller.Function, File: caller.File, Line: caller.Line, PC: caller.PC}
+	}
+	if !r.opt.IncludeFullStack {
+		return st
+	}
+
+	stack := make([]byte, r.opt.StackSize)
+	n := runtime.Stack(stack, true)
+
+	// A full buffer means the dump was cut short. Grow it up to MaxStackSize until it fits.
+	for n == len(stack) && len(stack) < r.opt.MaxStackSize {
+		size := 2 * len(stack)
+		if size > r.opt.MaxStackSize {
+			size = r.opt.MaxStackSize
+		}
+		stack = make([]byte, size)
+		n = runtime.Stack(stack, true)
+	}
+	st.full = append([]byte(nil), stack[:n]...)
+
+	return st
+}
+
+// panicStack fills in the Stack, Frames, Fingerprint, and Origin of info from st, and returns the stack dump handed to the panic handler and hooks. The fingerprint and origin are taken from every frame, before the FrameFilter, so they stay the same whatever it drops. The dump is rendered from the frames the filter keeps, with TrimPaths and CleanStack applied to the logged one; only a full dump, holding goroutines no frames are known for, is filtered as text.
+func (r *Recovery) panicStack(info *PanicInfo, st *capturedStack, route string) []byte {
+	info.Fingerprint, info.Origin = fingerprint(info.Value, st.frames, route), origin(st.frames)
+
+	var elided bool
+	info.Frames, elided = r.filterFrames(st.frames)
+
+	switch {
+	case st.full != nil:
+		stack := r.filterStack(st.full)
+		info.Stack = r.cleanStack(r.trimStack(stack))
+		return stack
+	case len(st.frames) == 0:
+		// Empty rather than nil, like any captured stack.
+		info.Stack = []byte{}
+		return info.Stack
+	}
+
+	stack := r.renderFrames(st.id, info.Frames, elided, false, false)
+	info.Stack = stack
+	if r.opt.TrimPaths || r.opt.CleanStack {
+		info.Stack = r.renderFrames(st.id, info.Frames, elided, r.opt.TrimPaths, r.opt.CleanStack)
+	}
+
+	return stack
+}
+
+// respond writes the error response for a recovered panic, hardened first in Production mode.
+func (r *Recovery) respond(w http.ResponseWriter, req *http.Request, v interface{}, stack []byte) {
+	if r.opt.Production {
+		hw := newBufferedWriter()
+		r.serveError(hw, req, v, stack)
+		r.writeHardened(w, req, hw)
+		return
+	}
+
+	r.serveError(w, req, v, stack)
+}
+
+// serveError runs the mapped response or the panic handler. A panic raised by the panic handler itself is logged and answered with the default response, so the original panic is never lost.
+func (r *Recovery) serveError(w http.ResponseWriter, req *http.Request, v interface{}, stack []byte) {
+	if m, ok := r.mappingFor(v); ok {
+		r.writeError(w, req, m.statusCode, m.body, v, stack)
+		return
+	}
+
+	defer func() {
+		if err := recover(); err != nil {
+			handlerInfo := &PanicInfo{
+				Value:    err,
+				Time:     time.Now(),
+				Severity: r.severity(err),
+			}
+			r.panicStack(handlerInfo, r.captureStack(), r.route(req))
+			if info, ok := FromContext(req.Context()); ok {
+				handlerInfo.RequestID = info.RequestID
+			}
+			r.logPanic(req, "Recovering from Panic in panic handler", handlerInfo, 0)
+
+			// Drop whatever the broken handler got out, when it was buffered.
+			if hw, ok := w.(*bufferedWriter); ok {
+				hw.reset()
+			}
+			r.defaultPanicHandler(w, req, v, stack)
+		}
+	}()
+
+	r.panicHandler.Load().(PanicHandlerFunc)(w, req, v, stack)
+}
+
+// logPanic logs a panic right away, bypassing RateLimit and DedupWindow.
+func (r *Recovery) logPanic(req *http.Request, message string, info *PanicInfo, status int) {
+	r.writeRecord(req.Context(), r.newRecord(req, message, info, status))
+}
+
+// newRecord builds the log view of a panic.
+func (r *Recovery) newRecord(req *http.Request, message string, info *PanicInfo, status int) *PanicRecord {
+	rec := &PanicRecord{
+		PanicInfo: info,
+		Message:   message,
+		Prefix:    r.opt.Prefix,
+		Panic:     r.loggedPanicValue(info.Value),
+		Method:    req.Method,
+		Path:      r.scrubText(req.URL.Path),
+		Status:    status,
+	}
+	if r.opt.TraceFunc != nil {
+		rec.TraceID, rec.SpanID, rec.TraceSampled = r.opt.TraceFunc(req)
+	}
+	if len(rec.TraceID) == 0 {
+		rec.TraceID, rec.SpanID, rec.TraceSampled = traceContext(req)
+	}
+	if r.opt.SourceContext > 0 {
+		rec.Source = r.sourceText(info.Frames)
+	}
+	r.requestDetails(rec, req)
+
+	return rec
+}
+
+// writeRecord writes a panic record with each of the configured formatters. When Slog or a PanicLogger is set, it gets the record in place of the main output, while the sinks with an `Out` of their own are still written to. The context is handed to Slog.
+func (r *Recovery) writeRecord(ctx context.Context, rec *PanicRecord) {
+	rec = r.loggerRecord(rec)
+
+	pl, structured := r.opt.Logger.(PanicLogger)
+	switch {
+	case r.opt.Slog != nil:
+		structured = true
+		r.emit(func() { r.logSlog(ctx, rec) })
+	case structured:
+		r.emit(func() { pl.LogPanic(rec) })
+	}
+
+	for _, s := range r.sinks {
+		if s.out == nil && structured {
+			continue
+		}
+		if b := s.formatter.Format(rec); len(b) > 0 {
+			r.write(s, b)
+		}
+	}
+}
+
+// loggerRecord is the record handed to the formatters, Slog, a PanicLogger, and the hooks: a copy without the request id, unless IncludeRequestID asks for it, so no sink reports an id that no log line holds.
+func (r *Recovery) loggerRecord(rec *PanicRecord) *PanicRecord {
+	if r.opt.IncludeRequestID || rec.PanicInfo == nil || len(rec.RequestID) == 0 {
+		return rec
+	}
+
+	logged, info := *rec, *rec.PanicInfo
+	info.RequestID = ""
+	logged.PanicInfo = &info
+	return &logged
+}
+
+// outputLock returns the lock serializing the writes to the output of a sink: the one of the main output or of an earlier sink when they share it, or a new one. Each output has its own, so a slow one, like a post, holds up no other. Only pointers are compared, as other writers may not be comparable.
+func (r *Recovery) outputLock(out, main io.Writer) *sync.Mutex {
+	if out == nil {
+		return nil
+	}
+	if reflect.ValueOf(out).Kind() == reflect.Ptr {
+		if out == main {
+			return &r.outMu
+		}
+		for _, s := range r.sinks {
+			if s.out == out {
+				return s.mu
+			}
+		}
+	}
+
+	return &sync.Mutex{}
+}
+
+// write sends one complete record to the output of a sink, or when it has none to the main output or Options.Logger. Records written to the same output are serialized so they never interleave. A sink output failing is noted.
+func (r *Recovery) write(s sink, b []byte) {
+	r.emit(func() {
+		if s.out == nil {
+			if r.opt.Logger != nil {
+				r.opt.Logger.Printf("%s", bytes.TrimSuffix(b, []byte("\n")))
+				return
+			}
+
+			r.outMu.Lock()
+			r.Writer().Write(b)
+			r.outMu.Unlock()
+			return
+		}
+
+		s.mu.Lock()
+		_, err := s.out.Write(b)
+		s.mu.Unlock()
+
+		// A sink failing is noted on the main output, which has no one to note its own failures to.
+		if err != nil {
+			r.notef("Error writing panic record: %s", err)
+		}
+	})
+}
+
+// notef logs a note about how a panic was handled, through Slog at warning level when it is set.
+func (r *Recovery) notef(format string, v ...interface{}) {
+	message := fmt.Sprintf(format, v...)
+
+	switch {
+	case r.opt.Slog != nil:
+		r.emit(func() { r.opt.Slog.Warn(message) })
+	case r.opt.Logger != nil:
+		r.emit(func() { r.opt.Logger.Printf("%s", message) })
+	default:
+		// Call depth 2 reports the caller of notef, like the embedded logger would.
+		buf := getBuffer()
+		r.scratchLogger(buf, r.Logger, time.Now()).Output(2, message)
+		r.write(sink{}, putBuffer(buf))
+	}
+}
+
+// panicValue returns the string form of the recovered value, capped to MaxPanicValueBytes.
+func (r *Recovery) panicValue(v interface{}) string {
+	return r.capPanicValue(panicString(v))
+}
+
+// loggedPanicValue returns the string form of the recovered value scrubbed, and then capped to MaxPanicValueBytes, so the cut never leaves the start of a match behind.
+func (r *Recovery) loggedPanicValue(v interface{}) string {
+	return r.capPanicValue(r.scrubText(panicString(v)))
+}
+
+// capPanicValue caps s to MaxPanicValueBytes.
+func (r *Recovery) capPanicValue(s string) string {
+	if r.opt.MaxPanicValueBytes > 0 && len(s) > r.opt.MaxPanicValueBytes {
+		s = truncate(s, r.opt.MaxPanicValueBytes) + truncatedMarker
+	}
+
+	return s
+}
+
+// panicString returns the string form of the recovered value.
+func panicString(v interface{}) string {
+	if v == nil {
+		// Match the message of *runtime.PanicNilError.
+		return "runtime error: panic called with nil argument"
+	}
+
+	return fmt.Sprint(v)
+}
+
+// truncate cuts s to at most n bytes, backing up to a rune boundary so we never emit half a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+// SetPanicHandler sets the handler to call when Recovery encounters a panic. It is safe to call while serving requests, so the handler can be swapped at runtime. A nil handler restores the default response.
 func (r *Recovery) SetPanicHandler(handler http.Handler) {
-	r.panicHandler = handler
+	if handler == nil {
+		r.SetPanicHandlerFunc(nil)
+		return
+	}
+
+	r.SetPanicHandlerFunc(func(w http.ResponseWriter, req *http.Request, _ interface{}, _ []byte) {
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// SetPanicHandlerFunc sets the function to call when Recovery encounters a panic. Unlike SetPanicHandler, the function is given the recovered value and stack so it can render or classify the failure. Like SetPanicHandler, it is safe to call at runtime and nil restores the default response.
+func (r *Recovery) SetPanicHandlerFunc(fn PanicHandlerFunc) {
+	if fn == nil {
+		fn = r.defaultPanicHandler
+	}
+
+	r.panicHandler.Store(fn)
 }
 
-func defaultPanicHandler(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+// OnPanic registers a hook that runs whenever a panic is recovered, after the response is written and the panic is logged. The request context holds the PanicInfo and the scrubbed PanicRecord of the panic, for FromContext and RecordFromContext. It can be called multiple times; hooks run in the order they were added. Hooks are meant for side effects such as metrics or alerting and should be registered before serving requests.
+func (r *Recovery) OnPanic(hook func(req *http.Request, panicVal interface{}, stack []byte)) {
+	r.hooks = append(r.hooks, hook)
 }
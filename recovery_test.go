@@ -2,13 +2,17 @@ package recovery
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"reflect"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -33,6 +37,25 @@ func TestNoConfigGood(t *testing.T) {
 	expect(t, res.Body.String(), "bar")
 }
 
+func TestServeHTTPNext(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/should/panic/", nil)
+	r.ServeHTTP(res, req, myPanicHandler)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, strings.TrimSpace(res.Body.String()), strings.TrimSpace(http.StatusText(http.StatusInternalServerError)))
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req, myHandler)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "bar")
+}
+
 func TestDefaultPanic(t *testing.T) {
 	r := New(Options{
 		Out: ioutil.Discard,
@@ -64,6 +87,362 @@ func TestCustomPanicHandler(t *testing.T) {
 	expect(t, res.Body.String(), "You got 400 yo!")
 }
 
+func TestCustomPanicHandlerFunc(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	var gotStack []byte
+	r.SetPanicHandlerFunc(func(w http.ResponseWriter, r *http.Request, panicVal interface{}, stack []byte) {
+		gotStack = stack
+		w.Header().Set("X-Error-Class", "string")
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprintf(w, "panicked with: %v", panicVal)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/should/418/", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusTeapot)
+	expect(t, res.Header().Get("X-Error-Class"), "string")
+	expect(t, res.Body.String(), "panicked with: this did not work")
+	expectContainsTrue(t, string(gotStack), "src/net/http/server.go")
+}
+
+func TestSetPanicHandlerConcurrently(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+	handler := r.Handler(myPanicHandler)
+
+	teapot := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.SetPanicHandler(teapot)
+			r.SetPanicHandler(nil)
+		}()
+		go func() {
+			defer wg.Done()
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/foo", nil)
+			handler.ServeHTTP(res, req)
+			if res.Code != http.StatusTeapot && res.Code != http.StatusInternalServerError {
+				t.Errorf("Unexpected status %d", res.Code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Nil restores the default.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	handler.ServeHTTP(res, req)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestPanickingPanicHandler(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+	r.SetPanicHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("the panic handler broke too")
+	}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	// Falls back to the default response.
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusInternalServerError))
+
+	// Both panics are logged.
+	expectContainsTrue(t, buf.String(), "Recovering from Panic in panic handler: the panic handler broke too")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: this did not work")
+}
+
+func TestOnPanicHooks(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	var calls []string
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		calls = append(calls, fmt.Sprintf("first %s %v", req.URL.Path, panicVal))
+	})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		expectContainsTrue(t, string(stack), "src/net/http/server.go")
+		calls = append(calls, "second")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/hooks", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, len(calls), 2)
+	expect(t, calls[0], "first /hooks this did not work")
+	expect(t, calls[1], "second")
+
+	// Hooks never fire without a panic.
+	calls = nil
+	res = httptest.NewRecorder()
+	r.Handler(myHandler).ServeHTTP(res, req)
+	expect(t, len(calls), 0)
+}
+
+func TestShouldRecover(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	type fatalSentinel struct{}
+	r := New(Options{
+		Out: buf,
+		ShouldRecover: func(recovered interface{}) bool {
+			_, fatal := recovered.(fatalSentinel)
+			return !fatal
+		},
+	})
+
+	// Ordinary panics are still recovered.
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	// The sentinel escapes untouched.
+	buf.Reset()
+	fatalHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(fatalSentinel{})
+	})
+
+	var escaped interface{}
+	func() {
+		defer func() {
+			escaped = recover()
+		}()
+		r.Handler(fatalHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	expect(t, escaped, interface{}(fatalSentinel{}))
+	expect(t, buf.Len(), 0)
+}
+
+func TestErrAbortHandler(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+
+	abortHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+
+	var escaped interface{}
+	func() {
+		defer func() {
+			escaped = recover()
+		}()
+		r.Handler(abortHandler).ServeHTTP(res, req)
+	}()
+
+	expect(t, escaped, interface{}(http.ErrAbortHandler))
+	expect(t, res.Body.Len(), 0)
+	expect(t, buf.Len(), 0)
+}
+
+func TestRepanic(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:     buf,
+		Repanic: true,
+	})
+
+	hooked := false
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		hooked = true
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+
+	var escaped interface{}
+	func() {
+		defer func() {
+			escaped = recover()
+		}()
+		r.Handler(myPanicHandler).ServeHTTP(res, req)
+	}()
+
+	expect(t, escaped, interface{}("this did not work"))
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, hooked, true)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: this did not work")
+}
+
+func TestAbortConnection(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:             buf,
+		AbortConnection: true,
+	})
+
+	hooked := false
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		hooked = true
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+
+	var escaped interface{}
+	func() {
+		defer func() {
+			escaped = recover()
+		}()
+		r.Handler(myPanicHandler).ServeHTTP(res, req)
+	}()
+
+	expect(t, escaped, interface{}(http.ErrAbortHandler))
+	expect(t, res.Body.Len(), 0)
+	expect(t, hooked, true)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: this did not work")
+}
+
+func TestAbortConnectionServer(t *testing.T) {
+	r := New(Options{
+		Out:             ioutil.Discard,
+		AbortConnection: true,
+	})
+
+	server := httptest.NewUnstartedServer(r.Handler(myPanicHandler))
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.Start()
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err == nil {
+		res.Body.Close()
+		t.Fatalf("Expected the connection to be aborted, got status %d", res.StatusCode)
+	}
+}
+
+func TestNilPanic(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+
+	var hooked interface{}
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		hooked = panicVal
+	})
+
+	nilPanicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(nilPanicHandler).ServeHTTP(res, req)
+
+	_, isPanicNilError := hooked.(*runtime.PanicNilError)
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, isPanicNilError, true)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: runtime error: panic called with nil argument")
+	expectContainsTrue(t, buf.String(), "src/net/http/server.go")
+}
+
+// legacyPanicNil reruns the calling test in a subprocess with GODEBUG=panicnil=1, where recover returns nil for panic(nil), and reports whether the caller is that subprocess.
+func legacyPanicNil(t *testing.T) bool {
+	if os.Getenv("GODEBUG") == "panicnil=1" {
+		return true
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(os.Environ(), "GODEBUG=panicnil=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Expected the legacy panic(nil) test to pass - Got %v\n%s", err, out)
+	}
+	return false
+}
+
+func TestNilPanicLegacy(t *testing.T) {
+	if !legacyPanicNil(t) {
+		return
+	}
+
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+
+	hooked := false
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		hooked = panicVal == nil
+	})
+
+	nilPanicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(nilPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, hooked, true)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: runtime error: panic called with nil argument")
+	expectContainsTrue(t, buf.String(), "src/net/http/server.go")
+}
+
+func TestGoexitIsNotAPanic(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+
+	hooked := false
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		hooked = true
+	})
+
+	goexitHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runtime.Goexit()
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Handler(goexitHandler).ServeHTTP(res, req)
+	}()
+	<-done
+
+	expect(t, res.Body.Len(), 0)
+	expect(t, hooked, false)
+	expect(t, buf.Len(), 0)
+}
+
 func TestDefaultConfig(t *testing.T) {
 	buf := bytes.NewBufferString("")
 
@@ -111,6 +490,88 @@ func TestStackSize(t *testing.T) {
 	expectContainsTrue(t, buf.String(), curDate)
 }
 
+func TestMaxStackSize(t *testing.T) {
+	r := New(Options{
+		StackSize:    50,
+		MaxStackSize: 64 * 1024,
+	})
+
+	stack := r.panicStack(&PanicInfo{}, r.captureStack(), "")
+	expect(t, len(stack) > 50, true)
+	expect(t, len(stack) < 64*1024, true)
+	expectContainsTrue(t, string(stack), "src/testing/testing.go")
+
+	// The buffer never grows past the maximum.
+	r = New(Options{
+		StackSize:    50,
+		MaxStackSize: 120,
+	})
+
+	// The dump is cut at a frame boundary, and ends with the elided frames line.
+	stack = r.panicStack(&PanicInfo{}, r.captureStack(), "")
+	expect(t, len(stack) <= 120, true)
+	expect(t, strings.HasSuffix(string(stack), "\n"+elidedFrames), true)
+	expect(t, strings.HasPrefix(string(stack), "goroutine "), true)
+}
+
+func TestDisableStackCapture(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:                 buf,
+		DisableStackCapture: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: this did not work")
+	expectContainsFalse(t, buf.String(), "goroutine ")
+	expectContainsFalse(t, buf.String(), "src/net/http/server.go")
+}
+
+func TestDisableStackCaptureNilPanic(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:                 buf,
+		DisableStackCapture: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(nil)
+	})).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: ")
+}
+
+func TestDisableStackCaptureNilPanicLegacy(t *testing.T) {
+	if !legacyPanicNil(t) {
+		return
+	}
+
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:                 buf,
+		DisableStackCapture: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(nil)
+	})).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: ")
+}
+
 func TestIncludeFullStack(t *testing.T) {
 	bufNormal := bytes.NewBufferString("")
 	bufFull := bytes.NewBufferString("")
@@ -247,6 +708,247 @@ func TestIgnoreMultipleConfigs(t *testing.T) {
 	expectContainsTrue(t, buf.String(), curDate)
 }
 
+func TestMaxPanicValueBytes(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:                buf,
+		MaxPanicValueBytes: 100,
+	})
+
+	hugeValue := strings.Repeat("x", 64*1024)
+	hugePanicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(hugeValue)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(hugePanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: "+strings.Repeat("x", 100)+truncatedMarker)
+	expectContainsFalse(t, buf.String(), strings.Repeat("x", 101))
+	expectContainsTrue(t, buf.String(), "src/net/http/server.go")
+}
+
+func TestCustomLogger(t *testing.T) {
+	out := bytes.NewBufferString("")
+	var lines []string
+	logger := LoggerFunc(func(format string, v ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, v...))
+	})
+
+	r := New(Options{
+		Out:    out,
+		Prefix: "testapp",
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, out.Len(), 0)
+	expect(t, len(lines), 1)
+	expect(t, strings.HasPrefix(lines[0], "Recovering from Panic: this did not work\n"), true)
+	expect(t, strings.HasSuffix(lines[0], "\n"), false)
+	expectContainsFalse(t, lines[0], "[testapp]")
+}
+
+func TestCustomLoggerStdlib(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Format: FormatJSON,
+		Logger: log.New(buf, "stdlib: ", 0),
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, strings.HasPrefix(buf.String(), "stdlib: {"), true)
+	expect(t, strings.Count(buf.String(), "\n"), 1)
+}
+
+type recordLogger struct {
+	records []*PanicRecord
+	notes   []string
+}
+
+func (l *recordLogger) Printf(format string, v ...interface{}) {
+	l.notes = append(l.notes, fmt.Sprintf(format, v...))
+}
+
+func (l *recordLogger) LogPanic(rec *PanicRecord) {
+	l.records = append(l.records, rec)
+}
+
+func TestPanicLogger(t *testing.T) {
+	out := bytes.NewBufferString("")
+	logger := &recordLogger{}
+
+	r := New(Options{
+		Out:    out,
+		Logger: logger,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(halfWrittenHandler).ServeHTTP(res, req)
+
+	expect(t, out.Len(), 0)
+	expect(t, len(logger.records), 1)
+	expect(t, logger.records[0].Message, "Recovering from Panic")
+	expect(t, logger.records[0].Path, "/foo")
+	expect(t, logger.records[0].Status, http.StatusOK)
+	expect(t, len(logger.notes), 1)
+	expectContainsTrue(t, logger.notes[0], "Response already committed")
+}
+
+func TestPanicLoggerSinks(t *testing.T) {
+	sinkOut := bytes.NewBufferString("")
+	logger := &recordLogger{}
+
+	r := New(Options{
+		Logger: logger,
+		Sinks:  []Sink{{}, {Out: sinkOut, Format: FormatJSON}},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, len(logger.records), 1)
+	expect(t, len(logger.notes), 0)
+	expectContainsTrue(t, sinkOut.String(), `"panic":"this did not work"`)
+}
+
+func TestPanicLoggerStatus(t *testing.T) {
+	logger := &recordLogger{}
+	r := New(Options{
+		Logger:         logger,
+		ResponseStatus: http.StatusServiceUnavailable,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, len(logger.records), 1)
+	expect(t, logger.records[0].Status, http.StatusServiceUnavailable)
+}
+
+func TestPanicLoggerRequestID(t *testing.T) {
+	for _, include := range []bool{false, true} {
+		logger := &recordLogger{}
+		r := New(Options{
+			Logger:           logger,
+			IncludeRequestID: include,
+		})
+
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.Header.Set("X-Request-ID", "abc123")
+		r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+		expect(t, len(logger.records), 1)
+		expect(t, logger.records[0].RequestID == "abc123", include)
+	}
+}
+
+// signalWriter signals every write on written.
+type signalWriter struct {
+	written chan string
+}
+
+func (w *signalWriter) Write(b []byte) (int, error) {
+	w.written <- string(b)
+	return len(b), nil
+}
+
+func TestSlowSinkHoldsUpNoOtherOutput(t *testing.T) {
+	main := &signalWriter{written: make(chan string, 4)}
+	slow := &blockingWriter{release: make(chan struct{})}
+
+	r := New(Options{
+		Out:   main,
+		Sinks: []Sink{{}, {Out: slow}},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+			done <- struct{}{}
+		}()
+	}
+
+	// While the slow sink is stuck on the first record, the second still reaches the main output.
+	for i := 0; i < 2; i++ {
+		select {
+		case line := <-main.written:
+			expectContainsTrue(t, line, "this did not work")
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the main output not to wait for the slow sink")
+		}
+	}
+
+	close(slow.release)
+	<-done
+	<-done
+}
+
+func TestSinks(t *testing.T) {
+	out := bytes.NewBufferString("")
+	jsonOut := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:    out,
+		Prefix: "testapp",
+		Format: FormatJSON,
+		Sinks: []Sink{
+			{OutputFlags: -1},
+			{Out: jsonOut, Format: FormatJSON},
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, strings.HasPrefix(out.String(), "[testapp] Recovering from Panic: this did not work\n"), true)
+	expect(t, strings.HasPrefix(jsonOut.String(), `{"time":`), true)
+	expectContainsTrue(t, jsonOut.String(), `"prefix":"testapp"`)
+	expect(t, strings.Count(jsonOut.String(), "\n"), 1)
+}
+
+func TestSinksWithLogger(t *testing.T) {
+	fileOut := bytes.NewBufferString("")
+	var lines []string
+
+	r := New(Options{
+		Prefix: "testapp",
+		Logger: LoggerFunc(func(format string, v ...interface{}) {
+			lines = append(lines, fmt.Sprintf(format, v...))
+		}),
+		Sinks: []Sink{
+			{},
+			{Out: fileOut, OutputFlags: -1},
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, len(lines), 1)
+	expect(t, strings.HasPrefix(lines[0], "Recovering from Panic: this did not work\n"), true)
+	expect(t, strings.HasPrefix(fileOut.String(), "[testapp] Recovering from Panic: this did not work\n"), true)
+}
+
 /* Test Helpers */
 func expect(t *testing.T, a interface{}, b interface{}) {
 	if a != b {
@@ -0,0 +1,229 @@
+// Package recoverycloudwatch ships the panics recovered by github.com/unrolled/recovery to an AWS CloudWatch Logs stream, without an agent on the host.
+//
+//	package main
+//
+//	import (
+//	    "context"
+//	    "net/http"
+//
+//	    "github.com/aws/aws-sdk-go-v2/config"
+//	    "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverycloudwatch"
+//	)
+//
+//	func main() {
+//	    cfg, _ := config.LoadDefaultConfig(context.Background())
+//	    w := recoverycloudwatch.New(cloudwatchlogs.NewFromConfig(cfg), "/myapp/panics", "web-1", 0)
+//	    defer w.Close()
+//
+//	    r := recovery.New(recovery.Options{
+//	        Sinks: []recovery.Sink{{Format: recovery.FormatText}, {Out: w, Format: recovery.FormatJSON}},
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverycloudwatch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const (
+	// DefaultFlushInterval is how often a Writer sends its batch, unless one is given to New.
+	DefaultFlushInterval = 5 * time.Second
+
+	// maxBatchEvents and maxBatchBytes are the limits of a PutLogEvents call. Every event counts 26 bytes on top of its message.
+	maxBatchEvents = 10000
+	maxBatchBytes  = 1048576
+	eventOverhead  = 26
+	// maxEventBytes is the largest message of a single event.
+	maxEventBytes = 256*1024 - eventOverhead
+
+	// maxAttempts is how many times a batch is sent before its events are dropped.
+	maxAttempts = 3
+	// retryDelay is the wait before the second attempt, doubled for each one after.
+	retryDelay = 100 * time.Millisecond
+)
+
+// ErrClosed is returned by writes to a closed Writer.
+var ErrClosed = errors.New("recoverycloudwatch: writer closed")
+
+// API is the part of *cloudwatchlogs.Client a Writer uses.
+type API interface {
+	PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+	CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+}
+
+// Writer sends each write to a CloudWatch Logs stream as one log event. Events are batched and sent every flush interval, or sooner once a batch is full, from a background goroutine. A failed batch is retried before its events are dropped, and the stream is created on first use when it does not exist. The log group must exist already.
+type Writer struct {
+	client API
+	group  string
+	stream string
+
+	mu     sync.Mutex
+	batch  []types.InputLogEvent
+	size   int
+	closed bool
+
+	// sendMu keeps batches in order.
+	sendMu sync.Mutex
+
+	full chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns a Writer sending to the given log group and stream, which flushes every interval, or every DefaultFlushInterval when it is 0. Call Close on shutdown to send what is left.
+func New(client API, group, stream string, interval time.Duration) *Writer {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	w := &Writer{
+		client: client,
+		group:  group,
+		stream: stream,
+		full:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run(interval)
+
+	return w
+}
+
+func (w *Writer) run(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.full:
+		case <-w.stop:
+			return
+		}
+
+		// Errors of background flushes have nowhere to go; the events are dropped after the retries.
+		w.Flush()
+	}
+}
+
+// Write adds p, without its trailing newline, to the batch as one log event, cut to the largest event CloudWatch takes.
+func (w *Writer) Write(p []byte) (int, error) {
+	message := string(p)
+	if len(message) > 0 && message[len(message)-1] == '\n' {
+		message = message[:len(message)-1]
+	}
+	if len(message) > maxEventBytes {
+		message = message[:maxEventBytes]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrClosed
+	}
+
+	w.batch = append(w.batch, types.InputLogEvent{
+		Message:   aws.String(message),
+		Timestamp: aws.Int64(time.Now().UnixMilli()),
+	})
+	w.size += len(message) + eventOverhead
+
+	if len(w.batch) >= maxBatchEvents || w.size >= maxBatchBytes {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush sends the batched events right away. It returns the error of the last attempt when every retry failed, in which case the events are dropped.
+func (w *Writer) Flush() error {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.batch
+	w.batch, w.size = nil, 0
+	w.mu.Unlock()
+
+	// Send in chunks within the limits of a call, as writes may have come in after the batch filled up.
+	var err error
+	for len(batch) > 0 {
+		n, size := 0, 0
+		for n < len(batch) && n < maxBatchEvents && size+len(*batch[n].Message)+eventOverhead <= maxBatchBytes {
+			size += len(*batch[n].Message) + eventOverhead
+			n++
+		}
+
+		if sendErr := w.send(batch[:n]); sendErr != nil {
+			err = sendErr
+		}
+		batch = batch[n:]
+	}
+
+	return err
+}
+
+// send puts a batch, retrying with a growing delay, and creates the stream when it is missing.
+func (w *Writer) send(batch []types.InputLogEvent) error {
+	input := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(w.group),
+		LogStreamName: aws.String(w.stream),
+		LogEvents:     batch,
+	}
+
+	var err error
+	delay := retryDelay
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		if _, err = w.client.PutLogEvents(context.Background(), input); err == nil {
+			return nil
+		}
+
+		var missing *types.ResourceNotFoundException
+		if errors.As(err, &missing) {
+			w.client.CreateLogStream(context.Background(), &cloudwatchlogs.CreateLogStreamInput{
+				LogGroupName:  aws.String(w.group),
+				LogStreamName: aws.String(w.stream),
+			})
+		}
+	}
+
+	return err
+}
+
+// Close stops the background flushes and sends what is left. Writes after Close fail with ErrClosed.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+
+	return w.Flush()
+}
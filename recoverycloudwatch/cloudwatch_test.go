@@ -0,0 +1,171 @@
+package recoverycloudwatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+// client keeps the events it is given, failing the first calls when told to.
+type client struct {
+	mu      sync.Mutex
+	fail    []error
+	calls   int
+	created []string
+	puts    [][]types.InputLogEvent
+}
+
+func (c *client) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if len(c.fail) > 0 {
+		err := c.fail[0]
+		c.fail = c.fail[1:]
+		return nil, err
+	}
+
+	c.puts = append(c.puts, params.LogEvents)
+	return &cloudwatchlogs.PutLogEventsOutput{}, nil
+}
+
+func (c *client) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.created = append(c.created, *params.LogStreamName)
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func TestWriter(t *testing.T) {
+	c := &client{}
+	w := New(c, "/myapp/panics", "web-1", time.Hour)
+
+	r := recovery.New(recovery.Options{
+		Sinks: []recovery.Sink{{Out: w, Format: recovery.FormatJSON}},
+	})
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(c.puts) != 0 {
+		t.Fatalf("Expected the events to wait for the flush - Got %d calls", len(c.puts))
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.puts) != 1 || len(c.puts[0]) != 2 {
+		t.Fatalf("Expected one batch of 2 events - Got %v", c.puts)
+	}
+
+	var doc map[string]interface{}
+	message := *c.puts[0][0].Message
+	if err := json.Unmarshal([]byte(message), &doc); err != nil {
+		t.Fatalf("Expected a JSON record - Got error %v for [%s]", err, message)
+	}
+	if doc["panic"] != "this did not work" || strings.HasSuffix(message, "\n") {
+		t.Errorf("Expected the record without its newline - Got [%s]", message)
+	}
+
+	if _, err := w.Write([]byte("late\n")); err != ErrClosed {
+		t.Errorf("Expected ErrClosed - Got %v", err)
+	}
+}
+
+func TestWriterRetries(t *testing.T) {
+	c := &client{fail: []error{errors.New("throttled")}}
+	w := New(c, "/myapp/panics", "web-1", time.Hour)
+	defer w.Close()
+
+	w.Write([]byte("boom\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if c.calls != 2 || len(c.puts) != 1 {
+		t.Errorf("Expected the batch on the second attempt - Got %d calls", c.calls)
+	}
+}
+
+func TestWriterDropsAfterRetries(t *testing.T) {
+	failure := errors.New("throttled")
+	c := &client{fail: []error{failure, failure, failure, failure}}
+	w := New(c, "/myapp/panics", "web-1", time.Hour)
+	defer w.Close()
+
+	w.Write([]byte("boom\n"))
+	if err := w.Flush(); err != failure {
+		t.Errorf("Expected the error of the last attempt - Got %v", err)
+	}
+	if c.calls != maxAttempts {
+		t.Errorf("Expected %d attempts - Got %d", maxAttempts, c.calls)
+	}
+}
+
+func TestWriterCreatesStream(t *testing.T) {
+	c := &client{fail: []error{&types.ResourceNotFoundException{}}}
+	w := New(c, "/myapp/panics", "web-1", time.Hour)
+	defer w.Close()
+
+	w.Write([]byte("boom\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.created) != 1 || c.created[0] != "web-1" || len(c.puts) != 1 {
+		t.Errorf("Expected the stream to be created and the batch sent - Got %v and %d batches", c.created, len(c.puts))
+	}
+}
+
+func TestWriterBatchLimits(t *testing.T) {
+	c := &client{}
+	w := New(c, "/myapp/panics", "web-1", time.Hour)
+	defer w.Close()
+
+	// Hold the sends back so the events pile up past the limits of one call.
+	w.sendMu.Lock()
+	line := bytes.Repeat([]byte("x"), 300*1024)
+	for i := 0; i < 8; i++ {
+		w.Write(line)
+	}
+	w.sendMu.Unlock()
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events int
+	for _, put := range c.puts {
+		size := 0
+		for _, event := range put {
+			size += len(*event.Message) + eventOverhead
+		}
+		if size > maxBatchBytes {
+			t.Errorf("Expected batches within %d bytes - Got %d", maxBatchBytes, size)
+		}
+		events += len(put)
+	}
+	if events != 8 || len(*c.puts[0][0].Message) != maxEventBytes {
+		t.Errorf("Expected 8 events cut to %d bytes - Got %d", maxEventBytes, events)
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/unrolled/recovery/recoverycloudwatch
+
+go 1.21
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.36.1
+	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.45.13
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.6.9 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.3.32 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.6.32 // indirect
+	github.com/aws/smithy-go v1.22.2 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
@@ -0,0 +1,70 @@
+// Package recoverydatadog attaches the panics recovered by github.com/unrolled/recovery to the active Datadog APM span, so they show up in Datadog Error Tracking.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverydatadog"
+//	    httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+//	    "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+//	)
+//
+//	func main() {
+//	    tracer.Start()
+//	    defer tracer.Stop()
+//
+//	    r := recovery.New(recovery.Options{})
+//	    r.OnPanic(recoverydatadog.Report)
+//
+//	    mux := httptrace.NewServeMux()
+//	    mux.Handle("/", r.Handler(http.DefaultServeMux))
+//	    http.ListenAndServe("127.0.0.1:3000", mux)
+//	}
+package recoverydatadog
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/unrolled/recovery"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// FingerprintTag is the span tag holding the panic fingerprint. Error Tracking groups errors with the same error.fingerprint into one issue.
+const FingerprintTag = "error.fingerprint"
+
+// Report marks the span on the request context as failed, with the error.message, error.type, and error.stack tags of the recovered panic, the message scrubbed like the logs, its fingerprint, and its origin. The span is the one the tracing middleware wrapping Recovery started, which finishes after the panic is handled. Requests without a span are left alone. Register it with recovery.OnPanic.
+func Report(req *http.Request, panicVal interface{}, stack []byte) {
+	span, ok := tracer.SpanFromContext(req.Context())
+	if !ok {
+		return
+	}
+
+	// The value as logged, scrubbed and capped to MaxPanicValueBytes, and the request id when the logs hold it.
+	message, requestID := fmt.Sprint(panicVal), ""
+	if rec, ok := recovery.RecordFromContext(req.Context()); ok {
+		message, requestID = rec.Panic, rec.RequestID
+	}
+
+	span.SetTag(ext.Error, true)
+	span.SetTag(ext.ErrorMsg, message)
+	span.SetTag(ext.ErrorType, fmt.Sprintf("%T", panicVal))
+	if len(stack) > 0 {
+		span.SetTag(ext.ErrorStack, string(stack))
+	}
+
+	if info, ok := recovery.FromContext(req.Context()); ok {
+		if len(info.Fingerprint) > 0 {
+			span.SetTag(FingerprintTag, info.Fingerprint)
+		}
+		if len(info.Origin) > 0 {
+			span.SetTag("error.origin", info.Origin)
+		}
+	}
+	if len(requestID) > 0 {
+		span.SetTag("http.request_id", requestID)
+	}
+}
@@ -0,0 +1,103 @@
+package recoverydatadog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/unrolled/recovery"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+func TestReport(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}, IncludeRequestID: true})
+	r.OnPanic(Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	span, ctx := tracer.StartSpanFromContext(req.Context(), "http.request")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span - Got %d", len(spans))
+	}
+
+	tags := spans[0].Tags()
+	if tags[ext.Error] != true {
+		t.Errorf("Expected the span to be an error - Got %v", tags[ext.Error])
+	}
+	if tags[ext.ErrorMsg] != "this did not work" {
+		t.Errorf("Expected error.message [this did not work] - Got [%v]", tags[ext.ErrorMsg])
+	}
+	if tags[ext.ErrorType] != "string" {
+		t.Errorf("Expected error.type [string] - Got [%v]", tags[ext.ErrorType])
+	}
+	if stack, _ := tags[ext.ErrorStack].(string); !strings.Contains(stack, "datadog_test.go") {
+		t.Errorf("Expected the stack of the panic - Got [%s]", stack)
+	}
+	if fp, _ := tags[FingerprintTag].(string); len(fp) == 0 {
+		t.Errorf("Expected the fingerprint of the panic - Got [%v]", tags[FingerprintTag])
+	}
+	if tags["http.request_id"] != "abc123" {
+		t.Errorf("Expected http.request_id [abc123] - Got [%v]", tags["http.request_id"])
+	}
+}
+
+func TestReportScrubbed(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := recovery.New(recovery.Options{
+		Out:                &bytes.Buffer{},
+		MaxPanicValueBytes: 18,
+		ScrubPatterns:      []*regexp.Regexp{recovery.EmailPattern},
+	})
+	r.OnPanic(Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	span, ctx := tracer.StartSpanFromContext(req.Context(), "http.request")
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("no user gopher@example.com in the directory")
+	})).ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	span.Finish()
+
+	tags := mt.FinishedSpans()[0].Tags()
+	if msg := tags[ext.ErrorMsg]; msg != "no user [REDACTED]...(truncated)" {
+		t.Errorf("Expected the scrubbed error.message - Got [%v]", msg)
+	}
+	// Without IncludeRequestID, the logs hold no request id to tag.
+	if id, ok := tags["http.request_id"]; ok {
+		t.Errorf("Expected no http.request_id - Got [%v]", id)
+	}
+}
+
+func TestReportWithoutSpan(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	res := httptest.NewRecorder()
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError || len(mt.FinishedSpans()) != 0 {
+		t.Errorf("Expected a 500 and no spans - Got %d and %d", res.Code, len(mt.FinishedSpans()))
+	}
+}
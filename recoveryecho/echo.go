@@ -0,0 +1,185 @@
+// Package recoveryecho runs github.com/unrolled/recovery as an Echo middleware, in place of middleware.Recover. Panics are logged, reported, and hooked like Recovery does, and returned as an *echo.HTTPError, so the HTTPErrorHandler of Echo renders the response.
+//
+//	package main
+//
+//	import (
+//	    "github.com/labstack/echo/v4"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoveryecho"
+//	)
+//
+//	func main() {
+//	    r := recovery.New(recovery.Options{
+//	        Format:       recovery.FormatJSON,
+//	        RoutePattern: recoveryecho.RoutePattern,
+//	    })
+//
+//	    e := echo.New()
+//	    e.Use(recoveryecho.New(r))
+//	    e.GET("/users/:id", func(c echo.Context) error { panic("this did not work") })
+//	    e.Start("127.0.0.1:3000")
+//	}
+package recoveryecho
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/unrolled/recovery"
+)
+
+type contextKey int
+
+const (
+	// routeKey holds the route of the request on its context.
+	routeKey contextKey = iota
+	// panicKey holds the *recovered of the request on its context.
+	panicKey
+)
+
+// recovered is the panic value of a request, set by the hook of New.
+type recovered struct {
+	value interface{}
+	ok    bool
+}
+
+// New returns an Echo middleware recovering the panics of the handlers after it with r, which logs them and runs its sinks and hooks. The response r would write is not sent: its status makes an *echo.HTTPError instead, with the recovered value as its internal error, for the HTTPErrorHandler of Echo to render. The message of the error is the plain text body r would write, like the one of a mapped panic type, or else the status text. New registers a hook on r, so call it once per Recovery.
+func New(r *recovery.Recovery) echo.MiddlewareFunc {
+	r.OnPanic(func(req *http.Request, v interface{}, _ []byte) {
+		if p, ok := req.Context().Value(panicKey).(*recovered); ok {
+			p.value, p.ok = v, true
+		}
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error
+			completed := false
+			p := &recovered{}
+
+			res := c.Response()
+			original := res.Writer
+			w := &switchWriter{ResponseWriter: original}
+
+			ctx := context.WithValue(c.Request().Context(), routeKey, c.Path())
+			req := c.Request().WithContext(context.WithValue(ctx, panicKey, p))
+			r.Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer func() {
+					// Still unwinding a panic: what Recovery writes from now on is the error response, which Echo renders instead.
+					if !completed {
+						w.capturing = true
+					}
+				}()
+
+				// The handlers write through the writer of Recovery, which knows whether the response started, or buffers it with BufferResponse.
+				res.Writer = rw
+				c.SetRequest(req)
+
+				err = next(c)
+				completed = true
+			})).ServeHTTP(w, req)
+			res.Writer = original
+
+			if completed || !p.ok {
+				return err
+			}
+
+			return w.httpError(p.value)
+		}
+	}
+}
+
+// RoutePattern returns the Echo route of a request, like "/users/:id", for the RoutePattern option. It is blank for requests not passing through a New middleware.
+func RoutePattern(req *http.Request) string {
+	route, _ := req.Context().Value(routeKey).(string)
+	return route
+}
+
+// switchWriter writes to the response of Echo, until capturing is set, and keeps what is written after.
+type switchWriter struct {
+	http.ResponseWriter
+	capturing bool
+
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *switchWriter) Header() http.Header {
+	if !w.capturing {
+		return w.ResponseWriter.Header()
+	}
+	if w.header == nil {
+		w.header = http.Header{}
+	}
+	return w.header
+}
+
+func (w *switchWriter) WriteHeader(status int) {
+	if !w.capturing {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *switchWriter) Write(b []byte) (int, error) {
+	if !w.capturing {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Flush implements http.Flusher when the response of Echo does.
+func (w *switchWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.capturing {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the response of Echo does, for websockets.
+func (w *switchWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("recoveryecho: the ResponseWriter does not support hijacking")
+	}
+
+	return h.Hijack()
+}
+
+// Unwrap returns the response of Echo, for http.ResponseController.
+func (w *switchWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// httpError returns the error of the captured response, wrapping the recovered value.
+func (w *switchWriter) httpError(v interface{}) *echo.HTTPError {
+	status := w.status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	message := http.StatusText(status)
+	if body := strings.TrimSpace(w.body.String()); len(body) > 0 && strings.HasPrefix(w.header.Get("Content-Type"), "text/plain") {
+		message = body
+	}
+
+	internal, ok := v.(error)
+	if !ok {
+		internal = fmt.Errorf("%v", v)
+	}
+
+	return echo.NewHTTPError(status, message).SetInternal(internal)
+}
@@ -0,0 +1,131 @@
+package recoveryecho
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/unrolled/recovery"
+)
+
+var errGone = errors.New("gone")
+
+func myPanicHandler(c echo.Context) error {
+	panic("this did not work")
+}
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	r := recovery.New(recovery.Options{
+		Sinks:             []recovery.Sink{{Out: &out, Format: recovery.FormatJSON}},
+		LogRequestDetails: true,
+		RoutePattern:      RoutePattern,
+	})
+
+	var handled error
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled = err
+		e.DefaultHTTPErrorHandler(err, c)
+	}
+	e.Use(New(r))
+	e.GET("/users/:id", myPanicHandler)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/42", nil)
+	e.ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", res.Code)
+	}
+	if strings.TrimSpace(res.Body.String()) != `{"message":"Internal Server Error"}` {
+		t.Errorf("Expected the response of Echo - Got [%s]", res.Body.String())
+	}
+
+	var he *echo.HTTPError
+	if !errors.As(handled, &he) || he.Internal == nil || he.Internal.Error() != "this did not work" {
+		t.Errorf("Expected an HTTPError wrapping the panic - Got %v", handled)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the log - Got %v", doc["panic"])
+	}
+	if doc["route"] != "/users/:id" {
+		t.Errorf("Expected the Echo route in the log - Got %v", doc["route"])
+	}
+	if !strings.Contains(doc["stack"].(string), "echo_test.go") {
+		t.Errorf("Expected the stack of the handler in the log - Got %v", doc["stack"])
+	}
+}
+
+func TestNewMapPanic(t *testing.T) {
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}})
+	r.MapPanic(errGone, http.StatusGone, "This page is gone")
+
+	e := echo.New()
+	e.Use(New(r))
+	e.GET("/old", func(c echo.Context) error { panic(errGone) })
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/old", nil)
+	e.ServeHTTP(res, req)
+
+	if res.Code != http.StatusGone {
+		t.Errorf("Expected status 410 - Got %d", res.Code)
+	}
+	if strings.TrimSpace(res.Body.String()) != `{"message":"This page is gone"}` {
+		t.Errorf("Expected the mapped message - Got [%s]", res.Body.String())
+	}
+}
+
+func TestNewNoPanic(t *testing.T) {
+	e := echo.New()
+	e.Use(New(recovery.New()))
+	e.GET("/foo", func(c echo.Context) error { return c.String(http.StatusCreated, "done") })
+	e.GET("/bar", func(c echo.Context) error { return echo.NewHTTPError(http.StatusTeapot, "no coffee") })
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	e.ServeHTTP(res, req)
+
+	if res.Code != http.StatusCreated || res.Body.String() != "done" {
+		t.Errorf("Expected the response of the handler - Got %d [%s]", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/bar", nil)
+	e.ServeHTTP(res, req)
+
+	if res.Code != http.StatusTeapot || !strings.Contains(res.Body.String(), "no coffee") {
+		t.Errorf("Expected the error of the handler - Got %d [%s]", res.Code, res.Body.String())
+	}
+}
+
+func TestNewCommitted(t *testing.T) {
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}})
+
+	e := echo.New()
+	e.Use(New(r))
+	e.GET("/partial", func(c echo.Context) error {
+		c.String(http.StatusAccepted, "half a respo")
+		panic("this did not work")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/partial", nil)
+	e.ServeHTTP(res, req)
+
+	// The response went out already, so Echo adds nothing to it.
+	if res.Code != http.StatusAccepted || res.Body.String() != "half a respo" {
+		t.Errorf("Expected the partial response - Got %d [%s]", res.Code, res.Body.String())
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/unrolled/recovery/recoveryecho
+
+go 1.21
+
+require (
+	github.com/labstack/echo/v4 v4.13.3
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+require (
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
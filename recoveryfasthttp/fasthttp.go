@@ -0,0 +1,165 @@
+// Package recoveryfasthttp recovers the panics of fasthttp handlers with github.com/unrolled/recovery, so services on fasthttp get the same logging, sinks, formats, and error responses as the ones on net/http.
+//
+//	package main
+//
+//	import (
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoveryfasthttp"
+//	    "github.com/valyala/fasthttp"
+//	)
+//
+//	func main() {
+//	    r := recovery.New(recovery.Options{
+//	        Format:            recovery.FormatJSON,
+//	        LogRequestDetails: true,
+//	    })
+//
+//	    fasthttp.ListenAndServe("127.0.0.1:3000", recoveryfasthttp.New(r, func(ctx *fasthttp.RequestCtx) {
+//	        panic("this did not work")
+//	    }))
+//	}
+package recoveryfasthttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/unrolled/recovery"
+	"github.com/valyala/fasthttp"
+)
+
+// New returns a handler recovering the panics of next with r.
+func New(r *recovery.Recovery, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		Serve(r, ctx, context.Background(), func() { next(ctx) })
+	}
+}
+
+// Serve runs next, which handles ctx, recovering its panics with r. The *http.Request Recovery logs and hooks have parent as context, or context.Background() when it is nil, for frameworks on fasthttp to pass values like the route. Serve reports whether next returned without panicking.
+//
+// The request is only converted to an *http.Request once next panics, and its body only read then, so requests that do not panic pay for little more than the two small allocations of an empty request. A body streamed with StreamRequestBody is never read by Serve, so it is not captured with CaptureBodyBytes. The error response replaces whatever next wrote, as fasthttp sends nothing before the handler returns. The request is copied, so hooks and AsyncLogging can keep it after ctx is reused. With AbortConnection, the connection is closed without a response; other panics Recovery raises again, with Repanic or ShouldRecover, go on to the server.
+func Serve(r *recovery.Recovery, ctx *fasthttp.RequestCtx, parent context.Context, next func()) bool {
+	completed := false
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	defer func() {
+		// Recovery panics with http.ErrAbortHandler, with AbortConnection, for net/http to drop the connection. fasthttp does not recover it, so drop it here.
+		if err := recover(); err != nil {
+			if err != http.ErrAbortHandler {
+				panic(err)
+			}
+			ctx.HijackSetNoResponse(true)
+			ctx.Hijack(func(net.Conn) {})
+		}
+	}()
+
+	// The body is only read from ctx once Recovery drains it, past a panic.
+	req := (&http.Request{Body: &lazyBody{ctx: ctx}}).WithContext(parent)
+	rw := &responseWriter{ctx: ctx, header: http.Header{}}
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			// Still unwinding a panic: Recovery handles this very request next.
+			if !completed {
+				rw.recovering = true
+				fill(req, ctx)
+			}
+		}()
+
+		next()
+		completed = true
+	})).ServeHTTP(rw, req)
+
+	return completed
+}
+
+// fill copies the request of ctx into req, in place, leaving the body to the reader Recovery may capture it with.
+func fill(req *http.Request, ctx *fasthttp.RequestCtx) {
+	uri := string(ctx.RequestURI())
+	u, err := url.ParseRequestURI(uri)
+	if err != nil {
+		u = &url.URL{Path: string(ctx.Path())}
+	}
+
+	req.Method = string(ctx.Method())
+	req.URL = u
+	req.RequestURI = uri
+	req.Proto = string(ctx.Request.Header.Protocol())
+	req.ProtoMajor, req.ProtoMinor, _ = http.ParseHTTPVersion(req.Proto)
+	req.Host = string(ctx.Host())
+	req.RemoteAddr = ctx.RemoteAddr().String()
+	req.ContentLength = int64(len(ctx.PostBody()))
+	req.TLS = ctx.TLSConnectionState()
+	req.Header = http.Header{}
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		req.Header.Add(string(key), string(value))
+	})
+
+	// With CaptureBodyBytes, the body is captured as it is read, which the handler did on ctx instead. A streamed body was read from the connection by the handler, if at all, and is left alone.
+	if !ctx.Request.IsBodyStream() {
+		io.Copy(io.Discard, req.Body)
+	}
+}
+
+// lazyBody reads the body of ctx, which it only loads on the first Read.
+type lazyBody struct {
+	ctx *fasthttp.RequestCtx
+	r   *bytes.Reader
+}
+
+func (b *lazyBody) Read(p []byte) (int, error) {
+	if b.r == nil {
+		b.r = bytes.NewReader(b.ctx.PostBody())
+	}
+
+	return b.r.Read(p)
+}
+
+func (b *lazyBody) Close() error {
+	return nil
+}
+
+// responseWriter writes the error response of Recovery to ctx, in place of the response of the handler. The handler writes to ctx itself, so before a panic, like when BufferResponse replays an empty buffer, writes are dropped and the response of the handler is left as is.
+type responseWriter struct {
+	ctx         *fasthttp.RequestCtx
+	header      http.Header
+	recovering  bool
+	wroteHeader bool
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.recovering || w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	// A hijacked connection is the handler's, and gets no response.
+	if w.ctx.Hijacked() {
+		return
+	}
+	w.ctx.Response.Reset()
+	w.ctx.SetStatusCode(status)
+	for name, values := range w.header {
+		for _, value := range values {
+			w.ctx.Response.Header.Add(name, value)
+		}
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if !w.recovering || w.ctx.Hijacked() {
+		return len(b), nil
+	}
+
+	return w.ctx.Write(b)
+}
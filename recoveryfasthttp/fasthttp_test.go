@@ -0,0 +1,197 @@
+package recoveryfasthttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/unrolled/recovery"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func myPanicHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetStatusCode(http.StatusAccepted)
+	ctx.Response.Header.Set("X-Partial", "yes")
+	ctx.WriteString("half a respo")
+	panic("this did not work")
+}
+
+// newCtx returns the context of a request to the handler.
+func newCtx(method, uri, body string) *fasthttp.RequestCtx {
+	var req fasthttp.Request
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	req.Header.Set("X-Request-ID", "abc123")
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.SetBodyString(body)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&req, &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}, nil)
+	return ctx
+}
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	r := recovery.New(recovery.Options{
+		Sinks:             []recovery.Sink{{Out: &out, Format: recovery.FormatJSON}},
+		IncludeRequestID:  true,
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+		CaptureBodyBytes:  4,
+	})
+
+	ctx := newCtx("POST", "/users/42?page=2", "name=gopher")
+	New(r, myPanicHandler)(ctx)
+
+	// The partial response is replaced by the default error response.
+	if ctx.Response.StatusCode() != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", ctx.Response.StatusCode())
+	}
+	if !strings.HasPrefix(string(ctx.Response.Body()), http.StatusText(http.StatusInternalServerError)) {
+		t.Errorf("Expected the default response - Got [%s]", ctx.Response.Body())
+	}
+	if len(ctx.Response.Header.Peek("X-Partial")) > 0 {
+		t.Errorf("Expected the headers of the handler to be dropped")
+	}
+	if string(ctx.Response.Header.Peek("X-Request-ID")) != "abc123" {
+		t.Errorf("Expected the request id header - Got [%s]", ctx.Response.Header.Peek("X-Request-ID"))
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for field, value := range map[string]interface{}{
+		"panic":       "this did not work",
+		"method":      "POST",
+		"path":        "/users/42",
+		"url":         "/users/42?page=2",
+		"request_id":  "abc123",
+		"remote_addr": "10.0.0.1:1234",
+		"body":        "name",
+	} {
+		if doc[field] != value {
+			t.Errorf("Expected %s [%v] in the log - Got [%v]", field, value, doc[field])
+		}
+	}
+	if !strings.Contains(out.String(), "curl/8.0") {
+		t.Errorf("Expected the logged header - Got [%s]", out.String())
+	}
+	if !strings.Contains(doc["stack"].(string), "fasthttp_test.go") {
+		t.Errorf("Expected the stack of the handler in the log - Got %v", doc["stack"])
+	}
+}
+
+func TestNewNoPanic(t *testing.T) {
+	ctx := newCtx("GET", "/foo", "")
+	New(recovery.New(), func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(http.StatusCreated)
+		ctx.WriteString("done")
+	})(ctx)
+
+	if ctx.Response.StatusCode() != http.StatusCreated || string(ctx.Response.Body()) != "done" {
+		t.Errorf("Expected the response of the handler - Got %d [%s]", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+}
+
+func TestNewBufferResponse(t *testing.T) {
+	r := recovery.New(recovery.Options{
+		Sinks:          []recovery.Sink{{Out: &bytes.Buffer{}}},
+		BufferResponse: true,
+	})
+
+	ctx := newCtx("GET", "/foo", "")
+	New(r, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(http.StatusCreated)
+		ctx.WriteString("hello")
+	})(ctx)
+
+	if ctx.Response.StatusCode() != http.StatusCreated || string(ctx.Response.Body()) != "hello" {
+		t.Errorf("Expected the response of the handler - Got %d [%s]", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	ctx = newCtx("GET", "/foo", "")
+	New(r, myPanicHandler)(ctx)
+
+	if ctx.Response.StatusCode() != http.StatusInternalServerError || strings.Contains(string(ctx.Response.Body()), "half a respo") {
+		t.Errorf("Expected the default response - Got %d [%s]", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+}
+
+func TestServeHook(t *testing.T) {
+	var method string
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		method = req.Method
+	})
+
+	ctx := newCtx("DELETE", "/foo", "")
+	if Serve(r, ctx, nil, func() { panic("this did not work") }) {
+		t.Errorf("Expected Serve to report the panic")
+	}
+	if method != "DELETE" {
+		t.Errorf("Expected the request in the hook - Got [%s]", method)
+	}
+	if !Serve(r, ctx, nil, func() {}) {
+		t.Errorf("Expected Serve to report no panic")
+	}
+}
+
+func TestNewAbortConnection(t *testing.T) {
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}, AbortConnection: true})
+
+	ctx := newCtx("GET", "/foo", "")
+	New(r, myPanicHandler)(ctx)
+
+	if !ctx.Hijacked() {
+		t.Errorf("Expected the connection to be dropped")
+	}
+}
+
+// serve runs handler behind New on a server streaming request bodies past 16 bytes, and posts body to it.
+func serve(t *testing.T, r *recovery.Recovery, handler fasthttp.RequestHandler, body string) *fasthttp.Response {
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+
+	srv := &fasthttp.Server{Handler: New(r, handler), StreamRequestBody: true, MaxRequestBodySize: 16}
+	go srv.Serve(ln)
+
+	client := &fasthttp.Client{Dial: func(string) (net.Conn, error) { return ln.Dial() }}
+	req, res := fasthttp.AcquireRequest(), &fasthttp.Response{}
+	req.Header.SetMethod("POST")
+	req.SetRequestURI("http://example.com/upload")
+	req.SetBodyString(body)
+	if err := client.Do(req, res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestNewStreamRequestBody(t *testing.T) {
+	body := strings.Repeat("x", 1024)
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}, CaptureBodyBytes: 8})
+
+	// The handler reads the whole stream, as New leaves it alone.
+	var read int
+	res := serve(t, r, func(ctx *fasthttp.RequestCtx) {
+		b, _ := io.ReadAll(ctx.RequestBodyStream())
+		read = len(b)
+	}, body)
+	if read != len(body) || res.StatusCode() != http.StatusOK {
+		t.Errorf("Expected the handler to read the body - Got %d bytes, status %d", read, res.StatusCode())
+	}
+
+	// A panic does not read the rest of the stream.
+	res = serve(t, r, func(ctx *fasthttp.RequestCtx) {
+		panic("this did not work")
+	}, body)
+	if res.StatusCode() != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", res.StatusCode())
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/unrolled/recovery/recoveryfasthttp
+
+go 1.21
+
+require (
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+	github.com/valyala/fasthttp v1.58.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
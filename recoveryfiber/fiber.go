@@ -0,0 +1,64 @@
+// Package recoveryfiber runs github.com/unrolled/recovery as a Fiber middleware, in place of the recover middleware of Fiber, so Fiber applications get the logging, sinks, formats, and error responses of the Options. It is built on github.com/unrolled/recovery/recoveryfasthttp.
+//
+//	package main
+//
+//	import (
+//	    "github.com/gofiber/fiber/v2"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoveryfiber"
+//	)
+//
+//	func main() {
+//	    r := recovery.New(recovery.Options{
+//	        Format:       recovery.FormatJSON,
+//	        RoutePattern: recoveryfiber.RoutePattern,
+//	    })
+//
+//	    app := fiber.New()
+//	    app.Use(recoveryfiber.New(r))
+//	    app.Get("/users/:id", func(c *fiber.Ctx) error { panic("this did not work") })
+//	    app.Listen("127.0.0.1:3000")
+//	}
+package recoveryfiber
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unrolled/recovery"
+	"github.com/unrolled/recovery/recoveryfasthttp"
+)
+
+type contextKey int
+
+// routeKey holds the *string route of the request on its context.
+const routeKey contextKey = iota
+
+// New returns a Fiber middleware recovering the panics of the handlers after it with r, which writes the error response. The *http.Request Recovery logs and hooks has the user context of the request as context. Register it first, so the panics of every other middleware are recovered.
+func New(r *recovery.Recovery) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var err error
+		route := new(string)
+
+		parent := context.WithValue(c.UserContext(), routeKey, route)
+		recoveryfasthttp.Serve(r, c.Context(), parent, func() {
+			// The route of the handler that panicked, copied as c is reused once the request is over.
+			defer func() { *route = c.Route().Path }()
+
+			err = c.Next()
+		})
+
+		return err
+	}
+}
+
+// RoutePattern returns the Fiber route of a request, like "/users/:id", for the RoutePattern option. It is blank for requests not passing through a New middleware.
+func RoutePattern(req *http.Request) string {
+	route, ok := req.Context().Value(routeKey).(*string)
+	if !ok {
+		return ""
+	}
+
+	return *route
+}
@@ -0,0 +1,88 @@
+package recoveryfiber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/unrolled/recovery"
+)
+
+func myPanicHandler(c *fiber.Ctx) error {
+	panic("this did not work")
+}
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	r := recovery.New(recovery.Options{
+		Sinks:             []recovery.Sink{{Out: &out, Format: recovery.FormatJSON}},
+		LogRequestDetails: true,
+		RoutePattern:      RoutePattern,
+	})
+
+	app := fiber.New()
+	app.Use(New(r))
+	app.Get("/users/:id", myPanicHandler)
+
+	res, err := app.Test(httptest.NewRequest("GET", "/users/42", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", res.StatusCode)
+	}
+	if strings.TrimSpace(string(body)) != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("Expected the default response - Got [%s]", body)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the log - Got %v", doc["panic"])
+	}
+	if doc["route"] != "/users/:id" {
+		t.Errorf("Expected the Fiber route in the log - Got %v", doc["route"])
+	}
+	if doc["path"] != "/users/42" {
+		t.Errorf("Expected the path in the log - Got %v", doc["path"])
+	}
+	if !strings.Contains(doc["stack"].(string), "fiber_test.go") {
+		t.Errorf("Expected the stack of the handler in the log - Got %v", doc["stack"])
+	}
+}
+
+func TestNewNoPanic(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(recovery.New()))
+	app.Get("/foo", func(c *fiber.Ctx) error { return c.Status(http.StatusCreated).SendString("done") })
+	app.Get("/bar", func(c *fiber.Ctx) error { return errors.New("broken") })
+
+	res, err := app.Test(httptest.NewRequest("GET", "/foo", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusCreated || string(body) != "done" {
+		t.Errorf("Expected the response of the handler - Got %d [%s]", res.StatusCode, body)
+	}
+
+	// Errors are left to the error handler of Fiber.
+	res, err = app.Test(httptest.NewRequest("GET", "/bar", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusInternalServerError || string(body) != "broken" {
+		t.Errorf("Expected the response of the error handler - Got %d [%s]", res.StatusCode, body)
+	}
+}
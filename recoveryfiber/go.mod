@@ -0,0 +1,28 @@
+module github.com/unrolled/recovery/recoveryfiber
+
+go 1.21
+
+require (
+	github.com/gofiber/fiber/v2 v2.52.6
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+	github.com/unrolled/recovery/recoveryfasthttp v0.0.0-20261014111837-7878624c1445
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mattn/go-runewidth v0.0.16 // indirect
+	github.com/rivo/uniseg v0.2.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasthttp v1.58.0 // indirect
+	github.com/valyala/tcplisten v1.0.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
+
+replace (
+	github.com/unrolled/recovery => ../
+	github.com/unrolled/recovery/recoveryfasthttp => ../recoveryfasthttp
+)
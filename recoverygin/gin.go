@@ -0,0 +1,131 @@
+// Package recoverygin runs github.com/unrolled/recovery as a Gin middleware, in place of gin.Recovery, so Gin applications get the logging, sinks, formats, and panic mapping of the Options.
+//
+//	package main
+//
+//	import (
+//	    "github.com/gin-gonic/gin"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverygin"
+//	)
+//
+//	func main() {
+//	    r := recovery.New(recovery.Options{
+//	        Format:       recovery.FormatJSON,
+//	        RoutePattern: recoverygin.RoutePattern,
+//	    })
+//
+//	    engine := gin.New()
+//	    engine.Use(gin.Logger(), recoverygin.New(r))
+//	    engine.GET("/users/:id", func(c *gin.Context) { panic("this did not work") })
+//	    engine.Run("127.0.0.1:3000")
+//	}
+package recoverygin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unrolled/recovery"
+)
+
+type contextKey int
+
+// routeKey holds the route of the request on its context.
+const routeKey contextKey = iota
+
+// New returns a Gin middleware recovering the panics of the handlers after it with r. Once a panic is recovered, the handlers left are skipped. Register it first, after the logging middleware, so the panics of every other middleware are recovered.
+func New(r *recovery.Recovery) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		completed := false
+
+		original := c.Writer
+		req := c.Request.WithContext(context.WithValue(c.Request.Context(), routeKey, c.FullPath()))
+		r.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// The handlers write through the writer of Recovery, which knows whether the response started, or buffers it with BufferResponse.
+			c.Writer = &responseWriter{ResponseWriter: original, out: w}
+			c.Request = req
+
+			c.Next()
+			completed = true
+		})).ServeHTTP(original, req)
+
+		c.Writer = original
+		if !completed {
+			c.Abort()
+		}
+	}
+}
+
+// RoutePattern returns the Gin route of a request, like "/users/:id", for the RoutePattern option. It is blank for requests not passing through a New middleware, and for the ones no route matched.
+func RoutePattern(req *http.Request) string {
+	route, _ := req.Context().Value(routeKey).(string)
+	return route
+}
+
+// responseWriter is the gin.ResponseWriter of the handlers, writing through the writer of Recovery. The rest of the gin.ResponseWriter methods are the ones of the writer of Gin, which only sees the response once it is sent when the writer of Recovery buffers it.
+type responseWriter struct {
+	gin.ResponseWriter
+	out http.ResponseWriter
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.out.Header()
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.out.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	return w.out.Write(b)
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	return w.out.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the status written so far, unless the writer of Recovery buffers the response, when it is sent with it.
+func (w *responseWriter) WriteHeaderNow() {
+	if w.direct() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.out.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.out.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("recoverygin: the response is buffered, and cannot be hijacked")
+	}
+
+	return h.Hijack()
+}
+
+// Unwrap returns the writer of Recovery, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.out
+}
+
+// direct reports whether the writer of Recovery writes to the writer of Gin, rather than buffering.
+func (w *responseWriter) direct() bool {
+	rw := w.out
+	for {
+		if rw == http.ResponseWriter(w.ResponseWriter) {
+			return true
+		}
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		rw = u.Unwrap()
+	}
+}
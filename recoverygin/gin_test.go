@@ -0,0 +1,120 @@
+package recoverygin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unrolled/recovery"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func myPanicHandler(c *gin.Context) {
+	panic("this did not work")
+}
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	r := recovery.New(recovery.Options{
+		Sinks:             []recovery.Sink{{Out: &out, Format: recovery.FormatJSON}},
+		LogRequestDetails: true,
+		RoutePattern:      RoutePattern,
+	})
+
+	skipped := true
+	engine := gin.New()
+	engine.Use(New(r))
+	engine.GET("/users/:id", myPanicHandler, func(c *gin.Context) { skipped = false })
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/42", nil)
+	engine.ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", res.Code)
+	}
+	if strings.TrimSpace(res.Body.String()) != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("Expected the default response - Got [%s]", res.Body.String())
+	}
+	if !skipped {
+		t.Errorf("Expected the handlers after the panic to be skipped")
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the log - Got %v", doc["panic"])
+	}
+	if doc["route"] != "/users/:id" {
+		t.Errorf("Expected the Gin route in the log - Got %v", doc["route"])
+	}
+	if !strings.Contains(doc["stack"].(string), "gin_test.go") {
+		t.Errorf("Expected the stack of the handler in the log - Got %v", doc["stack"])
+	}
+}
+
+func TestNewNoPanic(t *testing.T) {
+	engine := gin.New()
+	engine.Use(New(recovery.New()))
+	engine.GET("/foo", func(c *gin.Context) { c.Header("X-Foo", "bar"); c.String(http.StatusCreated, "done") })
+	engine.GET("/bar", func(c *gin.Context) { c.AbortWithStatus(http.StatusNoContent) })
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	engine.ServeHTTP(res, req)
+
+	if res.Code != http.StatusCreated || res.Body.String() != "done" || res.Header().Get("X-Foo") != "bar" {
+		t.Errorf("Expected the response of the handler - Got %d [%s] %v", res.Code, res.Body.String(), res.Header())
+	}
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/bar", nil)
+	engine.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 - Got %d", res.Code)
+	}
+}
+
+func TestNewBufferResponse(t *testing.T) {
+	r := recovery.New(recovery.Options{
+		Sinks:          []recovery.Sink{{Out: &bytes.Buffer{}}},
+		BufferResponse: true,
+	})
+
+	engine := gin.New()
+	engine.Use(New(r))
+	engine.GET("/partial", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+		c.Writer.WriteHeaderNow()
+		c.Writer.WriteString("half a respo")
+		panic("this did not work")
+	})
+	engine.GET("/status", func(c *gin.Context) { c.AbortWithStatus(http.StatusAccepted) })
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/partial", nil)
+	engine.ServeHTTP(res, req)
+
+	// The partial response was held back, so a clean error replaces it.
+	if res.Code != http.StatusInternalServerError || strings.Contains(res.Body.String(), "half") {
+		t.Errorf("Expected a clean error - Got %d [%s]", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/status", nil)
+	engine.ServeHTTP(res, req)
+
+	if res.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202 - Got %d", res.Code)
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/unrolled/recovery/recoverygrpc
+
+go 1.21
+
+require (
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+	google.golang.org/grpc v1.67.0
+)
+
+require (
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
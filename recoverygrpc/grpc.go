@@ -0,0 +1,219 @@
+// Package recoverygrpc recovers the panics of unary and streaming gRPC handlers with github.com/unrolled/recovery, so gRPC services get the same logging, sinks, formats, and hooks as the HTTP ones. A recovered panic fails its call with a status error, codes.Internal by default.
+//
+//	package main
+//
+//	import (
+//	    "net"
+//
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverygrpc"
+//	    "google.golang.org/grpc"
+//	)
+//
+//	func main() {
+//	    r := recovery.New(recovery.Options{Format: recovery.FormatJSON})
+//
+//	    srv := grpc.NewServer(
+//	        grpc.ChainUnaryInterceptor(recoverygrpc.UnaryServerInterceptor(r)),
+//	        grpc.ChainStreamInterceptor(recoverygrpc.StreamServerInterceptor(r)),
+//	    )
+//	    // Register the services on srv.
+//
+//	    lis, _ := net.Listen("tcp", "127.0.0.1:50051")
+//	    srv.Serve(lis)
+//	}
+package recoverygrpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/unrolled/recovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Options configures the interceptors.
+type Options struct {
+	// Code, if set, returns the status code a call fails with after a panic. Returning codes.OK keeps the default. Default is nil (the code matching the HTTP status Recovery answers the panic with, so codes.Internal unless MapPanic says otherwise).
+	Code func(panicVal interface{}) codes.Code
+}
+
+type contextKey int
+
+// panicKey holds the *recovered of a call on its context.
+const panicKey contextKey = iota
+
+// recovered is the panic value of a call, set by the hook of the interceptors.
+type recovered struct {
+	value interface{}
+	ok    bool
+}
+
+// httpCodes are the status codes of the HTTP statuses Recovery may answer with. Other statuses are codes.Internal.
+var httpCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// UnaryServerInterceptor returns an interceptor recovering the panics of unary handlers with r, which logs them and runs its sinks and hooks. The *http.Request Recovery sees is made up from the call, with the full method as its path, the incoming metadata as its headers, and the context of the call. The response Recovery would write is not sent: the call fails with a status error instead, whose message is the plain text body Recovery would write, like the one of a mapped panic type, or else the HTTP status text. With AbortConnection, the call fails with codes.Aborted. Each interceptor registers a hook on r.
+func UnaryServerInterceptor(r *recovery.Recovery, opts ...Options) grpc.UnaryServerInterceptor {
+	opt := interceptorOptions(r, opts)
+
+	return func(ctx context.Context, in interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if perr := serve(ctx, r, opt, info.FullMethod, func() { resp, err = handler(ctx, in) }); perr != nil {
+			return nil, perr
+		}
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor recovering the panics of streaming handlers with r, like UnaryServerInterceptor does for unary ones. The context of the request is the one of the stream, and a panic in the middle of a stream ends it with the status error; the messages sent before it stay sent.
+func StreamServerInterceptor(r *recovery.Recovery, opts ...Options) grpc.StreamServerInterceptor {
+	opt := interceptorOptions(r, opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if perr := serve(ss.Context(), r, opt, info.FullMethod, func() { err = handler(srv, ss) }); perr != nil {
+			return perr
+		}
+
+		return err
+	}
+}
+
+// interceptorOptions returns the options of an interceptor, and registers the hook keeping the panic value of a call on r.
+func interceptorOptions(r *recovery.Recovery, opts []Options) Options {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	r.OnPanic(func(req *http.Request, v interface{}, _ []byte) {
+		if p, ok := req.Context().Value(panicKey).(*recovered); ok {
+			p.value, p.ok = v, true
+		}
+	})
+
+	return opt
+}
+
+// serve runs next, which handles a call of the method, recovering its panics with r. It returns the status error of a recovered panic, and nil otherwise.
+func serve(ctx context.Context, r *recovery.Recovery, opt Options, method string, next func()) (err error) {
+	completed := false
+	p := &recovered{}
+
+	defer func() {
+		// Recovery panics with http.ErrAbortHandler, with AbortConnection, for net/http to drop the connection. A call cannot drop its connection, so it is aborted instead.
+		if v := recover(); v != nil {
+			if v != http.ErrAbortHandler {
+				panic(v)
+			}
+			err = status.Error(codes.Aborted, "call aborted")
+		}
+	}()
+
+	w := &responseWriter{header: http.Header{}}
+	req := (&http.Request{Method: http.MethodPost, URL: &url.URL{Path: method}, Header: http.Header{}}).WithContext(context.WithValue(ctx, panicKey, p))
+	r.Handler(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		defer func() {
+			// Still unwinding a panic: Recovery handles this very request next.
+			if !completed {
+				fill(ctx, req, method)
+			}
+		}()
+
+		next()
+		completed = true
+	})).ServeHTTP(w, req)
+
+	if completed || !p.ok {
+		return nil
+	}
+
+	return w.statusError(opt, p.value)
+}
+
+// fill makes up the request of a call, in place, once it panicked.
+func fill(ctx context.Context, req *http.Request, method string) {
+	req.RequestURI = method
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for name, values := range md {
+		// Recovery already knows the pseudo headers, like :path.
+		if strings.HasPrefix(name, ":") {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		req.Host = authority[0]
+	}
+	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
+		req.RemoteAddr = pr.Addr.String()
+	}
+}
+
+// responseWriter keeps the error response Recovery writes, to turn it into a status error.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// statusError returns the status error of the kept response.
+func (w *responseWriter) statusError(opt Options, v interface{}) error {
+	httpStatus := w.status
+	if httpStatus == 0 {
+		httpStatus = http.StatusInternalServerError
+	}
+
+	code := codes.Internal
+	if c, ok := httpCodes[httpStatus]; ok {
+		code = c
+	}
+	if opt.Code != nil {
+		if c := opt.Code(v); c != codes.OK {
+			code = c
+		}
+	}
+
+	message := http.StatusText(httpStatus)
+	if body := strings.TrimSpace(w.body.String()); len(body) > 0 && strings.HasPrefix(w.header.Get("Content-Type"), "text/plain") {
+		message = body
+	}
+
+	return status.Error(code, message)
+}
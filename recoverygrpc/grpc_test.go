@@ -0,0 +1,231 @@
+package recoverygrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/unrolled/recovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var errMissing = errors.New("missing")
+
+var info = &grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"}
+
+func myPanicHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	panic("this did not work")
+}
+
+// newContext returns the context of a call from a client.
+func newContext() context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		":authority", "users.internal:50051",
+		"x-request-id", "abc123",
+		"user-agent", "grpc-go/1.67.0",
+	))
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var out bytes.Buffer
+	r := recovery.New(recovery.Options{
+		Sinks:             []recovery.Sink{{Out: &out, Format: recovery.FormatJSON}},
+		IncludeRequestID:  true,
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	resp, err := UnaryServerInterceptor(r)(newContext(), "in", info, myPanicHandler)
+
+	if resp != nil {
+		t.Errorf("Expected no response - Got %v", resp)
+	}
+	// The message is the body Recovery would write, with the request id of IncludeRequestID.
+	if s, _ := status.FromError(err); s.Code() != codes.Internal || s.Message() != "Internal Server Error\nRequest ID: abc123" {
+		t.Errorf("Expected an Internal status - Got %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for field, value := range map[string]interface{}{
+		"panic":       "this did not work",
+		"method":      "POST",
+		"path":        "/users.v1.Users/Get",
+		"request_id":  "abc123",
+		"remote_addr": "10.0.0.1:1234",
+	} {
+		if doc[field] != value {
+			t.Errorf("Expected %s [%v] in the log - Got [%v]", field, value, doc[field])
+		}
+	}
+	if !strings.Contains(out.String(), "grpc-go/1.67.0") {
+		t.Errorf("Expected the logged header - Got [%s]", out.String())
+	}
+	if !strings.Contains(doc["stack"].(string), "grpc_test.go") {
+		t.Errorf("Expected the stack of the handler in the log - Got %v", doc["stack"])
+	}
+}
+
+func TestUnaryServerInterceptorNoPanic(t *testing.T) {
+	interceptor := UnaryServerInterceptor(recovery.New())
+
+	resp, err := interceptor(newContext(), "in", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "out", nil
+	})
+	if resp != "out" || err != nil {
+		t.Errorf("Expected the response of the handler - Got %v, %v", resp, err)
+	}
+
+	_, err = interceptor(newContext(), "in", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such user")
+	})
+	if s, _ := status.FromError(err); s.Code() != codes.NotFound {
+		t.Errorf("Expected the error of the handler - Got %v", err)
+	}
+}
+
+func TestUnaryServerInterceptorCodes(t *testing.T) {
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}})
+	r.MapPanic(errMissing, http.StatusNotFound, "No such user")
+
+	var hooked interface{}
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) { hooked = panicVal })
+
+	// Mapped panics get the code of their HTTP status.
+	_, err := UnaryServerInterceptor(r)(newContext(), "in", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic(errMissing)
+	})
+	if s, _ := status.FromError(err); s.Code() != codes.NotFound || s.Message() != "No such user" {
+		t.Errorf("Expected a NotFound status - Got %v", err)
+	}
+	if hooked != errMissing {
+		t.Errorf("Expected the hooks of Recovery to run - Got %v", hooked)
+	}
+
+	// The Code option overrides them, unless it returns codes.OK.
+	interceptor := UnaryServerInterceptor(r, Options{Code: func(v interface{}) codes.Code {
+		if v == errMissing {
+			return codes.FailedPrecondition
+		}
+		return codes.OK
+	}})
+	_, err = interceptor(newContext(), "in", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic(errMissing)
+	})
+	if s, _ := status.FromError(err); s.Code() != codes.FailedPrecondition {
+		t.Errorf("Expected a FailedPrecondition status - Got %v", err)
+	}
+	_, err = interceptor(newContext(), "in", info, myPanicHandler)
+	if s, _ := status.FromError(err); s.Code() != codes.Internal {
+		t.Errorf("Expected an Internal status - Got %v", err)
+	}
+}
+
+func TestUnaryServerInterceptorAbortConnection(t *testing.T) {
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}, AbortConnection: true})
+
+	_, err := UnaryServerInterceptor(r)(newContext(), "in", info, myPanicHandler)
+	if s, _ := status.FromError(err); s.Code() != codes.Aborted {
+		t.Errorf("Expected an Aborted status - Got %v", err)
+	}
+}
+
+// serverStream is a stream of a call from a client, keeping the messages sent to it.
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []interface{}
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+var streamInfo = &grpc.StreamServerInfo{FullMethod: "/users.v1.Users/Watch", IsServerStream: true}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var out bytes.Buffer
+	r := recovery.New(recovery.Options{
+		Sinks:             []recovery.Sink{{Out: &out, Format: recovery.FormatJSON}},
+		LogRequestDetails: true,
+	})
+
+	ss := &serverStream{ctx: newContext()}
+	err := StreamServerInterceptor(r)(nil, ss, streamInfo, func(srv interface{}, stream grpc.ServerStream) error {
+		stream.SendMsg("first")
+		panic("this did not work")
+	})
+
+	if s, _ := status.FromError(err); s.Code() != codes.Internal || s.Message() != "Internal Server Error" {
+		t.Errorf("Expected an Internal status - Got %v", err)
+	}
+	if len(ss.sent) != 1 {
+		t.Errorf("Expected the message sent before the panic - Got %v", ss.sent)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the log - Got %v", doc["panic"])
+	}
+	if doc["path"] != "/users.v1.Users/Watch" {
+		t.Errorf("Expected the method in the log - Got %v", doc["path"])
+	}
+	if doc["remote_addr"] != "10.0.0.1:1234" {
+		t.Errorf("Expected the peer in the log - Got %v", doc["remote_addr"])
+	}
+	if !strings.Contains(doc["stack"].(string), "grpc_test.go") {
+		t.Errorf("Expected the stack of the handler in the log - Got %v", doc["stack"])
+	}
+}
+
+func TestStreamServerInterceptorNoPanic(t *testing.T) {
+	interceptor := StreamServerInterceptor(recovery.New())
+
+	ss := &serverStream{ctx: newContext()}
+	err := interceptor(nil, ss, streamInfo, func(srv interface{}, stream grpc.ServerStream) error {
+		stream.SendMsg("first")
+		return stream.SendMsg("second")
+	})
+	if err != nil || len(ss.sent) != 2 {
+		t.Errorf("Expected the stream of the handler - Got %v, %v", err, ss.sent)
+	}
+
+	err = interceptor(nil, ss, streamInfo, func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.Canceled, "gone")
+	})
+	if s, _ := status.FromError(err); s.Code() != codes.Canceled {
+		t.Errorf("Expected the error of the handler - Got %v", err)
+	}
+}
+
+func TestStreamServerInterceptorCode(t *testing.T) {
+	r := recovery.New(recovery.Options{Sinks: []recovery.Sink{{Out: &bytes.Buffer{}}}})
+	interceptor := StreamServerInterceptor(r, Options{Code: func(v interface{}) codes.Code { return codes.Unavailable }})
+
+	err := interceptor(nil, &serverStream{ctx: newContext()}, streamInfo, func(srv interface{}, stream grpc.ServerStream) error {
+		panic("this did not work")
+	})
+	if s, _ := status.FromError(err); s.Code() != codes.Unavailable {
+		t.Errorf("Expected an Unavailable status - Got %v", err)
+	}
+}
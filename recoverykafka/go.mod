@@ -0,0 +1,15 @@
+module github.com/unrolled/recovery/recoverykafka
+
+go 1.21
+
+require (
+	github.com/segmentio/kafka-go v0.4.48
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
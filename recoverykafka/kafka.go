@@ -0,0 +1,91 @@
+// Package recoverykafka publishes the panics recovered by github.com/unrolled/recovery to a Kafka topic as JSON events, keyed by fingerprint, so crashes can be aggregated across service instances.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverykafka"
+//	)
+//
+//	func main() {
+//	    w := recoverykafka.New([]string{"kafka-1:9092", "kafka-2:9092"}, "panics")
+//	    defer w.Close()
+//
+//	    r := recovery.New(recovery.Options{
+//	        Sinks:        []recovery.Sink{{Format: recovery.FormatText}, {Out: w, Format: recovery.FormatJSON}},
+//	        AsyncLogging: true,
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverykafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// writeTimeout bounds the publishing of one event.
+const writeTimeout = 10 * time.Second
+
+// Producer is the part of *kafka.Writer a Writer uses.
+type Producer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Writer publishes each write, a FormatJSON record, to a Kafka topic as one message. The key of the message is the fingerprint of the panic, so the occurrences of a bug land on the same partition, in order.
+type Writer struct {
+	producer Producer
+}
+
+// New returns a Writer publishing to the topic through the given brokers. Messages are spread over the partitions by a hash of their key, and a write waits for the leader of the partition to acknowledge it. Call Close on shutdown.
+func New(brokers []string, topic string) *Writer {
+	return NewWithProducer(&kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireOne,
+	})
+}
+
+// NewWithProducer returns a Writer publishing through the given producer, like a *kafka.Writer configured with TLS, SASL, or batching of its own.
+func NewWithProducer(producer Producer) *Writer {
+	return &Writer{producer: producer}
+}
+
+// Write publishes p, without its trailing newline, keyed by the fingerprint field of the record. Records without one are published without a key.
+func (w *Writer) Write(p []byte) (int, error) {
+	value := bytes.TrimSuffix(p, []byte("\n"))
+
+	msg := kafka.Message{
+		Value:   append([]byte(nil), value...),
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+	}
+	var rec struct {
+		Fingerprint string `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(value, &rec); err == nil && len(rec.Fingerprint) > 0 {
+		msg.Key = []byte(rec.Fingerprint)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if err := w.producer.WriteMessages(ctx, msg); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close flushes the pending messages and closes the producer.
+func (w *Writer) Close() error {
+	return w.producer.Close()
+}
@@ -0,0 +1,108 @@
+package recoverykafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+type fakeProducer struct {
+	msgs   []kafka.Message
+	err    error
+	closed bool
+}
+
+func (p *fakeProducer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestWriter(t *testing.T) {
+	producer := &fakeProducer{}
+	w := NewWithProducer(producer)
+
+	r := recovery.New(recovery.Options{
+		Sinks: []recovery.Sink{{Out: w, Format: recovery.FormatJSON}},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(producer.msgs) != 2 {
+		t.Fatalf("Expected 2 messages - Got %d", len(producer.msgs))
+	}
+
+	msg := producer.msgs[0]
+	var doc map[string]interface{}
+	if err := json.Unmarshal(msg.Value, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the event - Got %v", doc["panic"])
+	}
+	if string(msg.Key) != doc["fingerprint"] || len(msg.Key) == 0 {
+		t.Errorf("Expected the fingerprint as key - Got [%s]", msg.Key)
+	}
+	if string(producer.msgs[1].Key) != string(msg.Key) {
+		t.Errorf("Expected the same key for the same panic - Got [%s] and [%s]", msg.Key, producer.msgs[1].Key)
+	}
+	if len(msg.Headers) != 1 || string(msg.Headers[0].Value) != "application/json" {
+		t.Errorf("Expected a JSON content-type header - Got %v", msg.Headers)
+	}
+
+	if err := w.Close(); err != nil || !producer.closed {
+		t.Errorf("Expected the producer to be closed - Got %v", err)
+	}
+}
+
+func TestWriterWithoutFingerprint(t *testing.T) {
+	producer := &fakeProducer{}
+
+	n, err := NewWithProducer(producer).Write([]byte("not json\n"))
+	if err != nil || n != 9 {
+		t.Fatalf("Expected 9 bytes written - Got %d, %v", n, err)
+	}
+	if string(producer.msgs[0].Value) != "not json" || producer.msgs[0].Key != nil {
+		t.Errorf("Expected a message without key - Got [%s] [%s]", producer.msgs[0].Value, producer.msgs[0].Key)
+	}
+}
+
+func TestWriterError(t *testing.T) {
+	producer := &fakeProducer{err: errors.New("broker down")}
+
+	if _, err := NewWithProducer(producer).Write([]byte("{}\n")); err == nil || err.Error() != "broker down" {
+		t.Errorf("Expected the error of the producer - Got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	kw, ok := New([]string{"localhost:9092"}, "panics").producer.(*kafka.Writer)
+	if !ok {
+		t.Fatal("Expected a *kafka.Writer")
+	}
+	if kw.Topic != "panics" || kw.Addr.String() != "localhost:9092" {
+		t.Errorf("Expected the topic and brokers - Got [%s] [%s]", kw.Topic, kw.Addr)
+	}
+	if _, ok := kw.Balancer.(*kafka.Hash); !ok {
+		t.Errorf("Expected a hash balancer - Got %T", kw.Balancer)
+	}
+}
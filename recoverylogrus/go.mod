@@ -0,0 +1,12 @@
+module github.com/unrolled/recovery/recoverylogrus
+
+go 1.21
+
+require (
+	github.com/sirupsen/logrus v1.9.3
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+
+replace github.com/unrolled/recovery => ../
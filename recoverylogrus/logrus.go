@@ -0,0 +1,97 @@
+// Package recoverylogrus logs the panics recovered by github.com/unrolled/recovery as logrus entries.
+//
+// Entries go through the regular logrus machinery, so hooks registered on the logger fire for panics like for any other error.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//
+//	    "github.com/sirupsen/logrus"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverylogrus"
+//	)
+//
+//	func main() {
+//	    r := recovery.New(recovery.Options{
+//	        Logger: recoverylogrus.New(logrus.StandardLogger()),
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverylogrus
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/unrolled/recovery"
+)
+
+// Logger adapts a logrus logger or entry to recovery.Options.Logger. Panics are logged at the level matching their severity with their details as fields, and notes at warning level.
+type Logger struct {
+	logrus logrus.FieldLogger
+}
+
+// New returns a Logger writing to the given *logrus.Logger or *logrus.Entry. Fields already set on an entry are kept.
+func New(logger logrus.FieldLogger) *Logger {
+	return &Logger{logrus: logger}
+}
+
+// Printf logs a note about how a panic was handled.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.logrus.Warnf(format, v...)
+}
+
+// LogPanic logs a recovered panic with the panic, stack, method, path, status, and fingerprint fields, plus the origin of the panic, the request_id with IncludeRequestID, the trace_id and span_id of a traced request, the count of aggregated entries and the url, proto, remote_addr, route, headers, and body of the request when they were logged.
+func (l *Logger) LogPanic(rec *recovery.PanicRecord) {
+	fields := logrus.Fields{
+		"panic":       rec.Panic,
+		"stack":       string(rec.Stack),
+		"method":      rec.Method,
+		"path":        rec.Path,
+		"status":      rec.Status,
+		"fingerprint": rec.Fingerprint,
+	}
+	if len(rec.Origin) > 0 {
+		fields["origin"] = rec.Origin
+	}
+	if len(rec.RequestID) > 0 {
+		fields["request_id"] = rec.RequestID
+	}
+	if len(rec.TraceID) > 0 {
+		fields["trace_id"] = rec.TraceID
+		fields["span_id"] = rec.SpanID
+	}
+	if rec.Count > 0 {
+		fields["count"] = rec.Count
+	}
+	if len(rec.URL) > 0 {
+		fields["url"] = rec.URL
+		fields["proto"] = rec.Proto
+		fields["remote_addr"] = rec.RemoteAddr
+		if len(rec.Route) > 0 {
+			fields["route"] = rec.Route
+		}
+	}
+	if len(rec.Headers) > 0 {
+		fields["headers"] = rec.Headers
+	}
+	if len(rec.Body) > 0 {
+		fields["body"] = string(rec.Body)
+	}
+
+	l.logrus.WithFields(fields).WithTime(rec.Time).Log(level(rec.Severity), rec.Message)
+}
+
+// level maps a severity to a logrus level. Critical panics log at error level too, since the levels above it panic or exit.
+func level(severity recovery.Severity) logrus.Level {
+	switch severity {
+	case recovery.SeverityDebug:
+		return logrus.DebugLevel
+	case recovery.SeverityInfo:
+		return logrus.InfoLevel
+	case recovery.SeverityWarning:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
@@ -0,0 +1,165 @@
+package recoverylogrus
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+func TestLogPanic(t *testing.T) {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	hook := test.NewLocal(logger)
+
+	r := recovery.New(recovery.Options{
+		Logger:           New(logger),
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if len(hook.Entries) != 1 {
+		t.Fatalf("Expected the hook to fire once - Got %d", len(hook.Entries))
+	}
+
+	entry := hook.LastEntry()
+	if entry.Level != logrus.ErrorLevel {
+		t.Errorf("Expected error level - Got %v", entry.Level)
+	}
+	if entry.Message != "Recovering from Panic" {
+		t.Errorf("Expected [Recovering from Panic] - Got [%s]", entry.Message)
+	}
+
+	expected := logrus.Fields{
+		"panic":      "this did not work",
+		"method":     "GET",
+		"path":       "/foo",
+		"status":     http.StatusInternalServerError,
+		"request_id": "abc123",
+	}
+	for key, value := range expected {
+		if entry.Data[key] != value {
+			t.Errorf("Expected %s [%v] - Got [%v]", key, value, entry.Data[key])
+		}
+	}
+
+	if stack, _ := entry.Data["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("Expected a stack dump - Got [%s]", stack)
+	}
+
+	if origin, _ := entry.Data["origin"].(string); !strings.Contains(origin, "recoverylogrus/logrus_test.go:") {
+		t.Errorf("Expected the origin in the test file - Got [%s]", origin)
+	}
+}
+
+func TestEntryFields(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	r := recovery.New(recovery.Options{
+		Logger: New(logger.WithField("service", "api")),
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if entry := hook.LastEntry(); entry == nil || entry.Data["service"] != "api" {
+		t.Errorf("Expected the entry fields to be kept - Got %v", entry)
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	New(logger).Printf("skipped %d", 1)
+
+	if entry := hook.LastEntry(); entry == nil || entry.Level != logrus.WarnLevel || entry.Message != "skipped 1" {
+		t.Errorf("Expected a warning [skipped 1] - Got %v", entry)
+	}
+}
+
+func TestSeverityLevel(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	r := recovery.New(recovery.Options{
+		Logger: New(logger),
+		Severity: func(recovered interface{}) recovery.Severity {
+			return recovery.SeverityInfo
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if entry := hook.LastEntry(); entry == nil || entry.Level != logrus.InfoLevel {
+		t.Errorf("Expected an info entry - Got %v", entry)
+	}
+}
+
+func TestRequestDetails(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	r := recovery.New(recovery.Options{
+		Logger:            New(logger),
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo?a=1", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := hook.LastEntry()
+	if entry.Data["url"] != "/foo?a=1" || entry.Data["proto"] != "HTTP/1.1" {
+		t.Errorf("Expected the request details - Got %v", entry.Data)
+	}
+	if headers, _ := entry.Data["headers"].(map[string]string); headers["User-Agent"] != "curl/8.0" {
+		t.Errorf("Expected the User-Agent header - Got %v", entry.Data["headers"])
+	}
+}
+
+func TestRequestIDOmitted(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	r := recovery.New(recovery.Options{
+		Logger: New(logger),
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, ok := hook.LastEntry().Data["request_id"]; ok {
+		t.Errorf("Expected no request_id without IncludeRequestID - Got %v", hook.LastEntry().Data)
+	}
+}
+
+func TestTraceFields(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	r := recovery.New(recovery.Options{
+		Logger: New(logger),
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	data := hook.LastEntry().Data
+	if data["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || data["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("Expected the trace fields - Got %v", data)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/unrolled/recovery/recoverynats
+
+go 1.21
+
+require (
+	github.com/nats-io/nats.go v1.37.0
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+require (
+	github.com/klauspost/compress v1.17.2 // indirect
+	github.com/nats-io/nkeys v0.4.7 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	golang.org/x/crypto v0.18.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
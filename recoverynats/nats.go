@@ -0,0 +1,100 @@
+// Package recoverynats publishes the panics recovered by github.com/unrolled/recovery to a NATS subject as JSON events, through core NATS or JetStream, so alerting and remediation services can subscribe to them.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//
+//	    "github.com/nats-io/nats.go"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverynats"
+//	)
+//
+//	func main() {
+//	    nc, _ := nats.Connect(nats.DefaultURL)
+//	    defer nc.Drain()
+//
+//	    r := recovery.New(recovery.Options{
+//	        Sinks: []recovery.Sink{{Format: recovery.FormatText}, {Out: recoverynats.New(nc, "app.panics"), Format: recovery.FormatJSON}},
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverynats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// FingerprintHeader and SeverityHeader hold the fingerprint and level of the panic of a message, for subscribers to filter on without decoding it.
+	FingerprintHeader = "Recovery-Fingerprint"
+	SeverityHeader    = "Recovery-Severity"
+
+	// publishTimeout bounds the wait for the acknowledgement of JetStream.
+	publishTimeout = 10 * time.Second
+)
+
+// Publisher is the part of *nats.Conn a Writer uses.
+type Publisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+// JetStreamPublisher is the part of jetstream.JetStream a Writer uses.
+type JetStreamPublisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// Writer publishes each write, a FormatJSON record, to a subject as one message, with the fingerprint and severity of the panic as headers.
+type Writer struct {
+	subject string
+	publish func(msg *nats.Msg) error
+}
+
+// New returns a Writer publishing on a core NATS connection. Publishing is fire and forget: messages are buffered by the connection, and lost when no one subscribes.
+func New(nc Publisher, subject string) *Writer {
+	return &Writer{subject: subject, publish: nc.PublishMsg}
+}
+
+// NewJetStream returns a Writer publishing to a JetStream stream, which keeps the events for consumers that are not running. Every write waits for the acknowledgement of the stream.
+func NewJetStream(js JetStreamPublisher, subject string) *Writer {
+	return &Writer{subject: subject, publish: func(msg *nats.Msg) error {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+
+		_, err := js.PublishMsg(ctx, msg)
+		return err
+	}}
+}
+
+// Write publishes p, without its trailing newline. The headers are left out for records without the fields.
+func (w *Writer) Write(p []byte) (int, error) {
+	msg := nats.NewMsg(w.subject)
+	msg.Data = append([]byte(nil), bytes.TrimSuffix(p, []byte("\n"))...)
+	msg.Header.Set("Content-Type", "application/json")
+
+	var rec struct {
+		Fingerprint string `json:"fingerprint"`
+		Level       string `json:"level"`
+	}
+	if err := json.Unmarshal(msg.Data, &rec); err == nil {
+		if len(rec.Fingerprint) > 0 {
+			msg.Header.Set(FingerprintHeader, rec.Fingerprint)
+		}
+		if len(rec.Level) > 0 {
+			msg.Header.Set(SeverityHeader, rec.Level)
+		}
+	}
+
+	if err := w.publish(msg); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
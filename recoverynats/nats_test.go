@@ -0,0 +1,100 @@
+package recoverynats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+type fakeConn struct {
+	msgs []*nats.Msg
+	err  error
+}
+
+func (c *fakeConn) PublishMsg(msg *nats.Msg) error {
+	c.msgs = append(c.msgs, msg)
+	return c.err
+}
+
+type fakeJetStream struct {
+	msgs []*nats.Msg
+}
+
+func (js *fakeJetStream) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, errors.New("no deadline")
+	}
+	js.msgs = append(js.msgs, msg)
+	return &jetstream.PubAck{Stream: "PANICS", Sequence: uint64(len(js.msgs))}, nil
+}
+
+func TestWriter(t *testing.T) {
+	nc := &fakeConn{}
+
+	r := recovery.New(recovery.Options{
+		Sinks: []recovery.Sink{{Out: New(nc, "app.panics"), Format: recovery.FormatJSON}},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(nc.msgs) != 1 {
+		t.Fatalf("Expected 1 message - Got %d", len(nc.msgs))
+	}
+
+	msg := nc.msgs[0]
+	if msg.Subject != "app.panics" {
+		t.Errorf("Expected subject [app.panics] - Got [%s]", msg.Subject)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the event - Got %v", doc["panic"])
+	}
+	if msg.Header.Get(FingerprintHeader) != doc["fingerprint"] || len(msg.Header.Get(FingerprintHeader)) == 0 {
+		t.Errorf("Expected the fingerprint header - Got [%s]", msg.Header.Get(FingerprintHeader))
+	}
+	if msg.Header.Get(SeverityHeader) != "error" {
+		t.Errorf("Expected severity [error] - Got [%s]", msg.Header.Get(SeverityHeader))
+	}
+	if msg.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON content type - Got [%s]", msg.Header.Get("Content-Type"))
+	}
+}
+
+func TestWriterError(t *testing.T) {
+	nc := &fakeConn{err: nats.ErrConnectionClosed}
+
+	if _, err := New(nc, "app.panics").Write([]byte("{}\n")); err != nats.ErrConnectionClosed {
+		t.Errorf("Expected the error of the connection - Got %v", err)
+	}
+}
+
+func TestJetStreamWriter(t *testing.T) {
+	js := &fakeJetStream{}
+
+	n, err := NewJetStream(js, "app.panics").Write([]byte(`{"fingerprint":"abc","level":"critical"}` + "\n"))
+	if err != nil || n != 41 {
+		t.Fatalf("Expected 41 bytes written - Got %d, %v", n, err)
+	}
+	if len(js.msgs) != 1 {
+		t.Fatalf("Expected 1 message - Got %d", len(js.msgs))
+	}
+	if js.msgs[0].Header.Get(FingerprintHeader) != "abc" || js.msgs[0].Header.Get(SeverityHeader) != "critical" {
+		t.Errorf("Expected the headers of the record - Got %v", js.msgs[0].Header)
+	}
+}
@@ -0,0 +1,83 @@
+// Package recoverynewrelic records the panics recovered by github.com/unrolled/recovery as New Relic noticed errors.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//	    "os"
+//
+//	    "github.com/newrelic/go-agent/v3/newrelic"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverynewrelic"
+//	)
+//
+//	func main() {
+//	    app, _ := newrelic.NewApplication(
+//	        newrelic.ConfigAppName("myapp"),
+//	        newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+//	    )
+//
+//	    r := recovery.New(recovery.Options{})
+//	    r.OnPanic(recoverynewrelic.Report)
+//
+//	    http.ListenAndServe("127.0.0.1:3000", newrelic.WrapHandle(app, "/", r.Handler(http.DefaultServeMux)))
+//	}
+package recoverynewrelic
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/unrolled/recovery"
+)
+
+// Report notices the recovered panic as an error on the New Relic transaction of the request, found with newrelic.FromContext. The error message is the panic value as logged, scrubbed like the logs, the error class is the panic type, its stack trace the frames of the goroutine that panicked, and the route of the request and the request ID, fingerprint, origin, and severity of the panic are added as attributes. Requests without a transaction are left alone. Register it with recovery.OnPanic.
+func Report(req *http.Request, panicVal interface{}, stack []byte) {
+	txn := newrelic.FromContext(req.Context())
+	if txn == nil {
+		return
+	}
+
+	txn.NoticeError(newError(req, panicVal))
+}
+
+// newError builds the noticed error of a panic.
+func newError(req *http.Request, panicVal interface{}) newrelic.Error {
+	err := newrelic.Error{
+		Message:    fmt.Sprint(panicVal),
+		Class:      fmt.Sprintf("%T", panicVal),
+		Attributes: map[string]interface{}{},
+	}
+
+	// The record has the value as logged, scrubbed and capped to MaxPanicValueBytes, the route of the RoutePattern option with LogRequestDetails, and the request id with IncludeRequestID.
+	route, requestID := "", ""
+	if rec, ok := recovery.RecordFromContext(req.Context()); ok {
+		err.Message, route, requestID = rec.Panic, rec.Route, rec.RequestID
+	}
+	if len(route) == 0 {
+		route = recovery.ServeMuxPattern(req)
+	}
+	if len(route) > 0 {
+		err.Attributes["route"] = route
+	}
+
+	info, ok := recovery.FromContext(req.Context())
+	if !ok {
+		return err
+	}
+
+	err.Stack = info.Recovered().StackTrace()
+	err.Attributes["severity"] = info.Severity.String()
+	for name, value := range map[string]string{
+		"request_id":  requestID,
+		"fingerprint": info.Fingerprint,
+		"origin":      info.Origin,
+	} {
+		if len(value) > 0 {
+			err.Attributes[name] = value
+		}
+	}
+
+	return err
+}
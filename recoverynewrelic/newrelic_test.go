@@ -0,0 +1,117 @@
+package recoverynewrelic
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+func TestNewError(t *testing.T) {
+	var noticed newrelic.Error
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}, IncludeRequestID: true})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		noticed = newError(req, panicVal)
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if noticed.Class != "string" || noticed.Message != "this did not work" {
+		t.Errorf("Expected [string: this did not work] - Got [%s: %s]", noticed.Class, noticed.Message)
+	}
+	if noticed.Attributes["request_id"] != "abc123" {
+		t.Errorf("Expected request_id [abc123] - Got [%v]", noticed.Attributes["request_id"])
+	}
+	if noticed.Attributes["severity"] != "error" {
+		t.Errorf("Expected severity [error] - Got [%v]", noticed.Attributes["severity"])
+	}
+	if fp, _ := noticed.Attributes["fingerprint"].(string); len(fp) == 0 {
+		t.Errorf("Expected the fingerprint of the panic - Got [%v]", noticed.Attributes["fingerprint"])
+	}
+
+	var found bool
+	frames := runtime.CallersFrames(noticed.Stack)
+	for {
+		frame, more := frames.Next()
+		if strings.HasSuffix(frame.File, "newrelic_test.go") {
+			found = true
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	if !found {
+		t.Errorf("Expected the frame of the panicking handler in the stack trace")
+	}
+}
+
+func TestNewErrorScrubbed(t *testing.T) {
+	var noticed newrelic.Error
+
+	r := recovery.New(recovery.Options{
+		Out:                &bytes.Buffer{},
+		MaxPanicValueBytes: 18,
+		ScrubPatterns:      []*regexp.Regexp{recovery.EmailPattern},
+	})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		noticed = newError(req, panicVal)
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("no user gopher@example.com in the directory")
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	if noticed.Message != "no user [REDACTED]...(truncated)" {
+		t.Errorf("Expected the scrubbed message - Got [%s]", noticed.Message)
+	}
+	// Without IncludeRequestID, the logs hold no request id to add.
+	if id, ok := noticed.Attributes["request_id"]; ok {
+		t.Errorf("Expected no request_id - Got [%v]", id)
+	}
+}
+
+func TestReport(t *testing.T) {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("recovery"),
+		newrelic.ConfigLicense(strings.Repeat("0", 40)),
+		newrelic.ConfigEnabled(false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(Report)
+
+	for _, withTxn := range []bool{true, false} {
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		if withTxn {
+			txn := app.StartTransaction("GET /foo")
+			defer txn.End()
+			req = newrelic.RequestWithTransactionContext(req, txn)
+		}
+
+		res := httptest.NewRecorder()
+		r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+		if res.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500 - Got %d", res.Code)
+		}
+	}
+}
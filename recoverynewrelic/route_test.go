@@ -0,0 +1,56 @@
+//go:build go1.23
+
+// The go 1.21 module line otherwise keeps the pattern-less ServeMux.
+//go:debug httpmuxgo121=0
+
+package recoverynewrelic
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/unrolled/recovery"
+)
+
+func TestNewErrorRoute(t *testing.T) {
+	var noticed newrelic.Error
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		noticed = newError(req, panicVal)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /items/{id}", myPanicHandler)
+
+	req, _ := http.NewRequest("GET", "/items/42", nil)
+	r.Handler(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	if noticed.Attributes["route"] != "GET /items/{id}" {
+		t.Errorf("Expected route [GET /items/{id}] - Got [%v]", noticed.Attributes["route"])
+	}
+}
+
+func TestNewErrorRoutePatternOption(t *testing.T) {
+	var noticed newrelic.Error
+
+	// The route of the RoutePattern option wins, as in the logs.
+	r := recovery.New(recovery.Options{
+		Out:               &bytes.Buffer{},
+		LogRequestDetails: true,
+		RoutePattern:      func(req *http.Request) string { return "/items/:id" },
+	})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		noticed = newError(req, panicVal)
+	})
+
+	req, _ := http.NewRequest("GET", "/items/42", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if noticed.Attributes["route"] != "/items/:id" {
+		t.Errorf("Expected route [/items/:id] - Got [%v]", noticed.Attributes["route"])
+	}
+}
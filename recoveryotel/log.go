@@ -0,0 +1,123 @@
+package recoveryotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/unrolled/recovery"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope is the instrumentation scope of the emitted log records.
+const scope = "github.com/unrolled/recovery/recoveryotel"
+
+// Logger adapts an OpenTelemetry LoggerProvider to recovery.Options.Logger. Panics are emitted as LogRecords at the severity matching their own, ERROR by default, and notes at WARN. The records go through the processors and exporters of the provider, an OTLP one straight to a collector, and carry its resource attributes.
+type Logger struct {
+	logger log.Logger
+}
+
+// NewLogger returns a Logger emitting through the given provider, or the global one when it is nil.
+func NewLogger(provider log.LoggerProvider) *Logger {
+	if provider == nil {
+		provider = global.GetLoggerProvider()
+	}
+
+	return &Logger{logger: provider.Logger(scope)}
+}
+
+// Printf emits a note about how a panic was handled.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	var record log.Record
+	record.SetTimestamp(time.Now())
+	record.SetSeverity(log.SeverityWarn)
+	record.SetSeverityText(log.SeverityWarn.String())
+	record.SetBody(log.StringValue(fmt.Sprintf(format, v...)))
+
+	l.logger.Emit(context.Background(), record)
+}
+
+// LogPanic emits a recovered panic, with the message and panic as body and the exception.type, exception.message, exception.stacktrace, http.request.method, url.path, http.response.status_code, and recovery.fingerprint attributes, plus the origin of the panic, the recovery.request_id with IncludeRequestID, the count of aggregated entries and the url.full, network.protocol.name, client.address, and http.route of the request when they were logged. The record carries the trace and span of the request, so the SDK correlates it with them.
+func (l *Logger) LogPanic(rec *recovery.PanicRecord) {
+	var record log.Record
+	record.SetTimestamp(rec.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(severity(rec.Severity))
+	record.SetSeverityText(severity(rec.Severity).String())
+	record.SetBody(log.StringValue(rec.Message + ": " + rec.Panic))
+
+	record.AddAttributes(
+		log.String(string(exceptionType), fmt.Sprintf("%T", rec.Value)),
+		log.String(string(exceptionMessage), rec.Panic),
+		log.String(string(exceptionStacktrace), string(rec.Stack)),
+		log.String("http.request.method", rec.Method),
+		log.String("url.path", rec.Path),
+		log.Int("http.response.status_code", rec.Status),
+		log.String("recovery.fingerprint", rec.Fingerprint),
+	)
+	if len(rec.Origin) > 0 {
+		record.AddAttributes(log.String("recovery.origin", rec.Origin))
+	}
+	if len(rec.RequestID) > 0 {
+		record.AddAttributes(log.String("recovery.request_id", rec.RequestID))
+	}
+	if rec.Count > 0 {
+		record.AddAttributes(log.Int("recovery.count", rec.Count))
+	}
+	if len(rec.URL) > 0 {
+		record.AddAttributes(
+			log.String("url.full", rec.URL),
+			log.String("network.protocol.name", rec.Proto),
+			log.String("client.address", rec.RemoteAddr),
+		)
+		if len(rec.Route) > 0 {
+			record.AddAttributes(log.String("http.route", rec.Route))
+		}
+	}
+
+	l.logger.Emit(spanContext(rec), record)
+}
+
+// spanContext returns a context carrying the trace and span of a record, which the SDK correlates the LogRecord with.
+func spanContext(rec *recovery.PanicRecord) context.Context {
+	ctx := context.Background()
+
+	traceID, err := trace.TraceIDFromHex(rec.TraceID)
+	if err != nil {
+		return ctx
+	}
+	spanID, err := trace.SpanIDFromHex(rec.SpanID)
+	if err != nil {
+		return ctx
+	}
+
+	var flags trace.TraceFlags
+	if rec.TraceSampled {
+		flags = trace.FlagsSampled
+	}
+
+	return trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	}))
+}
+
+// severity maps a recovery severity to an OpenTelemetry one.
+func severity(s recovery.Severity) log.Severity {
+	switch s {
+	case recovery.SeverityDebug:
+		return log.SeverityDebug
+	case recovery.SeverityInfo:
+		return log.SeverityInfo
+	case recovery.SeverityWarning:
+		return log.SeverityWarn
+	case recovery.SeverityCritical:
+		return log.SeverityFatal
+	default:
+		return log.SeverityError
+	}
+}
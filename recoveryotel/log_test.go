@@ -0,0 +1,175 @@
+package recoveryotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/unrolled/recovery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// memoryExporter keeps the exported records.
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, record := range records {
+		e.records = append(e.records, record.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func newProvider(exporter *memoryExporter) *sdklog.LoggerProvider {
+	return sdklog.NewLoggerProvider(
+		sdklog.WithResource(resource.NewSchemaless(attribute.String("service.name", "myapp"))),
+		sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)),
+	)
+}
+
+func TestLogPanic(t *testing.T) {
+	exporter := &memoryExporter{}
+	provider := newProvider(exporter)
+	defer provider.Shutdown(context.Background())
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	r := recovery.New(recovery.Options{
+		Logger:           NewLogger(provider),
+		TraceFunc:        TraceContext,
+		IncludeRequestID: true,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	ctx, span := tp.Tracer("test").Start(req.Context(), "GET /foo")
+	res := httptest.NewRecorder()
+	r.Handler(myPanicHandler).ServeHTTP(res, req.WithContext(ctx))
+	span.End()
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", res.Code)
+	}
+	if len(exporter.records) != 1 {
+		t.Fatalf("Expected 1 record - Got %d", len(exporter.records))
+	}
+
+	record := exporter.records[0]
+	if record.Severity() != log.SeverityError || record.SeverityText() != "ERROR" {
+		t.Errorf("Expected severity ERROR - Got %v [%s]", record.Severity(), record.SeverityText())
+	}
+	if body := record.Body().AsString(); body != "Recovering from Panic: this did not work" {
+		t.Errorf("Expected the message and panic as body - Got [%s]", body)
+	}
+	if record.TraceID() != span.SpanContext().TraceID() || record.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected the ids of the span - Got [%s] and [%s]", record.TraceID(), record.SpanID())
+	}
+
+	resAttrs := record.Resource()
+	if value, _ := resAttrs.Set().Value("service.name"); value.AsString() != "myapp" {
+		t.Errorf("Expected the resource of the provider - Got %v", resAttrs.Attributes())
+	}
+
+	attrs := map[string]log.Value{}
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	expected := map[string]string{
+		"exception.type":      "string",
+		"exception.message":   "this did not work",
+		"http.request.method": "GET",
+		"url.path":            "/foo",
+		"recovery.request_id": "abc123",
+	}
+	for key, value := range expected {
+		if attrs[key].AsString() != value {
+			t.Errorf("Expected %s [%s] - Got [%v]", key, value, attrs[key])
+		}
+	}
+	if len(attrs["recovery.fingerprint"].AsString()) == 0 {
+		t.Errorf("Expected a fingerprint - Got none")
+	}
+	if attrs["http.response.status_code"].AsInt64() != http.StatusInternalServerError {
+		t.Errorf("Expected status code 500 - Got %v", attrs["http.response.status_code"])
+	}
+	if !strings.Contains(attrs["exception.stacktrace"].AsString(), "log_test.go") {
+		t.Errorf("Expected the stack of the panic - Got [%s]", attrs["exception.stacktrace"].AsString())
+	}
+}
+
+func TestLogPanicSeverity(t *testing.T) {
+	exporter := &memoryExporter{}
+	provider := newProvider(exporter)
+	defer provider.Shutdown(context.Background())
+
+	r := recovery.New(recovery.Options{
+		Logger:   NewLogger(provider),
+		Severity: func(interface{}) recovery.Severity { return recovery.SeverityCritical },
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(exporter.records) != 1 {
+		t.Fatalf("Expected 1 record - Got %d", len(exporter.records))
+	}
+	if record := exporter.records[0]; record.Severity() != log.SeverityFatal || record.SeverityText() != "FATAL" {
+		t.Errorf("Expected severity FATAL - Got %v [%s]", record.Severity(), record.SeverityText())
+	}
+	if record := exporter.records[0]; record.TraceID().IsValid() {
+		t.Errorf("Expected no trace id without a span - Got [%s]", record.TraceID())
+	}
+}
+
+func TestLogPanicRequestIDOmitted(t *testing.T) {
+	exporter := &memoryExporter{}
+	provider := newProvider(exporter)
+	defer provider.Shutdown(context.Background())
+
+	r := recovery.New(recovery.Options{
+		Logger: NewLogger(provider),
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	exporter.records[0].WalkAttributes(func(kv log.KeyValue) bool {
+		if kv.Key == "recovery.request_id" {
+			t.Errorf("Expected no request id without IncludeRequestID - Got [%s]", kv.Value.AsString())
+		}
+		return true
+	})
+}
+
+func TestLoggerPrintf(t *testing.T) {
+	exporter := &memoryExporter{}
+	provider := newProvider(exporter)
+	defer provider.Shutdown(context.Background())
+
+	NewLogger(provider).Printf("dropped %d panics", 3)
+
+	if len(exporter.records) != 1 {
+		t.Fatalf("Expected 1 record - Got %d", len(exporter.records))
+	}
+	if record := exporter.records[0]; record.Severity() != log.SeverityWarn || record.Body().AsString() != "dropped 3 panics" {
+		t.Errorf("Expected a WARN note - Got %v [%s]", record.Severity(), record.Body().AsString())
+	}
+}
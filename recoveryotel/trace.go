@@ -0,0 +1,78 @@
+// Package recoveryotel connects the panics recovered by github.com/unrolled/recovery to OpenTelemetry: it records them on the active span of the request, puts the ids of that span in the panic records, and emits the records as OpenTelemetry logs.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoveryotel"
+//	    "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+//	)
+//
+//	func main() {
+//	    r := recovery.New(recovery.Options{
+//	        Format:    recovery.FormatJSON,
+//	        TraceFunc: recoveryotel.TraceContext,
+//	    })
+//	    r.OnPanic(recoveryotel.Report)
+//
+//	    http.ListenAndServe("127.0.0.1:3000", otelhttp.NewHandler(r.Handler(http.DefaultServeMux), "server"))
+//	}
+package recoveryotel
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/unrolled/recovery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The exception event and its attributes, from the OpenTelemetry semantic conventions.
+const (
+	exceptionEvent      = "exception"
+	exceptionType       = attribute.Key("exception.type")
+	exceptionMessage    = attribute.Key("exception.message")
+	exceptionStacktrace = attribute.Key("exception.stacktrace")
+	exceptionEscaped    = attribute.Key("exception.escaped")
+)
+
+// Report records the recovered panic as an exception event on the span of the request, with the exception.type, exception.message, and exception.stacktrace attributes, the message scrubbed like the logs, and sets the status of the span to Error. The span is the one the tracing middleware wrapping Recovery started. Requests without a recording span are left alone. Register it with recovery.OnPanic.
+func Report(req *http.Request, panicVal interface{}, stack []byte) {
+	span := trace.SpanFromContext(req.Context())
+	if !span.IsRecording() {
+		return
+	}
+
+	// The value as logged, scrubbed and capped to MaxPanicValueBytes.
+	message := fmt.Sprint(panicVal)
+	if rec, ok := recovery.RecordFromContext(req.Context()); ok {
+		message = rec.Panic
+	}
+	attrs := []attribute.KeyValue{
+		exceptionType.String(fmt.Sprintf("%T", panicVal)),
+		exceptionMessage.String(message),
+		exceptionStacktrace.String(string(stack)),
+		// Recovery handles the panic, so it does not escape the span.
+		exceptionEscaped.Bool(false),
+	}
+	if info, ok := recovery.FromContext(req.Context()); ok && len(info.Fingerprint) > 0 {
+		attrs = append(attrs, attribute.String("recovery.fingerprint", info.Fingerprint))
+	}
+
+	span.AddEvent(exceptionEvent, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, message)
+}
+
+// TraceContext returns the trace and span ids of the span on the request context, for recovery.Options.TraceFunc. The ids are blank when there is no valid span, so Recovery falls back to the trace headers.
+func TraceContext(req *http.Request) (traceID, spanID string, sampled bool) {
+	sc := trace.SpanContextFromContext(req.Context())
+	if !sc.IsValid() {
+		return "", "", false
+	}
+
+	return sc.TraceID().String(), sc.SpanID().String(), sc.IsSampled()
+}
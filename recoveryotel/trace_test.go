@@ -0,0 +1,120 @@
+package recoveryotel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/unrolled/recovery"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+func TestReport(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	buf := &bytes.Buffer{}
+	r := recovery.New(recovery.Options{
+		Out:       buf,
+		Format:    recovery.FormatJSON,
+		TraceFunc: TraceContext,
+	})
+	r.OnPanic(Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	ctx, span := tp.Tracer("test").Start(req.Context(), "GET /foo")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span - Got %d", len(spans))
+	}
+
+	got := spans[0]
+	if got.Status.Code != codes.Error || got.Status.Description != "this did not work" {
+		t.Errorf("Expected an Error status - Got %v", got.Status)
+	}
+	if len(got.Events) != 1 || got.Events[0].Name != "exception" {
+		t.Fatalf("Expected an exception event - Got %v", got.Events)
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range got.Events[0].Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["exception.type"] != "string" || attrs["exception.message"] != "this did not work" {
+		t.Errorf("Expected the exception type and message - Got %v", attrs)
+	}
+	if !strings.Contains(attrs["exception.stacktrace"], "trace_test.go") {
+		t.Errorf("Expected the stack of the panic - Got [%s]", attrs["exception.stacktrace"])
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["trace_id"] != got.SpanContext.TraceID().String() || doc["span_id"] != got.SpanContext.SpanID().String() {
+		t.Errorf("Expected the ids of the span in the record - Got [%v] and [%v]", doc["trace_id"], doc["span_id"])
+	}
+}
+
+func TestReportScrubbed(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	r := recovery.New(recovery.Options{
+		Out:                &bytes.Buffer{},
+		MaxPanicValueBytes: 18,
+		ScrubPatterns:      []*regexp.Regexp{recovery.EmailPattern},
+	})
+	r.OnPanic(Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	ctx, span := tp.Tracer("test").Start(req.Context(), "GET /foo")
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("no user gopher@example.com in the directory")
+	})).ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	span.End()
+
+	got := exporter.GetSpans()[0]
+	if got.Status.Description != "no user [REDACTED]...(truncated)" {
+		t.Errorf("Expected the scrubbed status - Got [%s]", got.Status.Description)
+	}
+	for _, attr := range got.Events[0].Attributes {
+		if attr.Key == "exception.message" && attr.Value.Emit() != got.Status.Description {
+			t.Errorf("Expected the scrubbed exception.message - Got [%s]", attr.Value.Emit())
+		}
+	}
+}
+
+func TestReportWithoutSpan(t *testing.T) {
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	res := httptest.NewRecorder()
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", res.Code)
+	}
+
+	traceID, spanID, sampled := TraceContext(req)
+	if len(traceID) > 0 || len(spanID) > 0 || sampled {
+		t.Errorf("Expected no trace context - Got [%s] [%s] %v", traceID, spanID, sampled)
+	}
+}
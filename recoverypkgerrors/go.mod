@@ -0,0 +1,10 @@
+module github.com/unrolled/recovery/recoverypkgerrors
+
+go 1.21
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+replace github.com/unrolled/recovery => ../
@@ -0,0 +1,61 @@
+// Package recoverypkgerrors hands the panics recovered by github.com/unrolled/recovery to error tooling built on github.com/pkg/errors.
+//
+// Such tooling finds stack traces through the stackTracer interface of pkg/errors, a StackTrace method returning an errors.StackTrace, which the root module cannot implement without depending on pkg/errors.
+//
+//	r.OnPanic(func(req *http.Request, v interface{}, stack []byte) {
+//	    if rec, ok := recovery.RecoveredFromContext(req.Context()); ok {
+//	        report(recoverypkgerrors.Wrap(rec))
+//	    }
+//	})
+package recoverypkgerrors
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/unrolled/recovery"
+)
+
+// Error is a recovered panic with the StackTrace method of github.com/pkg/errors.
+type Error struct {
+	*recovery.Recovered
+}
+
+// Wrap returns rec as an *Error. It returns nil for a nil rec.
+func Wrap(rec *recovery.Recovered) *Error {
+	if rec == nil {
+		return nil
+	}
+
+	return &Error{rec}
+}
+
+// StackTrace returns the frames of the goroutine that panicked, innermost first, as the stackTracer interface of github.com/pkg/errors has it.
+func (e *Error) StackTrace() errors.StackTrace {
+	pcs := e.Recovered.StackTrace()
+	trace := make(errors.StackTrace, len(pcs))
+	for i, pc := range pcs {
+		trace[i] = errors.Frame(pc)
+	}
+
+	return trace
+}
+
+// Cause returns the panic value when it is an error, for errors.Cause, and the recovered panic otherwise.
+func (e *Error) Cause() error {
+	if err := e.Recovered.Unwrap(); err != nil {
+		return err
+	}
+
+	return e.Recovered
+}
+
+// Unwrap returns the recovered panic, for errors.Is and errors.As.
+func (e *Error) Unwrap() error {
+	return e.Recovered
+}
+
+// Format prints the error like the recovered panic does.
+func (e *Error) Format(s fmt.State, verb rune) {
+	e.Recovered.Format(s, verb)
+}
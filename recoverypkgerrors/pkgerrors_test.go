@@ -0,0 +1,71 @@
+package recoverypkgerrors
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/unrolled/recovery"
+)
+
+// stackTracer is the interface github.com/pkg/errors tooling looks for.
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+func myPanicHandler(w http.ResponseWriter, req *http.Request) {
+	panic("this did not work")
+}
+
+func TestWrap(t *testing.T) {
+	var err error
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		if rec, ok := recovery.RecoveredFromContext(req.Context()); ok {
+			err = Wrap(rec)
+		}
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(http.HandlerFunc(myPanicHandler)).ServeHTTP(httptest.NewRecorder(), req)
+
+	tracer, ok := err.(stackTracer)
+	if !ok {
+		t.Fatalf("Expected a stackTracer - Got %T", err)
+	}
+	trace := tracer.StackTrace()
+	if len(trace) == 0 {
+		t.Fatal("Expected frames in the stack trace")
+	}
+	if frames := fmt.Sprintf("%v", trace); !strings.Contains(frames, "pkgerrors_test.go:") {
+		t.Errorf("Expected the handler in the stack trace - Got %s", frames)
+	}
+
+	if err.Error() != "panic: this did not work" {
+		t.Errorf("Expected the panic message - Got [%s]", err.Error())
+	}
+	if verbose := fmt.Sprintf("%+v", err); !strings.Contains(verbose, "pkgerrors_test.go:") {
+		t.Errorf("Expected the frames with %%+v - Got [%s]", verbose)
+	}
+}
+
+func TestWrapCause(t *testing.T) {
+	err := Wrap((&recovery.PanicInfo{Value: io.ErrUnexpectedEOF}).Recovered())
+	if errors.Cause(err) != io.ErrUnexpectedEOF {
+		t.Errorf("Expected the panic value as the cause - Got %v", errors.Cause(err))
+	}
+
+	rec := (&recovery.PanicInfo{Value: "oops"}).Recovered()
+	if errors.Cause(Wrap(rec)) != rec {
+		t.Errorf("Expected the recovered panic as the cause - Got %v", errors.Cause(Wrap(rec)))
+	}
+
+	if Wrap(nil) != nil {
+		t.Errorf("Expected nil for a nil panic")
+	}
+}
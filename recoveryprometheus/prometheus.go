@@ -0,0 +1,104 @@
+// Package recoveryprometheus counts the panics recovered by github.com/unrolled/recovery as Prometheus metrics, so panic rates can be graphed and alerted on.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//
+//	    "github.com/prometheus/client_golang/prometheus"
+//	    "github.com/prometheus/client_golang/prometheus/promhttp"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoveryprometheus"
+//	)
+//
+//	func main() {
+//	    metrics := recoveryprometheus.New(recoveryprometheus.Options{})
+//	    prometheus.MustRegister(metrics)
+//
+//	    r := recovery.New(recovery.Options{})
+//	    r.OnPanic(metrics.Hook("api"))
+//
+//	    mux := http.NewServeMux()
+//	    mux.Handle("/metrics", promhttp.Handler())
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(mux))
+//	}
+package recoveryprometheus
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/unrolled/recovery"
+)
+
+// Options configures a Collector.
+type Options struct {
+	// Namespace prefixes the metric names. Default is "recovery".
+	Namespace string
+	// RoutePattern, if set, returns the route label of a request, for routers that keep the pattern on the request context. Default is nil (the http.ServeMux pattern, from Go 1.23 on). Keep routes to patterns, not paths, so the label stays bounded.
+	RoutePattern func(req *http.Request) string
+}
+
+// Collector is a prometheus.Collector of recovered panics. It exports:
+//
+//	recovery_panics_total{handler,route,panic_type}   counter of the recovered panics
+//	recovery_last_panic_timestamp_seconds{handler}    gauge of when the last panic was recovered
+//
+// Register it once, and feed it with the hooks of Hook.
+type Collector struct {
+	panics *prometheus.CounterVec
+	last   *prometheus.GaugeVec
+	route  func(req *http.Request) string
+}
+
+// New returns a Collector with the given options.
+func New(opt Options) *Collector {
+	if len(opt.Namespace) == 0 {
+		opt.Namespace = "recovery"
+	}
+	if opt.RoutePattern == nil {
+		opt.RoutePattern = recovery.ServeMuxPattern
+	}
+
+	return &Collector{
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opt.Namespace,
+			Name:      "panics_total",
+			Help:      "Number of panics recovered.",
+		}, []string{"handler", "route", "panic_type"}),
+		last: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: opt.Namespace,
+			Name:      "last_panic_timestamp_seconds",
+			Help:      "Unix time of the last panic recovered.",
+		}, []string{"handler"}),
+		route: opt.RoutePattern,
+	}
+}
+
+// Hook returns a hook counting the panics of a handler, for recovery.OnPanic. The handler name labels the metrics, so one Collector can count the panics of every Recovery of a process.
+func (c *Collector) Hook(handler string) func(req *http.Request, panicVal interface{}, stack []byte) {
+	return func(req *http.Request, panicVal interface{}, stack []byte) {
+		recovered := time.Now()
+		if info, ok := recovery.FromContext(req.Context()); ok {
+			recovered = info.Time
+		}
+
+		c.panics.WithLabelValues(handler, c.route(req), fmt.Sprintf("%T", panicVal)).Inc()
+		c.last.WithLabelValues(handler).Set(float64(recovered.UnixNano()) / 1e9)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.panics.Describe(ch)
+	c.last.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.panics.Collect(ch)
+	c.last.Collect(ch)
+}
@@ -0,0 +1,86 @@
+package recoveryprometheus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+func TestHook(t *testing.T) {
+	metrics := New(Options{
+		RoutePattern: func(req *http.Request) string { return "/items/{id}" },
+	})
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(metrics.Hook("api"))
+
+	before := time.Now()
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/items/42", nil)
+		r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expected := `
+# HELP recovery_panics_total Number of panics recovered.
+# TYPE recovery_panics_total counter
+recovery_panics_total{handler="api",panic_type="string",route="/items/{id}"} 2
+`
+	if err := testutil.CollectAndCompare(metrics, strings.NewReader(expected), "recovery_panics_total"); err != nil {
+		t.Error(err)
+	}
+
+	last := testutil.ToFloat64(metrics.last.WithLabelValues("api"))
+	if last < float64(before.Unix()) || last > float64(time.Now().Unix()+1) {
+		t.Errorf("Expected the time of the last panic - Got %v", last)
+	}
+}
+
+func TestHookHandlers(t *testing.T) {
+	metrics := New(Options{Namespace: "myapp"})
+
+	api := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	api.OnPanic(metrics.Hook("api"))
+	admin := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	admin.OnPanic(metrics.Hook("admin"))
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	api.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	admin.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrNotSupported)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := `
+# HELP myapp_panics_total Number of panics recovered.
+# TYPE myapp_panics_total counter
+myapp_panics_total{handler="admin",panic_type="*http.ProtocolError",route=""} 1
+myapp_panics_total{handler="api",panic_type="string",route=""} 1
+`
+	if err := testutil.CollectAndCompare(metrics, strings.NewReader(expected), "myapp_panics_total"); err != nil {
+		t.Error(err)
+	}
+	if count := testutil.CollectAndCount(metrics, "myapp_last_panic_timestamp_seconds"); count != 2 {
+		t.Errorf("Expected a last panic gauge per handler - Got %d", count)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(New(Options{})); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reg.Gather(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build go1.23
+
+// The go 1.21 module line otherwise keeps the pattern-less ServeMux.
+//go:debug httpmuxgo121=0
+
+package recoveryprometheus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/unrolled/recovery"
+)
+
+func TestHookRoute(t *testing.T) {
+	metrics := New(Options{})
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(metrics.Hook("api"))
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /items/{id}", myPanicHandler)
+
+	req, _ := http.NewRequest("GET", "/items/42", nil)
+	r.Handler(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := `
+# HELP recovery_panics_total Number of panics recovered.
+# TYPE recovery_panics_total counter
+recovery_panics_total{handler="api",panic_type="string",route="GET /items/{id}"} 1
+`
+	if err := testutil.CollectAndCompare(metrics, strings.NewReader(expected), "recovery_panics_total"); err != nil {
+		t.Error(err)
+	}
+}
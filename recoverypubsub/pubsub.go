@@ -0,0 +1,91 @@
+// Package recoverypubsub publishes the panics recovered by github.com/unrolled/recovery to a Google Cloud Pub/Sub topic as JSON messages, for subscribers to triage, store, and alert on outside the request path.
+//
+//	package main
+//
+//	import (
+//	    "context"
+//	    "net/http"
+//
+//	    "cloud.google.com/go/pubsub"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverypubsub"
+//	)
+//
+//	func main() {
+//	    client, _ := pubsub.NewClient(context.Background(), "my-project")
+//	    w := recoverypubsub.New(client.Topic("panics"))
+//	    defer w.Close()
+//
+//	    r := recovery.New(recovery.Options{
+//	        Sinks:        []recovery.Sink{{Format: recovery.FormatText}, {Out: w, Format: recovery.FormatJSON}},
+//	        AsyncLogging: true,
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverypubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// publishTimeout bounds the wait for the acknowledgement of one message.
+const publishTimeout = 10 * time.Second
+
+// Writer publishes each write, a FormatJSON record, to a Pub/Sub topic as one message, with the fingerprint and severity of the panic as the fingerprint and severity attributes, for subscription filters to match on.
+type Writer struct {
+	topic *pubsub.Topic
+}
+
+// New returns a Writer publishing to the topic. Setting EnableMessageOrdering on the topic orders the occurrences of a bug, keyed by fingerprint. Call Close on shutdown.
+func New(topic *pubsub.Topic) *Writer {
+	return &Writer{topic: topic}
+}
+
+// Write publishes p, without its trailing newline, and waits for the server to acknowledge it. The attributes are left out for records without the fields.
+func (w *Writer) Write(p []byte) (int, error) {
+	msg := &pubsub.Message{
+		Data:       append([]byte(nil), bytes.TrimSuffix(p, []byte("\n"))...),
+		Attributes: map[string]string{},
+	}
+
+	var rec struct {
+		Fingerprint string `json:"fingerprint"`
+		Level       string `json:"level"`
+	}
+	if err := json.Unmarshal(msg.Data, &rec); err == nil {
+		if len(rec.Fingerprint) > 0 {
+			msg.Attributes["fingerprint"] = rec.Fingerprint
+			if w.topic.EnableMessageOrdering {
+				msg.OrderingKey = rec.Fingerprint
+			}
+		}
+		if len(rec.Level) > 0 {
+			msg.Attributes["severity"] = rec.Level
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if _, err := w.topic.Publish(ctx, msg).Get(ctx); err != nil {
+		if len(msg.OrderingKey) > 0 {
+			// A failed publish pauses its ordering key, which would fail every later panic of the bug.
+			w.topic.ResumePublish(msg.OrderingKey)
+		}
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close publishes the pending messages and stops the goroutines of the topic.
+func (w *Writer) Close() error {
+	w.topic.Stop()
+	return nil
+}
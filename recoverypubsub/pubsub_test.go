@@ -0,0 +1,105 @@
+package recoverypubsub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/unrolled/recovery"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+// newTopic returns a topic of a fake server, and the server.
+func newTopic(t *testing.T) (*pubsub.Topic, *pstest.Server) {
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := grpc.NewClient(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	client, err := pubsub.NewClient(context.Background(), "project", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	topic, err := client.CreateTopic(context.Background(), "panics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return topic, srv
+}
+
+func TestWriter(t *testing.T) {
+	topic, srv := newTopic(t)
+	w := New(topic)
+	defer w.Close()
+
+	r := recovery.New(recovery.Options{
+		Sinks: []recovery.Sink{{Out: w, Format: recovery.FormatJSON}},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("Expected 1 message - Got %d", len(msgs))
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(msgs[0].Data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the message - Got %v", doc["panic"])
+	}
+	if msgs[0].Attributes["fingerprint"] != doc["fingerprint"] {
+		t.Errorf("Expected the fingerprint attribute - Got [%s]", msgs[0].Attributes["fingerprint"])
+	}
+	if msgs[0].Attributes["severity"] != "error" {
+		t.Errorf("Expected the severity attribute - Got [%s]", msgs[0].Attributes["severity"])
+	}
+	if len(msgs[0].OrderingKey) > 0 {
+		t.Errorf("Expected no ordering key - Got [%s]", msgs[0].OrderingKey)
+	}
+}
+
+func TestWriterOrdering(t *testing.T) {
+	topic, srv := newTopic(t)
+	topic.EnableMessageOrdering = true
+	w := New(topic)
+	defer w.Close()
+
+	if _, err := w.Write([]byte(`{"fingerprint":"abc"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("not json\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("Expected 2 messages - Got %d", len(msgs))
+	}
+	if msgs[0].OrderingKey != "abc" {
+		t.Errorf("Expected the fingerprint as ordering key - Got [%s]", msgs[0].OrderingKey)
+	}
+	if string(msgs[1].Data) != "not json" || len(msgs[1].Attributes) > 0 {
+		t.Errorf("Expected the write without attributes - Got [%s] %v", msgs[1].Data, msgs[1].Attributes)
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/unrolled/recovery/recoverysentry
+
+go 1.21
+
+require (
+	github.com/getsentry/sentry-go v0.34.1
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+require (
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
@@ -0,0 +1,162 @@
+// Package recoverysentry reports the panics recovered by github.com/unrolled/recovery to Sentry.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//	    "time"
+//
+//	    "github.com/getsentry/sentry-go"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverysentry"
+//	)
+//
+//	func main() {
+//	    sentry.Init(sentry.ClientOptions{
+//	        Dsn:     "https://public@sentry.example.com/1",
+//	        Release: "myapp@1.2.3",
+//	    })
+//	    defer sentry.Flush(2 * time.Second)
+//
+//	    r := recovery.New(recovery.Options{})
+//	    r.OnPanic(recoverysentry.New(nil).Report)
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverysentry
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/unrolled/recovery"
+)
+
+// Reporter sends recovered panics to Sentry as events, with the request as Recovery logs it, the stack frames of the goroutine that panicked, and the fingerprint of the panic, so Sentry groups occurrences of the same bug together. The release and environment come from the sentry.ClientOptions of the hub.
+type Reporter struct {
+	hub *sentry.Hub
+}
+
+// New returns a Reporter sending to the given hub, or to sentry.CurrentHub when it is nil. A hub on the request context, as set by the sentryhttp middleware, is used instead when there is one.
+func New(hub *sentry.Hub) *Reporter {
+	return &Reporter{hub: hub}
+}
+
+// Report sends a recovered panic to Sentry. Register it with recovery.OnPanic. The event holds no more than the logs of Recovery: the panic value is scrubbed and capped to MaxPanicValueBytes, the query string is only sent with LogRequestDetails, scrubbed, and the headers are the LogHeaders, redacted.
+func (rep *Reporter) Report(req *http.Request, panicVal interface{}, stack []byte) {
+	hub := sentry.GetHubFromContext(req.Context())
+	if hub == nil {
+		hub = rep.hub
+	}
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	// Outside of a hook of Recovery, there are no scrubbing options to honor.
+	rec, ok := recovery.RecordFromContext(req.Context())
+	if !ok {
+		rec = &recovery.PanicRecord{Panic: fmt.Sprint(panicVal), Method: req.Method, Path: req.URL.Path}
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Message = rec.Panic
+	event.Request = request(req, rec)
+	if len(rec.RequestID) > 0 {
+		event.Tags["request_id"] = rec.RequestID
+	}
+
+	exception := sentry.Exception{
+		Type:      fmt.Sprintf("%T", panicVal),
+		Value:     rec.Panic,
+		Mechanism: &sentry.Mechanism{Type: "recovery"},
+	}
+	exception.Mechanism.SetUnhandled()
+
+	if info, ok := recovery.FromContext(req.Context()); ok {
+		event.Level = level(info.Severity)
+		event.Timestamp = info.Time
+		if len(info.Fingerprint) > 0 {
+			event.Fingerprint = []string{info.Fingerprint}
+			event.Tags["fingerprint"] = info.Fingerprint
+		}
+		if len(info.Origin) > 0 {
+			event.Tags["origin"] = info.Origin
+		}
+		if len(info.Body) > 0 {
+			event.Request.Data = string(info.Body)
+		}
+		if len(info.Frames) > 0 {
+			exception.Stacktrace = stacktrace(info.Frames)
+		}
+	}
+
+	if exception.Stacktrace == nil && len(stack) > 0 {
+		event.Extra["stack"] = string(stack)
+	}
+	event.Exception = []sentry.Exception{exception}
+
+	hub.CaptureEvent(event)
+}
+
+// request returns the Sentry request of a panic, from its record rather than the request itself, so it is scrubbed like the logs.
+func request(req *http.Request, rec *recovery.PanicRecord) *sentry.Request {
+	uri := rec.Path
+	if len(rec.URL) > 0 {
+		uri = rec.URL
+	}
+	path, query, _ := strings.Cut(uri, "?")
+
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	sr := &sentry.Request{
+		URL:         scheme + "://" + req.Host + path,
+		Method:      rec.Method,
+		QueryString: query,
+		Headers:     rec.Headers,
+	}
+	if host, _, err := net.SplitHostPort(rec.RemoteAddr); err == nil {
+		sr.Env = map[string]string{"REMOTE_ADDR": host}
+	}
+
+	return sr
+}
+
+// stacktrace converts frames, innermost first, to a Sentry stacktrace, which lists the outermost call first.
+func stacktrace(frames []recovery.Frame) *sentry.Stacktrace {
+	st := &sentry.Stacktrace{Frames: make([]sentry.Frame, 0, len(frames))}
+	for i := len(frames) - 1; i >= 0; i-- {
+		frame := frames[i]
+		st.Frames = append(st.Frames, sentry.NewFrame(runtime.Frame{
+			PC:       frame.PC,
+			Function: frame.Func,
+			File:     frame.File,
+			Line:     frame.Line,
+		}))
+	}
+
+	return st
+}
+
+// level maps a severity to a Sentry level.
+func level(severity recovery.Severity) sentry.Level {
+	switch severity {
+	case recovery.SeverityDebug:
+		return sentry.LevelDebug
+	case recovery.SeverityInfo:
+		return sentry.LevelInfo
+	case recovery.SeverityWarning:
+		return sentry.LevelWarning
+	case recovery.SeverityCritical:
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelError
+	}
+}
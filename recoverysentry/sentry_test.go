@@ -0,0 +1,200 @@
+package recoverysentry
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+// transport keeps the events instead of sending them.
+type transport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *transport) Flush(time.Duration) bool              { return true }
+func (t *transport) FlushWithContext(context.Context) bool { return true }
+func (t *transport) Configure(sentry.ClientOptions)        {}
+func (t *transport) Close()                                {}
+func (t *transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func newHub(t *testing.T, tr *transport) *sentry.Hub {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       "https://public@sentry.example.com/1",
+		Release:   "myapp@1.2.3",
+		Transport: tr,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sentry.NewHub(client, sentry.NewScope())
+}
+
+func TestReport(t *testing.T) {
+	tr := &transport{}
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}, LogRequestDetails: true, IncludeRequestID: true})
+	r.OnPanic(New(newHub(t, tr)).Report)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo?a=1", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if len(tr.events) != 1 {
+		t.Fatalf("Expected 1 event - Got %d", len(tr.events))
+	}
+
+	event := tr.events[0]
+	if event.Level != sentry.LevelError {
+		t.Errorf("Expected error level - Got %v", event.Level)
+	}
+	if event.Release != "myapp@1.2.3" {
+		t.Errorf("Expected release [myapp@1.2.3] - Got [%s]", event.Release)
+	}
+	if event.Tags["request_id"] != "abc123" {
+		t.Errorf("Expected request_id [abc123] - Got [%s]", event.Tags["request_id"])
+	}
+	if len(event.Fingerprint) != 1 || len(event.Fingerprint[0]) == 0 || event.Fingerprint[0] != event.Tags["fingerprint"] {
+		t.Errorf("Expected the panic fingerprint - Got %v", event.Fingerprint)
+	}
+	if event.Request == nil || event.Request.Method != "GET" || event.Request.QueryString != "a=1" {
+		t.Errorf("Expected the request - Got %+v", event.Request)
+	}
+
+	if len(event.Exception) != 1 {
+		t.Fatalf("Expected 1 exception - Got %d", len(event.Exception))
+	}
+	exception := event.Exception[0]
+	if exception.Type != "string" || exception.Value != "this did not work" {
+		t.Errorf("Expected [string: this did not work] - Got [%s: %s]", exception.Type, exception.Value)
+	}
+	if exception.Mechanism == nil || exception.Mechanism.Handled == nil || *exception.Mechanism.Handled {
+		t.Errorf("Expected an unhandled mechanism - Got %+v", exception.Mechanism)
+	}
+
+	// Sentry lists the outermost call first, so the handler that panicked is near the end.
+	frames := exception.Stacktrace.Frames
+	var found bool
+	for _, frame := range frames[len(frames)/2:] {
+		if strings.HasSuffix(frame.AbsPath, "sentry_test.go") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the frame of the panicking handler - Got %+v", frames)
+	}
+}
+
+func TestReportScrubbed(t *testing.T) {
+	tr := &transport{}
+
+	r := recovery.New(recovery.Options{
+		Out:                &bytes.Buffer{},
+		LogRequestDetails:  true,
+		LogHeaders:         []string{"Authorization", "User-Agent"},
+		MaxPanicValueBytes: 18,
+		ScrubPatterns:      []*regexp.Regexp{recovery.EmailPattern},
+	})
+	r.OnPanic(New(newHub(t, tr)).Report)
+
+	req, _ := http.NewRequest("GET", "/users/gopher@example.com?token=s3cret&page=2", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Cookie", "session=xyz")
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("no user gopher@example.com in the directory")
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(tr.events) != 1 {
+		t.Fatalf("Expected 1 event - Got %d", len(tr.events))
+	}
+
+	event := tr.events[0]
+	if event.Message != "no user [REDACTED]...(truncated)" || event.Exception[0].Value != event.Message {
+		t.Errorf("Expected the scrubbed panic value - Got [%s] [%s]", event.Message, event.Exception[0].Value)
+	}
+	if event.Request.URL != "http:///users/[REDACTED]" || event.Request.QueryString != "token=[REDACTED]&page=2" {
+		t.Errorf("Expected the scrubbed URL - Got [%s] [%s]", event.Request.URL, event.Request.QueryString)
+	}
+	if len(event.Request.Headers) != 2 || event.Request.Headers["Authorization"] != "[REDACTED]" || event.Request.Headers["User-Agent"] != "curl/8.0" {
+		t.Errorf("Expected the logged headers - Got %v", event.Request.Headers)
+	}
+	if len(event.Request.Cookies) > 0 {
+		t.Errorf("Expected no cookies - Got [%s]", event.Request.Cookies)
+	}
+}
+
+func TestReportNoRequestDetails(t *testing.T) {
+	tr := &transport{}
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(New(newHub(t, tr)).Report)
+
+	req, _ := http.NewRequest("GET", "/foo?a=1", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	// Without LogRequestDetails, neither the query string nor the headers are sent.
+	event := tr.events[0]
+	if event.Request.QueryString != "" || len(event.Request.Headers) > 0 {
+		t.Errorf("Expected no request details - Got %+v", event.Request)
+	}
+	// Without IncludeRequestID, the logs hold no request id to tag.
+	if _, ok := event.Tags["request_id"]; ok {
+		t.Errorf("Expected no request_id - Got [%s]", event.Tags["request_id"])
+	}
+}
+
+func TestReportSeverity(t *testing.T) {
+	tr := &transport{}
+
+	r := recovery.New(recovery.Options{
+		Out: &bytes.Buffer{},
+		Severity: func(recovered interface{}) recovery.Severity {
+			return recovery.SeverityCritical
+		},
+	})
+	r.OnPanic(New(newHub(t, tr)).Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(tr.events) != 1 || tr.events[0].Level != sentry.LevelFatal {
+		t.Errorf("Expected a fatal event - Got %v", tr.events)
+	}
+}
+
+func TestReportContextHub(t *testing.T) {
+	tr, other := &transport{}, &transport{}
+
+	r := recovery.New(recovery.Options{Out: &bytes.Buffer{}})
+	r.OnPanic(New(newHub(t, other)).Report)
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), newHub(t, tr)))
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(tr.events) != 1 || len(other.events) != 0 {
+		t.Errorf("Expected the event on the hub of the request - Got %d and %d", len(tr.events), len(other.events))
+	}
+}
@@ -0,0 +1,105 @@
+// Package recoverysns publishes the panics recovered by github.com/unrolled/recovery to an AWS SNS topic as JSON messages, fanning them out to the queues, functions, and endpoints subscribed to it for triage, storage, and alerting outside the request path.
+//
+//	package main
+//
+//	import (
+//	    "context"
+//	    "net/http"
+//
+//	    "github.com/aws/aws-sdk-go-v2/config"
+//	    "github.com/aws/aws-sdk-go-v2/service/sns"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverysns"
+//	)
+//
+//	func main() {
+//	    cfg, _ := config.LoadDefaultConfig(context.Background())
+//	    w := recoverysns.New(sns.NewFromConfig(cfg), "arn:aws:sns:us-east-1:123456789012:panics")
+//
+//	    r := recovery.New(recovery.Options{
+//	        Sinks:        []recovery.Sink{{Format: recovery.FormatText}, {Out: w, Format: recovery.FormatJSON}},
+//	        AsyncLogging: true,
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverysns
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// publishTimeout bounds the publishing of one message.
+const publishTimeout = 10 * time.Second
+
+// API is the part of *sns.Client a Writer uses.
+type API interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// Writer publishes each write, a FormatJSON record, to an SNS topic as one message, with the fingerprint and severity of the panic as the fingerprint and severity message attributes, for subscription filter policies to match on. On FIFO topics, the fingerprint is the message group, so the occurrences of a bug are kept in order, and the hash of the record the deduplication id.
+type Writer struct {
+	client   API
+	topicARN string
+	fifo     bool
+}
+
+// New returns a Writer publishing to the topic with the given ARN. Topics whose name ends in ".fifo" are FIFO topics.
+func New(client API, topicARN string) *Writer {
+	return &Writer{client: client, topicARN: topicARN, fifo: strings.HasSuffix(topicARN, ".fifo")}
+}
+
+// Write publishes p, without its trailing newline. The attributes are left out for records without the fields, and FIFO messages without a fingerprint share the group "recovery".
+func (w *Writer) Write(p []byte) (int, error) {
+	body := string(bytes.TrimSuffix(p, []byte("\n")))
+
+	input := &sns.PublishInput{
+		TopicArn:          aws.String(w.topicARN),
+		Message:           aws.String(body),
+		MessageAttributes: map[string]types.MessageAttributeValue{},
+	}
+
+	var rec struct {
+		Fingerprint string `json:"fingerprint"`
+		Level       string `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(body), &rec); err == nil {
+		for name, value := range map[string]string{
+			"fingerprint": rec.Fingerprint,
+			"severity":    rec.Level,
+		} {
+			if len(value) > 0 {
+				input.MessageAttributes[name] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+			}
+		}
+	}
+
+	if w.fifo {
+		group := rec.Fingerprint
+		if len(group) == 0 {
+			group = "recovery"
+		}
+		sum := sha256.Sum256([]byte(body))
+		input.MessageGroupId = aws.String(group)
+		input.MessageDeduplicationId = aws.String(hex.EncodeToString(sum[:]))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	if _, err := w.client.Publish(ctx, input); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
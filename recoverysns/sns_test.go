@@ -0,0 +1,93 @@
+package recoverysns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+// client keeps the messages it is given.
+type client struct {
+	err   error
+	sends []*sns.PublishInput
+}
+
+func (c *client) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.sends = append(c.sends, params)
+	return &sns.PublishOutput{}, nil
+}
+
+func TestWriter(t *testing.T) {
+	c := &client{}
+
+	r := recovery.New(recovery.Options{
+		Sinks: []recovery.Sink{{Out: New(c, "arn:aws:sns:us-east-1:123456789012:panics"), Format: recovery.FormatJSON}},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(c.sends) != 1 {
+		t.Fatalf("Expected 1 message - Got %d", len(c.sends))
+	}
+
+	input := c.sends[0]
+	if *input.TopicArn != "arn:aws:sns:us-east-1:123456789012:panics" {
+		t.Errorf("Expected the topic ARN - Got [%s]", *input.TopicArn)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(*input.Message), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["panic"] != "this did not work" {
+		t.Errorf("Expected the panic in the message - Got %v", doc["panic"])
+	}
+	if *input.MessageAttributes["fingerprint"].StringValue != doc["fingerprint"] {
+		t.Errorf("Expected the fingerprint attribute - Got %v", input.MessageAttributes["fingerprint"])
+	}
+	if *input.MessageAttributes["severity"].StringValue != "error" {
+		t.Errorf("Expected the severity attribute - Got %v", input.MessageAttributes["severity"])
+	}
+	if input.MessageGroupId != nil || input.MessageDeduplicationId != nil {
+		t.Errorf("Expected no group or deduplication id on a standard topic")
+	}
+}
+
+func TestWriterFIFO(t *testing.T) {
+	c := &client{}
+	w := New(c, "arn:aws:sns:us-east-1:123456789012:panics.fifo")
+
+	w.Write([]byte(`{"fingerprint":"abc","panic":"one"}` + "\n"))
+	w.Write([]byte(`{"fingerprint":"abc","panic":"two"}` + "\n"))
+	w.Write([]byte("{}\n"))
+
+	if *c.sends[0].MessageGroupId != "abc" || *c.sends[2].MessageGroupId != "recovery" {
+		t.Errorf("Expected the fingerprint as group - Got [%s] and [%s]", *c.sends[0].MessageGroupId, *c.sends[2].MessageGroupId)
+	}
+	if *c.sends[0].MessageDeduplicationId == *c.sends[1].MessageDeduplicationId {
+		t.Errorf("Expected distinct deduplication ids - Got [%s]", *c.sends[0].MessageDeduplicationId)
+	}
+}
+
+func TestWriterError(t *testing.T) {
+	c := &client{err: errors.New("topic gone")}
+
+	if _, err := New(c, "arn:aws:sns:us-east-1:123456789012:t").Write([]byte("{}\n")); err == nil || err.Error() != "topic gone" {
+		t.Errorf("Expected the error of the client - Got %v", err)
+	}
+}
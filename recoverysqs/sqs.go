@@ -0,0 +1,105 @@
+// Package recoverysqs enqueues the panics recovered by github.com/unrolled/recovery on an AWS SQS queue as JSON messages, for consumers to triage, store, and alert on outside the request path.
+//
+//	package main
+//
+//	import (
+//	    "context"
+//	    "net/http"
+//
+//	    "github.com/aws/aws-sdk-go-v2/config"
+//	    "github.com/aws/aws-sdk-go-v2/service/sqs"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoverysqs"
+//	)
+//
+//	func main() {
+//	    cfg, _ := config.LoadDefaultConfig(context.Background())
+//	    w := recoverysqs.New(sqs.NewFromConfig(cfg), "https://sqs.us-east-1.amazonaws.com/123456789012/panics")
+//
+//	    r := recovery.New(recovery.Options{
+//	        Sinks:        []recovery.Sink{{Format: recovery.FormatText}, {Out: w, Format: recovery.FormatJSON}},
+//	        AsyncLogging: true,
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoverysqs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// sendTimeout bounds the sending of one message.
+const sendTimeout = 10 * time.Second
+
+// API is the part of *sqs.Client a Writer uses.
+type API interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// Writer sends each write, a FormatJSON record, to an SQS queue as one message, with the fingerprint and severity of the panic as the fingerprint and severity message attributes. On FIFO queues, the fingerprint is the message group, so the occurrences of a bug are kept in order, and the hash of the record the deduplication id.
+type Writer struct {
+	client   API
+	queueURL string
+	fifo     bool
+}
+
+// New returns a Writer sending to the queue with the given URL. Queues whose name ends in ".fifo" are FIFO queues.
+func New(client API, queueURL string) *Writer {
+	return &Writer{client: client, queueURL: queueURL, fifo: strings.HasSuffix(queueURL, ".fifo")}
+}
+
+// Write sends p, without its trailing newline. The attributes are left out for records without the fields, and FIFO messages without a fingerprint share the group "recovery".
+func (w *Writer) Write(p []byte) (int, error) {
+	body := string(bytes.TrimSuffix(p, []byte("\n")))
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(w.queueURL),
+		MessageBody:       aws.String(body),
+		MessageAttributes: map[string]types.MessageAttributeValue{},
+	}
+
+	var rec struct {
+		Fingerprint string `json:"fingerprint"`
+		Level       string `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(body), &rec); err == nil {
+		for name, value := range map[string]string{
+			"fingerprint": rec.Fingerprint,
+			"severity":    rec.Level,
+		} {
+			if len(value) > 0 {
+				input.MessageAttributes[name] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+			}
+		}
+	}
+
+	if w.fifo {
+		group := rec.Fingerprint
+		if len(group) == 0 {
+			group = "recovery"
+		}
+		sum := sha256.Sum256([]byte(body))
+		input.MessageGroupId = aws.String(group)
+		input.MessageDeduplicationId = aws.String(hex.EncodeToString(sum[:]))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	if _, err := w.client.SendMessage(ctx, input); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,12 @@
+module github.com/unrolled/recovery/recoveryzap
+
+go 1.21
+
+require (
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+	go.uber.org/zap v1.28.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/unrolled/recovery => ../
@@ -0,0 +1,105 @@
+// Package recoveryzap logs the panics recovered by github.com/unrolled/recovery through a *zap.Logger.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoveryzap"
+//	    "go.uber.org/zap"
+//	)
+//
+//	func main() {
+//	    logger, _ := zap.NewProduction()
+//	    defer logger.Sync()
+//
+//	    r := recovery.New(recovery.Options{
+//	        Logger: recoveryzap.New(logger),
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoveryzap
+
+import (
+	"fmt"
+
+	"github.com/unrolled/recovery"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StacktraceKey is the field holding the stack dump. It matches the key zap uses for its own stack traces, so panics show up like any other zap error.
+const StacktraceKey = "stacktrace"
+
+// Logger adapts a *zap.Logger to recovery.Options.Logger. Panics are logged at the level matching their severity with their details as fields, and notes at warning level.
+type Logger struct {
+	zap *zap.Logger
+}
+
+// New returns a Logger writing to the given zap logger.
+func New(logger *zap.Logger) *Logger {
+	return &Logger{zap: logger}
+}
+
+// Printf logs a note about how a panic was handled.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.zap.Warn(fmt.Sprintf(format, v...))
+}
+
+// LogPanic logs a recovered panic with the panic, stacktrace, method, path, status, and fingerprint fields, plus the origin of the panic, the request_id with IncludeRequestID, the trace_id and span_id of a traced request, the count of aggregated entries and the url, proto, remote_addr, route, headers, and body of the request when they were logged.
+func (l *Logger) LogPanic(rec *recovery.PanicRecord) {
+	fields := []zap.Field{
+		zap.String("panic", rec.Panic),
+		zap.String(StacktraceKey, string(rec.Stack)),
+		zap.String("method", rec.Method),
+		zap.String("path", rec.Path),
+		zap.Int("status", rec.Status),
+		zap.String("fingerprint", rec.Fingerprint),
+	}
+	if len(rec.Origin) > 0 {
+		fields = append(fields, zap.String("origin", rec.Origin))
+	}
+	if len(rec.RequestID) > 0 {
+		fields = append(fields, zap.String("request_id", rec.RequestID))
+	}
+	if len(rec.TraceID) > 0 {
+		fields = append(fields, zap.String("trace_id", rec.TraceID), zap.String("span_id", rec.SpanID))
+	}
+	if rec.Count > 0 {
+		fields = append(fields, zap.Int("count", rec.Count))
+	}
+	if len(rec.URL) > 0 {
+		fields = append(fields,
+			zap.String("url", rec.URL),
+			zap.String("proto", rec.Proto),
+			zap.String("remote_addr", rec.RemoteAddr),
+		)
+		if len(rec.Route) > 0 {
+			fields = append(fields, zap.String("route", rec.Route))
+		}
+	}
+	if len(rec.Headers) > 0 {
+		fields = append(fields, zap.Any("headers", rec.Headers))
+	}
+	if len(rec.Body) > 0 {
+		fields = append(fields, zap.ByteString("body", rec.Body))
+	}
+
+	l.zap.Log(level(rec.Severity), rec.Message, fields...)
+}
+
+// level maps a severity to a zap level. Critical panics log at error level too, since the levels above it panic or exit.
+func level(severity recovery.Severity) zapcore.Level {
+	switch severity {
+	case recovery.SeverityDebug:
+		return zapcore.DebugLevel
+	case recovery.SeverityInfo:
+		return zapcore.InfoLevel
+	case recovery.SeverityWarning:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
@@ -0,0 +1,156 @@
+package recoveryzap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/unrolled/recovery"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+func TestLogPanic(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	r := recovery.New(recovery.Options{
+		Logger:           New(zap.New(core)),
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 - Got %d", res.Code)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry - Got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != zapcore.ErrorLevel {
+		t.Errorf("Expected error level - Got %v", entry.Level)
+	}
+	if entry.Message != "Recovering from Panic" {
+		t.Errorf("Expected [Recovering from Panic] - Got [%s]", entry.Message)
+	}
+
+	fields := entry.ContextMap()
+	expected := map[string]string{
+		"panic":      "this did not work",
+		"method":     "GET",
+		"path":       "/foo",
+		"request_id": "abc123",
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("Expected %s [%s] - Got [%v]", key, value, fields[key])
+		}
+	}
+
+	if fields["status"] != int64(http.StatusInternalServerError) {
+		t.Errorf("Expected status [500] - Got [%v]", fields["status"])
+	}
+
+	if stack, _ := fields[StacktraceKey].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("Expected a stack dump - Got [%s]", stack)
+	}
+
+	if origin, _ := fields["origin"].(string); !strings.Contains(origin, "recoveryzap/zap_test.go:") {
+		t.Errorf("Expected the origin in the test file - Got [%s]", origin)
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	New(zap.New(core)).Printf("skipped %d", 1)
+
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel || entries[0].Message != "skipped 1" {
+		t.Errorf("Expected a warning [skipped 1] - Got %v", entries)
+	}
+}
+
+func TestSeverityLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	r := recovery.New(recovery.Options{
+		Logger: New(zap.New(core)),
+		Severity: func(recovered interface{}) recovery.Severity {
+			return recovery.SeverityWarning
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if entries := logs.All(); len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+		t.Errorf("Expected a warning entry - Got %v", entries)
+	}
+}
+
+func TestRequestDetails(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	r := recovery.New(recovery.Options{
+		Logger:            New(zap.New(core)),
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo?a=1", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	fields := logs.All()[0].ContextMap()
+	if fields["url"] != "/foo?a=1" || fields["proto"] != "HTTP/1.1" {
+		t.Errorf("Expected the request details - Got %v", fields)
+	}
+	if headers, _ := fields["headers"].(map[string]string); headers["User-Agent"] != "curl/8.0" {
+		t.Errorf("Expected the User-Agent header - Got %v", fields["headers"])
+	}
+}
+
+func TestRequestIDOmitted(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	r := recovery.New(recovery.Options{
+		Logger: New(zap.New(core)),
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, ok := logs.All()[0].ContextMap()["request_id"]; ok {
+		t.Errorf("Expected no request_id without IncludeRequestID - Got %v", logs.All()[0].ContextMap())
+	}
+}
+
+func TestTraceFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	r := recovery.New(recovery.Options{
+		Logger: New(zap.New(core)),
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	fields := logs.All()[0].ContextMap()
+	if fields["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || fields["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("Expected the trace fields - Got %v", fields)
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/unrolled/recovery/recoveryzerolog
+
+go 1.21
+
+require (
+	github.com/rs/zerolog v1.33.0
+	github.com/unrolled/recovery v0.0.0-20261014111837-7878624c1445
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	golang.org/x/sys v0.12.0 // indirect
+)
+
+replace github.com/unrolled/recovery => ../
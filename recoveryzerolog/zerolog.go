@@ -0,0 +1,111 @@
+// Package recoveryzerolog logs the panics recovered by github.com/unrolled/recovery as zerolog events.
+//
+//	package main
+//
+//	import (
+//	    "net/http"
+//	    "os"
+//
+//	    "github.com/rs/zerolog"
+//	    "github.com/unrolled/recovery"
+//	    "github.com/unrolled/recovery/recoveryzerolog"
+//	)
+//
+//	func main() {
+//	    logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+//
+//	    r := recovery.New(recovery.Options{
+//	        Logger: recoveryzerolog.New(logger),
+//	    })
+//
+//	    http.ListenAndServe("127.0.0.1:3000", r.Handler(http.DefaultServeMux))
+//	}
+package recoveryzerolog
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/recovery"
+)
+
+// Logger adapts a zerolog.Logger to recovery.Options.Logger. Panics are logged at the level matching their severity with their details as fields, and notes at warning level.
+type Logger struct {
+	zerolog zerolog.Logger
+}
+
+// New returns a Logger writing to the given zerolog logger. Its context fields, timestamp, and level settings apply as usual.
+func New(logger zerolog.Logger) *Logger {
+	return &Logger{zerolog: logger}
+}
+
+// Printf logs a note about how a panic was handled.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.zerolog.Warn().Msgf(format, v...)
+}
+
+// LogPanic logs a recovered panic with the panic, stack, method, path, status, and fingerprint fields, plus the origin of the panic, the request_id with IncludeRequestID, the trace_id and span_id of a traced request, the count of aggregated entries and the url, proto, remote_addr, route, headers, and body of the request when they were logged.
+func (l *Logger) LogPanic(rec *recovery.PanicRecord) {
+	event := l.zerolog.WithLevel(level(rec.Severity)).
+		Str("panic", rec.Panic).
+		Bytes("stack", rec.Stack).
+		Str("method", rec.Method).
+		Str("path", rec.Path).
+		Int("status", rec.Status).
+		Str("fingerprint", rec.Fingerprint)
+	if len(rec.Origin) > 0 {
+		event = event.Str("origin", rec.Origin)
+	}
+	if len(rec.RequestID) > 0 {
+		event = event.Str("request_id", rec.RequestID)
+	}
+	if len(rec.TraceID) > 0 {
+		event = event.Str("trace_id", rec.TraceID).Str("span_id", rec.SpanID)
+	}
+	if rec.Count > 0 {
+		event = event.Int("count", rec.Count)
+	}
+	if len(rec.URL) > 0 {
+		event = event.
+			Str("url", rec.URL).
+			Str("proto", rec.Proto).
+			Str("remote_addr", rec.RemoteAddr)
+		if len(rec.Route) > 0 {
+			event = event.Str("route", rec.Route)
+		}
+	}
+	if len(rec.Headers) > 0 {
+		names := make([]string, 0, len(rec.Headers))
+		for name := range rec.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		headers := zerolog.Dict()
+		for _, name := range names {
+			headers = headers.Str(name, rec.Headers[name])
+		}
+		event = event.Dict("headers", headers)
+	}
+	if len(rec.Body) > 0 {
+		event = event.Bytes("body", rec.Body)
+	}
+
+	event.Msg(rec.Message)
+}
+
+// level maps a severity to a zerolog level. Critical panics log at fatal level, which WithLevel writes without exiting.
+func level(severity recovery.Severity) zerolog.Level {
+	switch severity {
+	case recovery.SeverityDebug:
+		return zerolog.DebugLevel
+	case recovery.SeverityInfo:
+		return zerolog.InfoLevel
+	case recovery.SeverityWarning:
+		return zerolog.WarnLevel
+	case recovery.SeverityCritical:
+		return zerolog.FatalLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
@@ -0,0 +1,141 @@
+package recoveryzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/unrolled/recovery"
+)
+
+var myPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("this did not work")
+})
+
+func TestLogPanic(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := recovery.New(recovery.Options{
+		Logger:           New(zerolog.New(buf).With().Str("service", "api").Logger()),
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Fatalf("Expected a single event - Got [%s]", buf.String())
+	}
+
+	var event map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("Expected a JSON event - Got [%s]: %v", buf.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"level":      "error",
+		"message":    "Recovering from Panic",
+		"service":    "api",
+		"panic":      "this did not work",
+		"method":     "GET",
+		"path":       "/foo",
+		"status":     float64(http.StatusInternalServerError),
+		"request_id": "abc123",
+	}
+	for key, value := range expected {
+		if event[key] != value {
+			t.Errorf("Expected %s [%v] - Got [%v]", key, value, event[key])
+		}
+	}
+
+	if stack, _ := event["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("Expected a stack dump - Got [%s]", stack)
+	}
+
+	if origin, _ := event["origin"].(string); !strings.Contains(origin, "recoveryzerolog/zerolog_test.go:") {
+		t.Errorf("Expected the origin in the test file - Got [%s]", origin)
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	New(zerolog.New(buf)).Printf("skipped %d", 1)
+
+	if buf.String() != `{"level":"warn","message":"skipped 1"}`+"\n" {
+		t.Errorf("Expected a warning [skipped 1] - Got [%s]", buf.String())
+	}
+}
+
+func TestSeverityLevel(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := recovery.New(recovery.Options{
+		Logger: New(zerolog.New(buf)),
+		Severity: func(recovered interface{}) recovery.Severity {
+			return recovery.SeverityCritical
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.HasPrefix(buf.String(), `{"level":"fatal",`) {
+		t.Errorf("Expected a fatal event - Got [%s]", buf.String())
+	}
+}
+
+func TestRequestDetails(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := recovery.New(recovery.Options{
+		Logger:            New(zerolog.New(buf)),
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo?a=1", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), `"url":"/foo?a=1","proto":"HTTP/1.1",`) || !strings.Contains(buf.String(), `"headers":{"User-Agent":"curl/8.0"}`) {
+		t.Errorf("Expected the request details - Got [%s]", buf.String())
+	}
+}
+
+func TestRequestIDOmitted(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := recovery.New(recovery.Options{
+		Logger: New(zerolog.New(buf)),
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(buf.String(), `"request_id"`) {
+		t.Errorf("Expected no request_id without IncludeRequestID - Got [%s]", buf.String())
+	}
+}
+
+func TestTraceFields(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := recovery.New(recovery.Options{
+		Logger: New(zerolog.New(buf)),
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"`) {
+		t.Errorf("Expected the trace fields - Got [%s]", buf.String())
+	}
+}
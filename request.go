@@ -0,0 +1,91 @@
+package recovery
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// redactedValue replaces the value of a redacted header.
+const redactedValue = "[REDACTED]"
+
+// defaultRedactHeaders are the credential headers redacted unless RedactHeaders says otherwise.
+var defaultRedactHeaders = []string{"Authorization", "Cookie", "X-Api-Key"}
+
+// headerValue returns the values of a request header as logged, joined with commas and scrubbed, or redacted when RedactHeaders lists it.
+func (r *Recovery) headerValue(name string, values []string) string {
+	name = http.CanonicalHeaderKey(name)
+	for _, redacted := range r.opt.RedactHeaders {
+		if name == redacted {
+			return redactedValue
+		}
+	}
+
+	return r.scrubText(strings.Join(values, ", "))
+}
+
+// route returns the route pattern that matched the request, from RoutePattern or the http.ServeMux.
+func (r *Recovery) route(req *http.Request) string {
+	if r.opt.RoutePattern != nil {
+		return r.opt.RoutePattern(req)
+	}
+	return ServeMuxPattern(req)
+}
+
+// requestDetails fills in the request metadata asked for by LogRequestDetails and LogHeaders.
+func (r *Recovery) requestDetails(rec *PanicRecord, req *http.Request) {
+	if r.opt.LogRequestDetails {
+		rec.URL = r.scrubURL(req.URL)
+		rec.Proto = req.Proto
+		rec.RemoteAddr = req.RemoteAddr
+		rec.Route = r.route(req)
+	}
+
+	for _, name := range r.opt.LogHeaders {
+		values := req.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		if rec.Headers == nil {
+			rec.Headers = make(map[string]string, len(r.opt.LogHeaders))
+		}
+		rec.Headers[http.CanonicalHeaderKey(name)] = r.headerValue(name, values)
+	}
+}
+
+// headerNames returns the names of the logged headers, sorted so records are stable.
+func (rec *PanicRecord) headerNames() []string {
+	names := make([]string, 0, len(rec.Headers))
+	for name := range rec.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// requestText renders the request metadata for FormatText records, one line for the request itself, one per header, and one for the captured body.
+func (rec *PanicRecord) requestText() string {
+	var b strings.Builder
+
+	if len(rec.URL) > 0 {
+		b.WriteString("Request: " + rec.Method + " " + rec.URL + " " + rec.Proto + " from " + rec.RemoteAddr)
+		if len(rec.Route) > 0 {
+			b.WriteString(" (route " + rec.Route + ")")
+		}
+		b.WriteByte('\n')
+	}
+
+	for _, name := range rec.headerNames() {
+		b.WriteString(name + ": " + rec.Headers[name] + "\n")
+	}
+
+	// The body is quoted, as payloads can span lines or hold binary data.
+	if len(rec.Body) > 0 {
+		b.WriteString("Body: " + strconv.Quote(string(rec.Body)) + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogRequestDetails(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:               buf,
+		OutputFlags:       -1,
+		LogRequestDetails: true,
+		LogHeaders:        []string{"user-agent", "Referer", "X-Missing"},
+		RoutePattern: func(req *http.Request) string {
+			return "/items/{id}"
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/items/42?verbose=1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Add("Referer", "https://example.com/a")
+	req.Header.Add("Referer", "https://example.com/b")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, strings.HasPrefix(buf.String(), "Recovering from Panic: this did not work\n"+
+		"Request: GET /items/42?verbose=1 HTTP/1.1 from 10.0.0.1:1234 (route /items/{id})\n"+
+		"Referer: https://example.com/a, https://example.com/b\n"+
+		"User-Agent: curl/8.0\n"+
+		"goroutine "), true)
+	expectContainsFalse(t, buf.String(), "X-Missing")
+}
+
+func TestLogRequestDetailsJSON(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:               buf,
+		Format:            FormatJSON,
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/items/42?verbose=1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a JSON record - Got [%s]: %v", buf.String(), err)
+	}
+
+	expect(t, doc["url"], interface{}("/items/42?verbose=1"))
+	expect(t, doc["proto"], interface{}("HTTP/1.1"))
+	expect(t, doc["remote_addr"], interface{}("10.0.0.1:1234"))
+	expect(t, doc["headers"].(map[string]interface{})["User-Agent"], interface{}("curl/8.0"))
+	_, hasRoute := doc["route"]
+	expect(t, hasRoute, false)
+}
+
+func TestLogRequestDetailsLogfmt(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:               buf,
+		Format:            FormatLogfmt,
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), " method=GET path=/foo url=/foo proto=HTTP/1.1 remote_addr=10.0.0.1:1234 header.user-agent=curl/8.0 panic=")
+}
+
+func TestLogRequestDetailsSlog(t *testing.T) {
+	slogOut := bytes.NewBufferString("")
+	r := New(Options{
+		Slog:              slog.New(slog.NewTextHandler(slogOut, nil)),
+		LogRequestDetails: true,
+		LogHeaders:        []string{"User-Agent"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, slogOut.String(), "url=/foo proto=HTTP/1.1 remote_addr=10.0.0.1:1234 headers.User-Agent=curl/8.0")
+}
+
+func TestRequestDetailsOffByDefault(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:    buf,
+		Format: FormatJSON,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), `"url"`)
+	expectContainsFalse(t, buf.String(), "curl")
+}
+
+func TestRedactHeaders(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:        buf,
+		Format:     FormatJSON,
+		LogHeaders: []string{"Authorization", "cookie", "X-Api-Key", "User-Agent"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "session=secret-session")
+	req.Header.Set("X-Api-Key", "secret-key")
+	req.Header.Set("User-Agent", "curl/8.0")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "secret")
+	expectContainsTrue(t, buf.String(), `"Authorization":"[REDACTED]"`)
+	expectContainsTrue(t, buf.String(), `"Cookie":"[REDACTED]"`)
+	expectContainsTrue(t, buf.String(), `"X-Api-Key":"[REDACTED]"`)
+	expectContainsTrue(t, buf.String(), `"User-Agent":"curl/8.0"`)
+}
+
+func TestRedactHeadersCustom(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:           buf,
+		OutputFlags:   -1,
+		LogHeaders:    []string{"Authorization", "X-Session"},
+		RedactHeaders: []string{"x-session"},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Authorization", "Basic dXNlcg==")
+	req.Header.Set("X-Session", "abc")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "Authorization: Basic dXNlcg==\n")
+	expectContainsTrue(t, buf.String(), "X-Session: [REDACTED]\n")
+}
+
+func TestRedactHeadersDevelopmentPage(t *testing.T) {
+	r := New(Options{
+		Out:         bytes.NewBufferString(""),
+		Development: true,
+	})
+
+	// The page shows the source of this test, so the cookie must not appear in it verbatim.
+	cookie := "session=" + "abc123"
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Cookie", cookie)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, res.Body.String(), cookie)
+	expectContainsTrue(t, res.Body.String(), "[REDACTED]")
+}
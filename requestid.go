@@ -0,0 +1,64 @@
+package recovery
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// NewRequestIDContext returns a copy of ctx carrying a request id. Recovery logs and answers with this id, so any middleware that stores its id here, like an access logger, is correlated with the panic records.
+func NewRequestIDContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request id stored by NewRequestIDContext or RequestIDHandler.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && len(id) > 0
+}
+
+// RequestIDHandler assigns every request an id before the rest of the chain runs: the one in the RequestIDHeader, or a generated one. The id is stored on the request context (see RequestIDFromContext), set as the RequestIDHeader of the request for middlewares reading it from there, and echoed in the response header. Put it first in the chain, before the access logger and Recovery, so they all emit the same id.
+func (r *Recovery) RequestIDHandler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(r.opt.RequestIDHeader)
+		if len(id) == 0 {
+			id = newRequestID()
+			req.Header.Set(r.opt.RequestIDHeader, id)
+		}
+		w.Header().Set(r.opt.RequestIDHeader, id)
+
+		next.ServeHTTP(w, req.WithContext(NewRequestIDContext(req.Context(), id)))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// requestID returns the id of req: from RequestIDFunc when set, the request context, the RequestIDHeader the client or a proxy sent, or a freshly generated one.
+func (r *Recovery) requestID(req *http.Request) string {
+	if r.opt.RequestIDFunc != nil {
+		if id := r.opt.RequestIDFunc(req); len(id) > 0 {
+			return id
+		}
+	}
+
+	if id, ok := RequestIDFromContext(req.Context()); ok {
+		return id
+	}
+
+	if id := req.Header.Get(r.opt.RequestIDHeader); len(id) > 0 {
+		return id
+	}
+
+	return newRequestID()
+}
+
+// newRequestID returns a random 128-bit id in hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
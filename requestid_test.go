@@ -0,0 +1,184 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestIncludeRequestID(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:              buf,
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "req-1234")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get("X-Request-ID"), "req-1234")
+	expect(t, res.Body.String(), "Internal Server Error\nRequest ID: req-1234\n")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic (request_id=req-1234): this did not work")
+}
+
+func TestIncludeRequestIDGenerated(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:              buf,
+		RequestIDHeader:  "X-Correlation-ID",
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "ignored")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	id := res.Header().Get("X-Correlation-ID")
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Fatalf("Expected a generated request id, got [%s]", id)
+	}
+	expectContainsTrue(t, res.Body.String(), "Request ID: "+id)
+	expectContainsTrue(t, buf.String(), "(request_id="+id+")")
+}
+
+func TestIncludeRequestIDFormats(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:                buf,
+		Formats:            []LogFormat{FormatJSON},
+		ContentNegotiation: true,
+		IncludeRequestID:   true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Request-ID", "req-1234")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var body map[string]string
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON body, got error %v for [%s]", err, res.Body.String())
+	}
+	expect(t, body["request_id"], "req-1234")
+
+	var rec jsonRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected a JSON record, got error %v for [%s]", err, buf.String())
+	}
+	expect(t, rec.RequestID, "req-1234")
+}
+
+func TestRequestIDNotIncludedByDefault(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "req-1234")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Header().Get("X-Request-ID"), "")
+	expectContainsFalse(t, res.Body.String(), "req-1234")
+	expectContainsFalse(t, buf.String(), "req-1234")
+}
+
+func TestRequestIDHandler(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:              buf,
+		IncludeRequestID: true,
+	})
+
+	// An access logger further down the chain sees the same id.
+	var loggedID, headerID string
+	accessLogger := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req)
+			loggedID, _ = RequestIDFromContext(req.Context())
+			headerID = req.Header.Get("X-Request-ID")
+		})
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.RequestIDHandler(accessLogger(r.Handler(myPanicHandler))).ServeHTTP(res, req)
+
+	id := res.Header().Get("X-Request-ID")
+	expect(t, len(id), 32)
+	expect(t, loggedID, id)
+	expect(t, headerID, id)
+	expectContainsTrue(t, buf.String(), "(request_id="+id+")")
+}
+
+func TestRequestIDHandlerKeepsHeader(t *testing.T) {
+	r := New()
+
+	var id string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id, _ = RequestIDFromContext(req.Context())
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "req-1234")
+	r.RequestIDHandler(handler).ServeHTTP(res, req)
+
+	expect(t, id, "req-1234")
+	expect(t, res.Header().Get("X-Request-ID"), "req-1234")
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:              buf,
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "from-header")
+	req = req.WithContext(NewRequestIDContext(req.Context(), "from-context"))
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "(request_id=from-context)")
+
+	_, ok := RequestIDFromContext(NewRequestIDContext(req.Context(), ""))
+	expect(t, ok, false)
+}
+
+func TestRequestIDFunc(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:              buf,
+		IncludeRequestID: true,
+		RequestIDFunc: func(req *http.Request) string {
+			return req.Header.Get("Traceparent")
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "from-header")
+	req.Header.Set("Traceparent", "00-trace-01")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, buf.String(), "(request_id=00-trace-01)")
+
+	buf.Reset()
+	req.Header.Del("Traceparent")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsTrue(t, buf.String(), "(request_id=from-header)")
+}
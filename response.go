@@ -0,0 +1,256 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	mimeText = "text/plain"
+	mimeJSON = "application/json"
+	mimeHTML = "text/html"
+	mimeXML  = "application/xml"
+
+	// mimeTextXML is an older alias of application/xml that legacy clients still send.
+	mimeTextXML = "text/xml"
+)
+
+// defaultOffers are the content types the default panic handler can produce, in order of preference when the client has none.
+var defaultOffers = []string{mimeText, mimeJSON, mimeHTML, mimeXML, mimeTextXML}
+
+// xmlError is the document written for XML clients.
+type xmlError struct {
+	XMLName   xml.Name
+	Status    int    `xml:"status"`
+	Message   string `xml:"message"`
+	RequestID string `xml:"request_id,omitempty"`
+}
+
+// ErrorPageData is the data Options.ErrorTemplate is executed with.
+type ErrorPageData struct {
+	// Status is the response status code.
+	Status int
+	// StatusText is the standard text for Status.
+	StatusText string
+	// Message is the error message, localized when LocalizedMessages has a match.
+	Message string
+	// Path is the request path.
+	Path string
+	// CorrelationID is the request id of the panic, for users to quote to support.
+	CorrelationID string
+	// Panic is the recovered value. Only set with ErrorTemplateDetails, and never in Production mode.
+	Panic string
+	// Stack is the stack dump. Only set with ErrorTemplateDetails, and never in Production mode.
+	Stack string
+}
+
+// defaultPanicHandler writes the standard error response.
+func (r *Recovery) defaultPanicHandler(w http.ResponseWriter, req *http.Request, v interface{}, stack []byte) {
+	r.writeError(w, req, r.opt.ResponseStatus, "", v, stack)
+}
+
+// writeError writes the error response of the default panic handler with status, and message as the error message. A blank message is the one of LocalizedMessages for the client, or the status text.
+func (r *Recovery) writeError(w http.ResponseWriter, req *http.Request, status int, message string, v interface{}, stack []byte) {
+	lang := ""
+	if len(message) == 0 {
+		message, lang = r.localizedMessage(req, status)
+	}
+
+	for name, values := range r.opt.ResponseHeaders {
+		w.Header()[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	if len(lang) > 0 {
+		w.Header().Set("Content-Language", lang)
+	}
+
+	requestID := ""
+	if info, ok := FromContext(req.Context()); ok && r.opt.IncludeRequestID {
+		requestID = info.RequestID
+		w.Header().Set(r.opt.RequestIDHeader, requestID)
+	}
+
+	contentType := mimeText
+	if r.opt.ContentNegotiation {
+		contentType = negotiateContentType(req.Header.Get("Accept"), defaultOffers)
+	} else if r.opt.ErrorTemplate != nil || r.development() {
+		contentType = mimeHTML
+	}
+
+	switch contentType {
+	case mimeJSON:
+		doc := map[string]string{"error": message}
+		if len(requestID) > 0 {
+			doc["request_id"] = requestID
+		}
+		body, _ := json.Marshal(doc)
+		writeResponse(w, status, mimeJSON+"; charset=utf-8", append(body, '\n'))
+	case mimeXML, mimeTextXML:
+		body, _ := xml.Marshal(xmlError{
+			XMLName:   xml.Name{Local: r.opt.XMLRootName},
+			Status:    status,
+			Message:   message,
+			RequestID: requestID,
+		})
+		writeResponse(w, status, contentType+"; charset=utf-8", append(append([]byte(xml.Header), body...), '\n'))
+	case mimeHTML:
+		if r.development() {
+			writeResponse(w, status, mimeHTML+"; charset=utf-8", r.developmentPage(req, status, v, stack))
+		} else {
+			writeResponse(w, status, mimeHTML+"; charset=utf-8", r.errorPage(req, status, message, requestID, v, stack))
+		}
+	default:
+		if len(requestID) > 0 {
+			message += "\nRequest ID: " + requestID
+		}
+		http.Error(w, message, status)
+	}
+}
+
+// errorPage renders the HTML error page, through ErrorTemplate when one is set.
+func (r *Recovery) errorPage(req *http.Request, status int, message, requestID string, v interface{}, stack []byte) []byte {
+	if r.opt.ErrorTemplate != nil {
+		data := ErrorPageData{
+			Status:     status,
+			StatusText: http.StatusText(status),
+			Message:    message,
+			Path:       req.URL.Path,
+		}
+		if info, ok := FromContext(req.Context()); ok {
+			data.CorrelationID = info.RequestID
+		}
+		if r.opt.ErrorTemplateDetails && !r.opt.Production {
+			data.Panic = r.panicValue(v)
+			data.Stack = string(stack)
+		}
+
+		var buf bytes.Buffer
+		err := r.opt.ErrorTemplate.Execute(&buf, data)
+		if err == nil {
+			return buf.Bytes()
+		}
+		r.notef("Error rendering ErrorTemplate: %s", err)
+	}
+
+	title := strconv.Itoa(status) + " " + html.EscapeString(message)
+	body := "<h1>" + html.EscapeString(message) + "</h1>"
+	if len(requestID) > 0 {
+		body += "<p>Request ID: " + html.EscapeString(requestID) + "</p>"
+	}
+
+	return []byte("<!DOCTYPE html>\n<html><head><title>" + title + "</title></head><body>" + body + "</body></html>\n")
+}
+
+// localizedMessage returns the error message in the language the Accept-Language header ranks highest, along with that language. Without a match the standard status text is returned with a blank language.
+func (r *Recovery) localizedMessage(req *http.Request, status int) (string, string) {
+	if len(r.opt.LocalizedMessages) > 0 {
+		items := parseQualityList(req.Header.Get("Accept-Language"))
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].q > items[j].q
+		})
+
+		for _, item := range items {
+			if item.q <= 0 {
+				continue
+			}
+
+			// Try the full tag (fr-ca), then its base language (fr).
+			tag := item.value
+			if message, ok := r.opt.LocalizedMessages[tag]; ok {
+				return message, tag
+			}
+			if i := strings.IndexByte(tag, '-'); i > 0 {
+				if message, ok := r.opt.LocalizedMessages[tag[:i]]; ok {
+					return message, tag[:i]
+				}
+			}
+		}
+	}
+
+	return http.StatusText(status), ""
+}
+
+// writeResponse writes a complete error response with the given content type.
+func writeResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// qualityItem is a single entry of a header like Accept or Accept-Language.
+type qualityItem struct {
+	value string
+	q     float64
+}
+
+// parseQualityList parses a comma separated header value with optional q parameters. Malformed q values count as 1.
+func parseQualityList(header string) []qualityItem {
+	var items []qualityItem
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(params[0]))
+		if len(value) == 0 {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) > 2 && (param[0] == 'q' || param[0] == 'Q') && param[1] == '=' {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		items = append(items, qualityItem{value: value, q: q})
+	}
+
+	return items
+}
+
+// negotiateContentType picks the offer the Accept header ranks highest. Ties go to the earlier offer, and the first offer is used when nothing is acceptable, since some response beats a 406 from a crash.
+func negotiateContentType(accept string, offers []string) string {
+	items := parseQualityList(accept)
+	if len(items) == 0 {
+		return offers[0]
+	}
+
+	best, bestQ := offers[0], 0.0
+	for _, offer := range offers {
+		// The most specific matching range decides the quality of an offer.
+		q, specificity := 0.0, -1
+		for _, item := range items {
+			s := mediaRangeMatch(item.value, offer)
+			if s > specificity {
+				q, specificity = item.q, s
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+// mediaRangeMatch returns how specifically mediaRange matches offer: 2 for an exact match, 1 for type/*, 0 for */* and -1 otherwise.
+func mediaRangeMatch(mediaRange, offer string) int {
+	switch {
+	case mediaRange == offer:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, mediaRange[:len(mediaRange)-1]):
+		return 1
+	}
+
+	return -1
+}
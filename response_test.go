@@ -0,0 +1,265 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseStatus(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		ResponseStatus:     http.StatusServiceUnavailable,
+		ContentNegotiation: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusServiceUnavailable)
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusServiceUnavailable))
+
+	res = httptest.NewRecorder()
+	req.Header.Set("Accept", "application/json")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusServiceUnavailable)
+	expectContainsTrue(t, res.Body.String(), `"error":"Service Unavailable"`)
+}
+
+func TestResponseHeaders(t *testing.T) {
+	r := New(Options{
+		Out:            ioutil.Discard,
+		ResponseStatus: http.StatusServiceUnavailable,
+		ResponseHeaders: http.Header{
+			"Retry-After":   {"30"},
+			"cache-control": {"no-store"},
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusServiceUnavailable)
+	expect(t, res.Header().Get("Retry-After"), "30")
+	expect(t, res.Header().Get("Cache-Control"), "no-store")
+	expect(t, res.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		ContentNegotiation: true,
+		LocalizedMessages: map[string]string{
+			"fr":    "Erreur interne du serveur",
+			"pt-BR": "Erro interno do servidor",
+		},
+	})
+
+	cases := []struct {
+		acceptLanguage string
+		message        string
+		lang           string
+	}{
+		{"", "Internal Server Error", ""},
+		{"de", "Internal Server Error", ""},
+		{"fr", "Erreur interne du serveur", "fr"},
+		{"fr-CA,fr;q=0.9", "Erreur interne du serveur", "fr"},
+		{"pt-BR", "Erro interno do servidor", "pt-br"},
+		{"de;q=1, pt-br;q=0.8, fr;q=0.9", "Erreur interne du serveur", "fr"},
+		{"fr;q=0, en", "Internal Server Error", ""},
+	}
+
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.Header.Set("Accept-Language", c.acceptLanguage)
+		r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+		expect(t, strings.TrimSpace(res.Body.String()), c.message)
+		expect(t, res.Header().Get("Content-Language"), c.lang)
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "fr")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, res.Body.String(), `"error":"Erreur interne du serveur"`)
+}
+
+func TestContentNegotiation(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		ContentNegotiation: true,
+	})
+
+	cases := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "text/plain; charset=utf-8"},
+		{"*/*", "text/plain; charset=utf-8"},
+		{"application/json", "application/json; charset=utf-8"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html; charset=utf-8"},
+		{"text/*;q=0.5, application/json;q=0.9", "application/json; charset=utf-8"},
+		{"text/html;q=0, */*", "text/plain; charset=utf-8"},
+		{"image/png", "text/plain; charset=utf-8"},
+		{"application/xml", "application/xml; charset=utf-8"},
+		{"text/xml", "text/xml; charset=utf-8"},
+	}
+
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/foo", nil)
+		req.Header.Set("Accept", c.accept)
+		r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+		expect(t, res.Code, http.StatusInternalServerError)
+		expect(t, res.Header().Get("Content-Type"), c.contentType)
+	}
+}
+
+func TestContentNegotiationBodies(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		ContentNegotiation: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var body map[string]string
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON body, got error %v for [%s]", err, res.Body.String())
+	}
+	expect(t, body["error"], http.StatusText(http.StatusInternalServerError))
+
+	res = httptest.NewRecorder()
+	req.Header.Set("Accept", "text/html")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+	expectContainsTrue(t, res.Body.String(), "<h1>Internal Server Error</h1>")
+}
+
+func TestContentNegotiationXML(t *testing.T) {
+	r := New(Options{
+		Out:                ioutil.Discard,
+		ContentNegotiation: true,
+		XMLRootName:        "fault",
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/xml")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Body.String(), xml.Header+"<fault><status>500</status><message>Internal Server Error</message></fault>\n")
+
+	var doc struct {
+		XMLName xml.Name
+		Status  int    `xml:"status"`
+		Message string `xml:"message"`
+	}
+	if err := xml.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected an XML body, got error %v for [%s]", err, res.Body.String())
+	}
+	expect(t, doc.XMLName.Local, "fault")
+	expect(t, doc.Status, http.StatusInternalServerError)
+}
+
+func TestContentNegotiationDisabled(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusInternalServerError))
+}
+
+var testErrorTemplate = template.Must(template.New("error").Parse(
+	`<h1>{{.Status}} {{.StatusText}}</h1><p>{{.Path}}</p><p id="ref">{{.CorrelationID}}</p>{{if .Panic}}<pre>{{.Panic}}</pre><pre>{{.Stack}}</pre>{{end}}`,
+))
+
+func TestErrorTemplate(t *testing.T) {
+	r := New(Options{
+		Out:           ioutil.Discard,
+		ErrorTemplate: testErrorTemplate,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/checkout", nil)
+	req.Header.Set("X-Request-ID", "req-1234")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	expectContainsTrue(t, res.Body.String(), "<h1>500 Internal Server Error</h1><p>/checkout</p><p id=\"ref\">req-1234</p>")
+	expectContainsFalse(t, res.Body.String(), "this did not work")
+}
+
+func TestErrorTemplateGeneratesCorrelationID(t *testing.T) {
+	r := New(Options{
+		Out:           ioutil.Discard,
+		ErrorTemplate: testErrorTemplate,
+	})
+
+	var info *PanicInfo
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		info, _ = FromContext(req.Context())
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/checkout", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, len(info.RequestID), 32)
+	expectContainsTrue(t, res.Body.String(), `<p id="ref">`+info.RequestID+`</p>`)
+}
+
+func TestErrorTemplateDetails(t *testing.T) {
+	r := New(Options{
+		Out:                  ioutil.Discard,
+		ErrorTemplate:        testErrorTemplate,
+		ErrorTemplateDetails: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/checkout", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, res.Body.String(), "<pre>this did not work</pre>")
+	expectContainsTrue(t, res.Body.String(), "src/net/http/server.go")
+}
+
+func TestErrorTemplateFailure(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:           buf,
+		ErrorTemplate: template.Must(template.New("broken").Parse(`{{.Missing}}`)),
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/checkout", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expectContainsTrue(t, res.Body.String(), "<h1>Internal Server Error</h1>")
+	expectContainsTrue(t, buf.String(), "Error rendering ErrorTemplate:")
+}
@@ -0,0 +1,169 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// defaultRollbarURL is the Rollbar API.
+const defaultRollbarURL = "https://api.rollbar.com"
+
+// RollbarOptions configures a RollbarSink.
+type RollbarOptions struct {
+	// Token is a project access token with the post_server_item scope.
+	Token string
+	// Environment is the environment the items are reported in, like "production". Default is blank.
+	Environment string
+	// BaseURL is the API endpoint, for a proxy or a self-hosted install. Default is "https://api.rollbar.com".
+	BaseURL string
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// rollbarItem is the payload of a Rollbar item.
+type rollbarItem struct {
+	Data rollbarData `json:"data"`
+}
+
+type rollbarData struct {
+	Environment string            `json:"environment"`
+	Body        rollbarBody       `json:"body"`
+	Level       string            `json:"level"`
+	Timestamp   int64             `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Language    string            `json:"language"`
+	Title       string            `json:"title"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Request     *rollbarRequest   `json:"request,omitempty"`
+	Server      rollbarServer     `json:"server"`
+	Custom      map[string]string `json:"custom,omitempty"`
+	Notifier    rollbarNotifier   `json:"notifier"`
+}
+
+// rollbarBody holds a trace, or a message when the stack was not captured.
+type rollbarBody struct {
+	Trace   *rollbarTrace   `json:"trace,omitempty"`
+	Message *rollbarMessage `json:"message,omitempty"`
+}
+
+type rollbarTrace struct {
+	Frames    []rollbarFrame   `json:"frames"`
+	Exception rollbarException `json:"exception"`
+}
+
+type rollbarFrame struct {
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	Method   string `json:"method"`
+}
+
+type rollbarException struct {
+	Class   string `json:"class"`
+	Message string `json:"message"`
+}
+
+type rollbarMessage struct {
+	Body string `json:"body"`
+}
+
+type rollbarRequest struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	UserIP  string            `json:"user_ip,omitempty"`
+}
+
+type rollbarServer struct {
+	Host string `json:"host"`
+}
+
+type rollbarNotifier struct {
+	Name string `json:"name"`
+}
+
+// rollbarFormatter renders a panic as a Rollbar item.
+type rollbarFormatter struct {
+	environment string
+	host        string
+}
+
+func (f rollbarFormatter) Format(rec *PanicRecord) []byte {
+	class := fmt.Sprintf("%T", rec.Value)
+
+	data := rollbarData{
+		Environment: f.environment,
+		Level:       rollbarLevel(rec.Severity),
+		Timestamp:   rec.Time.Unix(),
+		Platform:    "go",
+		Language:    "go",
+		Title:       class + ": " + rec.Panic,
+		Fingerprint: rec.Fingerprint,
+		Server:      rollbarServer{Host: f.host},
+		Custom:      map[string]string{},
+		Notifier:    rollbarNotifier{Name: "github.com/unrolled/recovery"},
+	}
+
+	if len(rec.Frames) > 0 {
+		// Rollbar lists the outermost call first.
+		frames := make([]rollbarFrame, 0, len(rec.Frames))
+		for i := len(rec.Frames) - 1; i >= 0; i-- {
+			frame := rec.Frames[i]
+			frames = append(frames, rollbarFrame{Filename: frame.File, Lineno: frame.Line, Method: frame.Func})
+		}
+		data.Body.Trace = &rollbarTrace{Frames: frames, Exception: rollbarException{Class: class, Message: rec.Panic}}
+	} else {
+		data.Body.Message = &rollbarMessage{Body: data.Title}
+	}
+
+	if len(rec.Method) > 0 {
+		url := rec.URL
+		if len(url) == 0 {
+			url = rec.Path
+		}
+		data.Request = &rollbarRequest{
+			URL:     url,
+			Method:  rec.Method,
+			Headers: rec.Headers,
+			Body:    string(rec.Body),
+			UserIP:  remoteIP(rec.RemoteAddr),
+		}
+	}
+
+	for name, value := range map[string]string{
+		"request_id": rec.RequestID,
+		"origin":     rec.Origin,
+		"route":      rec.Route,
+		"trace_id":   rec.TraceID,
+	} {
+		if len(value) > 0 {
+			data.Custom[name] = value
+		}
+	}
+
+	return jsonPayload(rollbarItem{Data: data})
+}
+
+// rollbarLevel maps a Severity to a Rollbar level.
+func rollbarLevel(s Severity) string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "error"
+	}
+}
+
+// RollbarSink returns a Sink that reports each panic to Rollbar as an item, with the stack frames, the request, and the environment, grouped by the panic fingerprint. Every post happens as the panic is logged, so consider AsyncLogging.
+func RollbarSink(opt RollbarOptions) Sink {
+	w := newPostWriter(opt.Client, apiEndpoint(opt.BaseURL, defaultRollbarURL, "/api/1/item/"), "application/json", "Rollbar")
+	w.header.Set("X-Rollbar-Access-Token", opt.Token)
+
+	return Sink{Out: w, Formatter: rollbarFormatter{opt.Environment, hostname()}}
+}
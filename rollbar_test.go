@@ -0,0 +1,77 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRollbarSink(t *testing.T) {
+	items := make(chan []byte, 1)
+	var token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token = req.Header.Get("X-Rollbar-Access-Token")
+		b, _ := io.ReadAll(req.Body)
+		items <- b
+	}))
+	defer srv.Close()
+
+	sink := RollbarSink(RollbarOptions{Token: "secret", Environment: "staging", BaseURL: srv.URL, Client: srv.Client()})
+
+	r := New(Options{
+		Sinks:             []Sink{sink},
+		LogRequestDetails: true,
+		IncludeRequestID:  true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo?a=1", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var item rollbarItem
+	if err := json.Unmarshal(<-items, &item); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, token, "secret")
+
+	data := item.Data
+	expect(t, data.Environment, "staging")
+	expect(t, data.Level, "error")
+	expect(t, data.Title, "string: this did not work")
+	expect(t, len(data.Fingerprint) > 0, true)
+	expect(t, data.Request.URL, "/foo?a=1")
+	expect(t, data.Request.Method, "GET")
+	expect(t, data.Custom["request_id"], "abc123")
+	expect(t, data.Body.Trace.Exception.Class, "string")
+	expect(t, data.Body.Trace.Exception.Message, "this did not work")
+
+	// The handler that panicked is the last frame of the tests.
+	frames := data.Body.Trace.Frames
+	expect(t, len(frames) > 0, true)
+	var last string
+	for _, frame := range frames {
+		if strings.HasSuffix(frame.Filename, "_test.go") {
+			last = frame.Filename
+		}
+	}
+	expect(t, strings.HasSuffix(last, "recovery_test.go"), true)
+}
+
+func TestRollbarMessage(t *testing.T) {
+	f := rollbarFormatter{"production", "localhost"}
+
+	var item rollbarItem
+	b := f.Format(&PanicRecord{PanicInfo: &PanicInfo{Value: "boom", Severity: SeverityCritical}, Panic: "boom"})
+	if err := json.Unmarshal(b, &item); err != nil {
+		t.Fatal(err)
+	}
+
+	expect(t, item.Data.Level, "critical")
+	expect(t, item.Data.Body.Trace == nil, true)
+	expect(t, item.Data.Body.Message.Body, "string: boom")
+	expect(t, item.Data.Request == nil, true)
+}
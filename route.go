@@ -0,0 +1,10 @@
+//go:build go1.23
+
+package recovery
+
+import "net/http"
+
+// ServeMuxPattern returns the http.ServeMux pattern that matched the request, like "GET /items/{id}". It is the route Recovery logs without a RoutePattern option, and the one reporters fall back to. It is blank for requests no ServeMux matched.
+func ServeMuxPattern(req *http.Request) string {
+	return req.Pattern
+}
@@ -0,0 +1,10 @@
+//go:build !go1.23
+
+package recovery
+
+import "net/http"
+
+// ServeMuxPattern returns the http.ServeMux pattern that matched the request. It is always blank before Go 1.23, which added the matched pattern to http.Request.
+func ServeMuxPattern(req *http.Request) string {
+	return ""
+}
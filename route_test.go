@@ -0,0 +1,30 @@
+//go:build go1.23
+
+// The go 1.21 module line otherwise keeps the pattern-less ServeMux.
+//go:debug httpmuxgo121=0
+
+package recovery
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServeMuxRoutePattern(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:               buf,
+		LogRequestDetails: true,
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /items/{id}", myPanicHandler)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/items/42", nil)
+	r.Handler(mux).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "(route GET /items/{id})")
+}
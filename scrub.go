@@ -0,0 +1,70 @@
+package recovery
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// EmailPattern matches email addresses, for use in ScrubPatterns.
+var EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// defaultScrubParams are the query parameter name fragments scrubbed unless ScrubParams says otherwise.
+var defaultScrubParams = []string{"password", "passwd", "secret", "token", "api_key", "apikey"}
+
+// scrubParam reports whether a query parameter is scrubbed, because its name contains one of the ScrubParams.
+func (r *Recovery) scrubParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, fragment := range r.opt.ScrubParams {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scrubText replaces every match of the ScrubPatterns in s.
+func (r *Recovery) scrubText(s string) string {
+	for _, pattern := range r.opt.ScrubPatterns {
+		s = pattern.ReplaceAllLiteralString(s, redactedValue)
+	}
+
+	return s
+}
+
+// scrubURL renders the request URI of u with the values of scrubbed query parameters, and anything matching the ScrubPatterns, redacted.
+func (r *Recovery) scrubURL(u *url.URL) string {
+	uri := r.scrubText(u.EscapedPath())
+	if len(u.RawQuery) == 0 {
+		return uri
+	}
+
+	return uri + "?" + r.scrubQuery(u.RawQuery)
+}
+
+// scrubQuery redacts the values of scrubbed parameters of a query or form body, and anything matching the ScrubPatterns. Parameters keep their order and encoding otherwise.
+func (r *Recovery) scrubQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, value, _ := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+
+		if r.scrubParam(name) {
+			params[i] = key + "=" + redactedValue
+			continue
+		}
+
+		// Patterns are matched on the decoded value, as an email shows up as user%40example.com.
+		if decoded, err := url.QueryUnescape(value); err == nil {
+			if scrubbed := r.scrubText(decoded); scrubbed != decoded {
+				params[i] = key + "=" + scrubbed
+			}
+		}
+	}
+
+	return strings.Join(params, "&")
+}
@@ -0,0 +1,82 @@
+package recovery
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func TestScrubURL(t *testing.T) {
+	r := New(Options{
+		ScrubPatterns: []*regexp.Regexp{EmailPattern},
+	})
+
+	u, _ := url.Parse("/users/jane@example.com/reset?b=2&Access_Token=abc&email=jane%40example.com&a=1&password")
+	expect(t, r.scrubURL(u), "/users/[REDACTED]/reset?b=2&Access_Token=[REDACTED]&email=[REDACTED]&a=1&password=[REDACTED]")
+
+	u, _ = url.Parse("/plain")
+	expect(t, r.scrubURL(u), "/plain")
+}
+
+func TestScrubParamsCustom(t *testing.T) {
+	r := New(Options{
+		ScrubParams: []string{"SSN"},
+	})
+
+	u, _ := url.Parse("/foo?ssn=123&token=abc")
+	expect(t, r.scrubURL(u), "/foo?ssn=[REDACTED]&token=abc")
+
+	r = New(Options{
+		ScrubParams: []string{},
+	})
+	expect(t, r.scrubURL(u), "/foo?ssn=123&token=abc")
+}
+
+func TestScrubPanicRecord(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:               buf,
+		OutputFlags:       -1,
+		LogRequestDetails: true,
+		LogHeaders:        []string{"From"},
+		ScrubPatterns:     []*regexp.Regexp{EmailPattern},
+	})
+
+	emailPanicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("no account for " + r.URL.Query().Get("email"))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/lookup?email=jane%40example.com&token=s3cr3t-value", nil)
+	req.Header.Set("From", "ops@example.com")
+	r.Handler(emailPanicHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "example.com")
+	expectContainsFalse(t, buf.String(), "s3cr3t-value")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: no account for [REDACTED]\n")
+	expectContainsTrue(t, buf.String(), "Request: GET /lookup?email=[REDACTED]&token=[REDACTED] HTTP/1.1")
+	expectContainsTrue(t, buf.String(), "From: [REDACTED]\n")
+}
+
+func TestScrubBeforeTruncate(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:                buf,
+		OutputFlags:        -1,
+		MaxPanicValueBytes: 20,
+		ScrubPatterns:      []*regexp.Regexp{EmailPattern},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("no account for jane@example.com")
+	})).ServeHTTP(res, req)
+
+	// The cut would otherwise leave "jane@" behind, which no longer matches the pattern.
+	expectContainsFalse(t, buf.String(), "jane")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: no account for [REDA...(truncated)\n")
+}
@@ -0,0 +1,60 @@
+package recovery
+
+import "log/slog"
+
+// Severity ranks how bad a recovered panic is, so alerting can tell a client abort from a nil dereference.
+type Severity int
+
+const (
+	// SeverityDebug is for panics only worth a look while debugging.
+	SeverityDebug Severity = iota
+	// SeverityInfo is for expected panics, like a client going away.
+	SeverityInfo
+	// SeverityWarning is for panics worth a look, but no page.
+	SeverityWarning
+	// SeverityError is for regular bugs. This is the default of every panic.
+	SeverityError
+	// SeverityCritical is for panics that need attention right away.
+	SeverityCritical
+)
+
+// String returns the lower case name of the severity, like "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "error"
+	}
+}
+
+// slogLevel is the slog level matching the severity. Critical panics log above slog.LevelError.
+func (s Severity) slogLevel() slog.Level {
+	switch s {
+	case SeverityDebug:
+		return slog.LevelDebug
+	case SeverityInfo:
+		return slog.LevelInfo
+	case SeverityWarning:
+		return slog.LevelWarn
+	case SeverityCritical:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelError
+	}
+}
+
+// severity classifies a recovered value with the Severity option.
+func (r *Recovery) severity(v interface{}) Severity {
+	if r.opt.Severity != nil {
+		return r.opt.Severity(v)
+	}
+
+	return SeverityError
+}
@@ -0,0 +1,90 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var errClientGone = errors.New("client went away")
+
+func classifyPanic(recovered interface{}) Severity {
+	if recovered == errClientGone {
+		return SeverityInfo
+	}
+	return SeverityCritical
+}
+
+func TestSeverityString(t *testing.T) {
+	expect(t, SeverityDebug.String(), "debug")
+	expect(t, SeverityInfo.String(), "info")
+	expect(t, SeverityWarning.String(), "warning")
+	expect(t, SeverityError.String(), "error")
+	expect(t, SeverityCritical.String(), "critical")
+}
+
+func TestSeverityText(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:      buf,
+		Severity: classifyPanic,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(panicWith(errClientGone)).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), "Recovering from Panic (level=info): client went away")
+}
+
+func TestSeverityDefault(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out: buf,
+	})
+
+	var severity Severity = -1
+	r.OnPanic(func(req *http.Request, v interface{}, stack []byte) {
+		info, _ := FromContext(req.Context())
+		severity = info.Severity
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expect(t, severity, SeverityError)
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: this did not work")
+}
+
+func TestSeverityJSON(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:      buf,
+		Formats:  []LogFormat{FormatJSON, FormatLogfmt},
+		Severity: classifyPanic,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), `"level":"critical"`)
+	expectContainsTrue(t, buf.String(), ` level=critical `)
+}
+
+func TestSeveritySlog(t *testing.T) {
+	slogOut := bytes.NewBufferString("")
+	r := New(Options{
+		Slog:     slog.New(slog.NewTextHandler(slogOut, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Severity: classifyPanic,
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(panicWith(errClientGone)).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsTrue(t, slogOut.String(), `level=INFO msg="Recovering from Panic"`)
+
+	slogOut.Reset()
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expectContainsTrue(t, slogOut.String(), `level=ERROR+4 msg="Recovering from Panic"`)
+}
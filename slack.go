@@ -0,0 +1,132 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// chatFrames is how many application frames the messages of chat notifiers show.
+const chatFrames = 5
+
+// slackMessage is the payload of a Slack incoming webhook. Text is the fallback of notifications.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackEscaper escapes the characters Slack reserves for links and mentions.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackFormatter renders a panic as a Slack message, at most once per throttle window and fingerprint.
+type slackFormatter struct {
+	logsURL string
+	limiter *rateLimiter
+}
+
+func (f slackFormatter) Format(rec *PanicRecord) []byte {
+	suppressed := 0
+	if f.limiter != nil {
+		var ok bool
+		if ok, suppressed = f.limiter.allow(rec.Fingerprint, rec.Time); !ok {
+			return nil
+		}
+	}
+
+	where := rec.Route
+	if len(where) == 0 {
+		where = rec.Method + " " + rec.Path
+	}
+	title := fmt.Sprintf("Panic in %s: %s", where, rec.Panic)
+
+	msg := slackMessage{
+		Text: slackEscaper.Replace(title),
+		Blocks: []slackBlock{{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: fmt.Sprintf(":rotating_light: *Panic in %s*\n%s", slackEscaper.Replace(where), slackEscaper.Replace(rec.Panic))},
+		}},
+	}
+
+	var fields []slackText
+	for _, field := range [][2]string{
+		{"Severity", rec.Severity.String()},
+		{"Request ID", rec.RequestID},
+		{"Origin", rec.Origin},
+		{"Fingerprint", rec.Fingerprint},
+	} {
+		if len(field[1]) > 0 {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: "*" + field[0] + "*\n" + slackEscaper.Replace(field[1])})
+		}
+	}
+	if len(fields) > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Fields: fields})
+	}
+
+	if frames := appFrames(rec.Frames, chatFrames); len(frames) > 0 {
+		var b strings.Builder
+		for _, frame := range frames {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Func, frame.File, frame.Line)
+		}
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "```" + slackEscaper.Replace(b.String()) + "```"}})
+	}
+
+	var footer []slackText
+	if link := f.link(rec); len(link) > 0 {
+		footer = append(footer, slackText{Type: "mrkdwn", Text: "<" + link + "|View logs>"})
+	}
+	if suppressed > 0 {
+		footer = append(footer, slackText{Type: "mrkdwn", Text: fmt.Sprintf("%d more since the last message", suppressed)})
+	}
+	if len(footer) > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: footer})
+	}
+
+	return jsonPayload(msg)
+}
+
+// link fills in the logs URL of a panic, with the values query escaped. It is blank for a panic without the request id the URL searches for, as without IncludeRequestID, which would link to no logs at all.
+func (f slackFormatter) link(rec *PanicRecord) string {
+	if strings.Contains(f.logsURL, "{request_id}") && len(rec.RequestID) == 0 {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{request_id}", url.QueryEscape(rec.RequestID),
+		"{fingerprint}", url.QueryEscape(rec.Fingerprint),
+		"{trace_id}", url.QueryEscape(rec.TraceID),
+	).Replace(f.logsURL)
+}
+
+// SlackOptions configures a SlackSink.
+type SlackOptions struct {
+	// LogsURL links each message to the logs. It may hold {request_id}, {fingerprint}, and {trace_id} placeholders, filled in for each panic; with {request_id}, it is only linked with IncludeRequestID. Default is blank (no link).
+	LogsURL string
+	// Throttle is how often a fingerprint is posted at most. Default is 0 (every panic is posted).
+	Throttle time.Duration
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// SlackSink returns a Sink that posts each panic to a Slack incoming webhook, with the panic value, the route, the top application frames, and a link to the logs. A panic is posted at most once per Throttle window and fingerprint, so a crash loop does not flood the channel; the next message tells how many were held back. Every post happens as the panic is logged, so consider AsyncLogging.
+func SlackSink(webhookURL string, opt SlackOptions) Sink {
+	f := slackFormatter{logsURL: opt.LogsURL}
+	if opt.Throttle > 0 {
+		f.limiter = newRateLimiter(1, opt.Throttle)
+	}
+
+	return Sink{Out: newPostWriter(opt.Client, webhookURL, "application/json", "Slack"), Formatter: f}
+}
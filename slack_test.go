@@ -0,0 +1,108 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlackSink(t *testing.T) {
+	messages := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		messages <- b
+	}))
+	defer srv.Close()
+
+	sink := SlackSink(srv.URL, SlackOptions{LogsURL: "https://logs.example.com/search?q={request_id}", Throttle: time.Minute, Client: srv.Client()})
+
+	r := New(Options{Sinks: []Sink{sink}, IncludeRequestID: true})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc 123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	var msg slackMessage
+	if err := json.Unmarshal(<-messages, &msg); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, msg.Text, "Panic in GET /foo: this did not work")
+	expect(t, len(msg.Blocks), 4)
+	expect(t, msg.Blocks[0].Text.Text, ":rotating_light: *Panic in GET /foo*\nthis did not work")
+	expect(t, msg.Blocks[1].Fields[0].Text, "*Severity*\nerror")
+	expect(t, msg.Blocks[1].Fields[1].Text, "*Request ID*\nabc 123")
+	expectContainsTrue(t, msg.Blocks[2].Text.Text, "recovery_test.go")
+	expect(t, msg.Blocks[3].Elements[0].Text, "<https://logs.example.com/search?q=abc+123|View logs>")
+
+	// The same panic again is throttled.
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	select {
+	case b := <-messages:
+		t.Errorf("Expected the second panic to be throttled - Got %s", b)
+	default:
+	}
+}
+
+func TestSlackThrottle(t *testing.T) {
+	f := SlackSink("", SlackOptions{Throttle: time.Minute}).Formatter
+	now := time.Now()
+
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Time: now, Fingerprint: "abc"}, Panic: "oops"}
+	expect(t, len(f.Format(rec)) > 0, true)
+
+	for i := 0; i < 3; i++ {
+		rec.Time = now.Add(time.Second)
+		expect(t, len(f.Format(rec)), 0)
+	}
+
+	// Other fingerprints are not held back.
+	expect(t, len(f.Format(&PanicRecord{PanicInfo: &PanicInfo{Time: now, Fingerprint: "def"}, Panic: "oops"})) > 0, true)
+
+	// The first message of the next window tells how many were held back.
+	rec.Time = now.Add(time.Minute)
+	var msg slackMessage
+	if err := json.Unmarshal(f.Format(rec), &msg); err != nil {
+		t.Fatal(err)
+	}
+	last := msg.Blocks[len(msg.Blocks)-1]
+	expect(t, last.Type, "context")
+	expect(t, last.Elements[0].Text, "3 more since the last message")
+}
+
+func TestSlackEscape(t *testing.T) {
+	f := SlackSink("", SlackOptions{}).Formatter
+
+	rec := &PanicRecord{PanicInfo: &PanicInfo{}, Method: "GET", Route: "/a", Panic: "<!channel> & co"}
+	var msg slackMessage
+	if err := json.Unmarshal(f.Format(rec), &msg); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, msg.Text, "Panic in /a: &lt;!channel&gt; &amp; co")
+
+	// Without a throttle every panic is posted.
+	expect(t, len(f.Format(rec)) > 0, true)
+}
+
+func TestSlackSinkWithoutRequestID(t *testing.T) {
+	messages := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		messages <- b
+	}))
+	defer srv.Close()
+
+	sink := SlackSink(srv.URL, SlackOptions{LogsURL: "https://logs.example.com/search?q={request_id}", Client: srv.Client()})
+	r := New(Options{Sinks: []Sink{sink}})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	// Neither the id nor a link searching for it are posted, as no log line holds it.
+	b := <-messages
+	expectContainsFalse(t, string(b), "abc123")
+	expectContainsFalse(t, string(b), "View logs")
+}
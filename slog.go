@@ -0,0 +1,51 @@
+package recovery
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logSlog emits a panic record as a structured slog entry, at the level matching its severity.
+func (r *Recovery) logSlog(ctx context.Context, rec *PanicRecord) {
+	attrs := []slog.Attr{
+		slog.String("panic", rec.Panic),
+		slog.String("stack", string(rec.Stack)),
+		slog.String("method", rec.Method),
+		slog.String("path", rec.Path),
+		slog.String("fingerprint", rec.Fingerprint),
+	}
+	if len(rec.Origin) > 0 {
+		attrs = append(attrs, slog.String("origin", rec.Origin))
+	}
+	if requestID := r.recordRequestID(rec); len(requestID) > 0 {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if len(rec.TraceID) > 0 {
+		attrs = append(attrs, slog.String("trace_id", rec.TraceID), slog.String("span_id", rec.SpanID))
+	}
+	if rec.Count > 0 {
+		attrs = append(attrs, slog.Int("count", rec.Count))
+	}
+	if len(rec.URL) > 0 {
+		attrs = append(attrs,
+			slog.String("url", rec.URL),
+			slog.String("proto", rec.Proto),
+			slog.String("remote_addr", rec.RemoteAddr),
+		)
+		if len(rec.Route) > 0 {
+			attrs = append(attrs, slog.String("route", rec.Route))
+		}
+	}
+	if len(rec.Headers) > 0 {
+		headers := make([]interface{}, 0, len(rec.Headers))
+		for _, name := range rec.headerNames() {
+			headers = append(headers, slog.String(name, rec.Headers[name]))
+		}
+		attrs = append(attrs, slog.Group("headers", headers...))
+	}
+	if len(rec.Body) > 0 {
+		attrs = append(attrs, slog.String("body", string(rec.Body)))
+	}
+
+	r.opt.Slog.LogAttrs(ctx, rec.Severity.slogLevel(), rec.Message, attrs...)
+}
@@ -0,0 +1,89 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlog(t *testing.T) {
+	out := bytes.NewBufferString("")
+	slogOut := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:              out,
+		Slog:             slog.New(slog.NewJSONHandler(slogOut, nil)),
+		IncludeRequestID: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", nil)
+	req.Header.Set("X-Request-ID", "req-1234")
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, out.Len(), 0)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(slogOut.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single slog entry, got error %v for [%s]", err, slogOut.String())
+	}
+
+	expect(t, entry["level"], interface{}("ERROR"))
+	expect(t, entry["msg"], interface{}("Recovering from Panic"))
+	expect(t, entry["panic"], interface{}("this did not work"))
+	expect(t, entry["method"], interface{}("POST"))
+	expect(t, entry["path"], interface{}("/orders"))
+	expect(t, entry["request_id"], interface{}("req-1234"))
+	expectContainsTrue(t, entry["stack"].(string), "src/net/http/server.go")
+}
+
+func TestSlogSinks(t *testing.T) {
+	out := bytes.NewBufferString("")
+	slogOut := bytes.NewBufferString("")
+	sinkOut := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:  out,
+		Slog: slog.New(slog.NewJSONHandler(slogOut, nil)),
+		Sinks: []Sink{
+			{Format: FormatJSON},
+			{Out: sinkOut, Format: FormatJSON},
+		},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	// Slog replaces the main output, but not the sinks with an Out of their own.
+	expect(t, out.Len(), 0)
+	expectContainsTrue(t, slogOut.String(), "this did not work")
+	expectContainsTrue(t, sinkOut.String(), `"panic":"this did not work"`)
+}
+
+func TestSlogNotes(t *testing.T) {
+	slogOut := bytes.NewBufferString("")
+
+	r := New(Options{
+		Slog: slog.New(slog.NewTextHandler(slogOut, nil)),
+	})
+
+	committedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("too late")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(committedHandler).ServeHTTP(res, req)
+
+	lines := strings.Split(strings.TrimSpace(slogOut.String()), "\n")
+	expect(t, len(lines), 2)
+	expectContainsTrue(t, lines[0], `level=WARN msg="Response already committed, skipping the panic handler"`)
+	expectContainsTrue(t, lines[1], `level=ERROR msg="Recovering from Panic" panic="too late"`)
+}
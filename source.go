@@ -0,0 +1,50 @@
+package recovery
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// sourceFiles reads source files once each, as many frames share a file. A file that cannot be read has no lines.
+type sourceFiles map[string][]string
+
+func (files sourceFiles) lines(path string) []string {
+	lines, ok := files[path]
+	if !ok {
+		if b, err := ioutil.ReadFile(path); err == nil {
+			lines = strings.Split(string(b), "\n")
+		}
+		files[path] = lines
+	}
+
+	return lines
+}
+
+// sourceText renders SourceContext lines of source around each application frame, for the log output. Frames whose files are not on disk are left out.
+func (r *Recovery) sourceText(frames []Frame) string {
+	var b strings.Builder
+
+	files := sourceFiles{}
+	for _, frame := range frames {
+		if !DefaultFrameFilter(frame) {
+			continue
+		}
+
+		snippet := sourceSnippet(files.lines(frame.File), frame.Line, r.opt.SourceContext)
+		if len(snippet) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Func, frame.File, frame.Line)
+		for _, line := range snippet {
+			marker := " "
+			if line.Current {
+				marker = ">"
+			}
+			fmt.Fprintf(&b, "\t%s %5d | %s\n", marker, line.Number, line.Text)
+		}
+	}
+
+	return b.String()
+}
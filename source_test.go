@@ -0,0 +1,64 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSourceContext(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:           buf,
+		SourceContext: 1,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	parts := strings.SplitN(buf.String(), "Source:\n", 2)
+	expect(t, len(parts), 2)
+	source := parts[len(parts)-1]
+	expectContainsTrue(t, source, "| \t\tpanic(\"this did not work\")")
+	expectContainsTrue(t, source, "\t> ")
+	expectContainsFalse(t, source, "src/runtime/panic.go:")
+}
+
+func TestSourceContextJSON(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:           buf,
+		Format:        FormatJSON,
+		SourceContext: 2,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var rec jsonRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected a single JSON record, got error %v for [%s]", err, buf.String())
+	}
+	expectContainsTrue(t, rec.Source, `panic("this did not work")`)
+}
+
+func TestSourceContextOff(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{Out: buf})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsFalse(t, buf.String(), "Source:\n")
+}
+
+func TestSourceTextMissingFile(t *testing.T) {
+	r := New(Options{SourceContext: 3})
+	expect(t, r.sourceText([]Frame{{Func: "main.handler", File: "/does/not/exist.go", Line: 3}}), "")
+}
@@ -0,0 +1,335 @@
+package recovery
+
+import (
+	"bytes"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxCallers is how many program counters are captured at first.
+	maxCallers = 128
+	// maxCallersLimit is how many program counters are captured at most, when the stack is deeper.
+	maxCallersLimit = 16 * 1024
+)
+
+// Frame is a single call of a panic stack, innermost first.
+type Frame struct {
+	// Func is the package path qualified name of the function, like "main.(*Server).handle".
+	Func string
+	// File is the full path of the source file.
+	File string
+	// Line is the line number in File.
+	Line int
+	// PC is the program counter of the call, or 0 when it is unknown, like for the frames of other goroutines in a full stack.
+	PC uintptr
+}
+
+// capturedStack is the stack of a panic, as captured while it unwinds.
+type capturedStack struct {
+	// id is the goroutine that panicked, or 0 when it is unknown.
+	id uint64
+	// frames are the calls of the goroutine that panicked, innermost first.
+	frames []Frame
+	// full is the runtime.Stack dump of every goroutine, with IncludeFullStack.
+	full []byte
+}
+
+// parseStack splits a runtime.Stack dump into frames. Goroutine headers are skipped, so a full stack dump yields the frames of every goroutine in order. A frame cut short by StackSize is dropped.
+func parseStack(stack []byte) []Frame {
+	var frames []Frame
+
+	lines := bytes.Split(stack, []byte("\n"))
+	for i := 0; i+1 < len(lines); i++ {
+		fn := string(lines[i])
+		if len(fn) == 0 || fn[0] == '\t' || strings.HasPrefix(fn, "goroutine ") {
+			continue
+		}
+
+		loc := string(lines[i+1])
+		if len(loc) == 0 || loc[0] != '\t' {
+			continue
+		}
+		i++
+
+		file, line, ok := parseLocation(loc[1:])
+		if !ok {
+			continue
+		}
+
+		frames = append(frames, Frame{
+			Func: parseFunc(fn),
+			File: file,
+			Line: line,
+		})
+	}
+
+	return frames
+}
+
+// callerFrames returns the frames of the current goroutine from runtime.Callers, starting at the caller of callerFrames. The frame of runtime.goexit every goroutine ends with is left out, like runtime.Stack does.
+func callerFrames() []runtime.Frame {
+	var buf [maxCallers]uintptr
+	pcs := buf[:runtime.Callers(2, buf[:])]
+
+	// A full buffer means there may be more frames.
+	for len(pcs) == cap(pcs) && len(pcs) < maxCallersLimit {
+		pcs = make([]uintptr, 2*len(pcs))
+		pcs = pcs[:runtime.Callers(2, pcs)]
+	}
+
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		if frame.Function != "runtime.goexit" {
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+
+	return frames
+}
+
+// goroutineID returns the id of the current goroutine, read from the header of a runtime.Stack dump, or 0 when it cannot be parsed.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		if id, err := strconv.ParseUint(string(b[:i]), 10, 64); err == nil {
+			return id
+		}
+	}
+
+	return 0
+}
+
+// renderStack writes the frames of goroutine id in the format of a runtime.Stack dump. Argument values are not known from program counters, so every call shows "(...)", like the runtime does for inlined calls. With clean, only the function, file, and line of each frame are written, like CleanStack has it. When the dump is longer than limit bytes, it is cut after the last frame that fits along with the elided frames line, so no frame is left half written. A limit of 0 keeps every frame.
+func renderStack(buf *bytes.Buffer, id uint64, frames []Frame, limit int, clean bool) {
+	start := buf.Len()
+
+	if !clean {
+		buf.WriteString("goroutine ")
+		if id > 0 {
+			buf.WriteString(strconv.FormatUint(id, 10))
+			buf.WriteByte(' ')
+		}
+		buf.WriteString("[running]:\n")
+	}
+
+	ends := make([]int, 0, len(frames)+1)
+	ends = append(ends, buf.Len())
+	for _, frame := range frames {
+		buf.WriteString(frame.Func)
+		if !clean {
+			buf.WriteString("(...)")
+		}
+		buf.WriteString("\n\t")
+		buf.WriteString(frame.File)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(frame.Line))
+		if offset, ok := frameOffset(frame); ok && !clean {
+			buf.WriteString(" +0x")
+			buf.WriteString(strconv.FormatUint(uint64(offset), 16))
+		}
+		buf.WriteByte('\n')
+		ends = append(ends, buf.Len())
+	}
+
+	if limit <= 0 || buf.Len()-start <= limit {
+		return
+	}
+
+	// The header is always kept, even when not even it fits.
+	cut := ends[0]
+	for _, end := range ends[1:] {
+		if end-start+len(elidedFrames) > limit {
+			break
+		}
+		cut = end
+	}
+	buf.Truncate(cut)
+	buf.WriteString(elidedFrames)
+}
+
+// frameOffset returns how far into its function the program counter of a frame is. Inlined calls have no function of their own, the one holding the program counter being another, and the runtime prints no offset for them.
+func frameOffset(frame Frame) (uintptr, bool) {
+	fn := runtime.FuncForPC(frame.PC)
+	if frame.PC == 0 || fn == nil || fn.Name() != frame.Func || frame.PC < fn.Entry() {
+		return 0, false
+	}
+
+	return frame.PC - fn.Entry(), true
+}
+
+// renderFrames renders the frames of goroutine id with renderStack, bounded like a runtime.Stack buffer of StackSize, or MaxStackSize when larger, would be, and ended by the elided frames line when MaxFrames cut them short. With trim, the file paths are shortened with trimmedPath.
+func (r *Recovery) renderFrames(id uint64, frames []Frame, elided, trim, clean bool) []byte {
+	limit := r.opt.StackSize
+	if r.opt.MaxStackSize > limit {
+		limit = r.opt.MaxStackSize
+	}
+
+	if trim {
+		trimmed := make([]Frame, len(frames))
+		for i, frame := range frames {
+			frame.File = trimmedPath(frame.Func, frame.File)
+			trimmed[i] = frame
+		}
+		frames = trimmed
+	}
+
+	buf := getBuffer()
+	renderStack(buf, id, frames, limit, clean)
+	if elided && !bytes.HasSuffix(buf.Bytes(), []byte(elidedFrames)) {
+		buf.WriteString(elidedFrames)
+	}
+
+	return putBuffer(buf)
+}
+
+// trimmedPath shortens the path of the source file of a function to one that is the same on every build machine: the path in the module cache without the module version, the path under GOROOT/src for the standard library, or else the import path of the package of the function followed by the file name.
+func trimmedPath(fn, file string) string {
+	if i := strings.LastIndex(file, "/pkg/mod/"); i >= 0 {
+		file = file[i+len("/pkg/mod/"):]
+
+		// Drop the version, as in "github.com/unrolled/recovery@v1.5.0/recovery.go".
+		if at := strings.Index(file, "@"); at >= 0 {
+			rest := ""
+			if slash := strings.Index(file[at:], "/"); slash >= 0 {
+				rest = file[at+slash:]
+			}
+			file = file[:at] + rest
+		}
+
+		return file
+	}
+
+	if goroot := runtime.GOROOT(); len(goroot) > 0 && strings.HasPrefix(file, goroot+"/src/") {
+		return strings.TrimPrefix(file, goroot+"/src/")
+	}
+
+	// The package path runs up to the first dot after its last slash, as in "github.com/me/app/handlers.(*Users).Get".
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return file
+	}
+
+	return fn[:slash+1+dot] + "/" + path.Base(file)
+}
+
+// trimStack rewrites the file paths of a full stack dump with trimmedPath, with TrimPaths. Other dumps are rendered from their frames with the paths trimmed already.
+func (r *Recovery) trimStack(stack []byte) []byte {
+	if !r.opt.TrimPaths {
+		return stack
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(stack))
+
+	var fn string
+	for _, line := range bytes.SplitAfter(stack, []byte("\n")) {
+		if len(line) == 0 || line[0] != '\t' {
+			fn = parseFunc(strings.TrimSuffix(string(line), "\n"))
+			buf.Write(line)
+			continue
+		}
+
+		loc := strings.TrimPrefix(string(line), "\t")
+		file, _, ok := parseLocation(strings.TrimSuffix(loc, "\n"))
+		if !ok {
+			buf.Write(line)
+			continue
+		}
+
+		buf.WriteByte('\t')
+		buf.WriteString(trimmedPath(fn, file))
+		buf.WriteString(loc[len(file):])
+	}
+
+	return buf.Bytes()
+}
+
+// cleanStack rewrites a full stack dump to only the function, file, and line of each frame, with CleanStack. Goroutine headers, argument values, and program counter offsets are dropped, and the goroutines of a full stack are kept apart by a blank line.
+func (r *Recovery) cleanStack(stack []byte) []byte {
+	if !r.opt.CleanStack {
+		return stack
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(stack))
+
+	lines := strings.Split(string(stack), "\n")
+	for i := 0; i < len(lines); i++ {
+		fn := lines[i]
+		if strings.HasPrefix(fn, "goroutine ") {
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			continue
+		}
+
+		if len(fn) > 0 && fn[0] != '\t' && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			if file, line, ok := parseLocation(lines[i+1][1:]); ok {
+				i++
+				buf.WriteString(parseFunc(fn))
+				buf.WriteString("\n\t")
+				buf.WriteString(file)
+				buf.WriteByte(':')
+				buf.WriteString(strconv.Itoa(line))
+				buf.WriteByte('\n')
+				continue
+			}
+		}
+
+		// Anything else but blank lines, like the elided frames line, is kept as is.
+		if len(fn) > 0 {
+			buf.WriteString(fn)
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// parseFunc strips the argument list, or the "created by" wording, from a function line.
+func parseFunc(s string) string {
+	if strings.HasPrefix(s, "created by ") {
+		s = strings.TrimPrefix(s, "created by ")
+		if i := strings.Index(s, " in goroutine "); i >= 0 {
+			s = s[:i]
+		}
+		return s
+	}
+
+	if strings.HasSuffix(s, ")") {
+		if i := strings.LastIndex(s, "("); i > 0 {
+			s = s[:i]
+		}
+	}
+
+	return s
+}
+
+// parseLocation parses "/path/to/file.go:12 +0x64" into its file and line.
+func parseLocation(s string) (string, int, bool) {
+	if i := strings.LastIndex(s, " +0x"); i >= 0 {
+		s = s[:i]
+	}
+
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return s[:i], line, true
+}
@@ -0,0 +1,245 @@
+package recovery
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testStack = `goroutine 7 [running]:
+github.com/unrolled/recovery.(*tt).m(0x4c39d3?)
+	/root/module/zz_test.go:4 +0x38
+github.com/unrolled/recovery.TestPrintStack.func1(...)
+	/root/module/zz_test.go:5
+panic({0x6b4e40?, 0x7cc2e0?})
+	/usr/local/go/src/runtime/panic.go:783 +0x132
+testing.tRunner(0x1e69ae6f0488, 0xbc88c0)
+	/usr/local/go/src/testing/testing.go:2193 +0xea
+created by testing.(*T).Run in goroutine 1
+	/usr/local/go/src/testing/testing.go:2258 +0x4d4
+
+goroutine 1 [chan receive]:
+main.main()
+	/src/main.go:10 +0x20
+net/http.(*conn).serve(0xc0001`
+
+func TestParseStack(t *testing.T) {
+	frames := parseStack([]byte(testStack))
+
+	want := []Frame{
+		{"github.com/unrolled/recovery.(*tt).m", "/root/module/zz_test.go", 4, 0},
+		{"github.com/unrolled/recovery.TestPrintStack.func1", "/root/module/zz_test.go", 5, 0},
+		{"panic", "/usr/local/go/src/runtime/panic.go", 783, 0},
+		{"testing.tRunner", "/usr/local/go/src/testing/testing.go", 2193, 0},
+		{"testing.(*T).Run", "/usr/local/go/src/testing/testing.go", 2258, 0},
+		{"main.main", "/src/main.go", 10, 0},
+	}
+
+	expect(t, len(frames), len(want))
+	for i := range want {
+		if i < len(frames) {
+			expect(t, frames[i], want[i])
+		}
+	}
+}
+
+func TestPanicInfoFrames(t *testing.T) {
+	r := New(Options{Out: bytes.NewBufferString("")})
+
+	var frames []Frame
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		if info, ok := FromContext(req.Context()); ok {
+			frames = info.Frames
+		}
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	var found bool
+	for _, frame := range frames {
+		// The first frame of the test files is the handler that panicked.
+		if strings.HasSuffix(frame.File, "recovery_test.go") {
+			found = true
+			expect(t, frame.PC != 0, true)
+			expect(t, runtime.FuncForPC(frame.PC).Name(), frame.Func)
+			break
+		}
+	}
+	if !found {
+		t.Errorf("Expected the frame of the panicking handler in %v", frames)
+	}
+}
+
+func TestTrimmedPath(t *testing.T) {
+	expect(t, trimmedPath("github.com/unrolled/recovery.(*Recovery).Handler.func1", "/home/ci/go/pkg/mod/github.com/unrolled/recovery@v1.5.0/recovery.go"), "github.com/unrolled/recovery/recovery.go")
+	expect(t, trimmedPath("github.com/go-chi/chi/v5.(*Mux).ServeHTTP", "/root/go/pkg/mod/github.com/go-chi/chi/v5@v5.0.12/mux.go"), "github.com/go-chi/chi/v5/mux.go")
+	expect(t, trimmedPath("net/http.HandlerFunc.ServeHTTP", runtime.GOROOT()+"/src/net/http/server.go"), "net/http/server.go")
+	expect(t, trimmedPath("github.com/me/app/handlers.(*Users).Get", "/home/ci/build/app/handlers/users.go"), "github.com/me/app/handlers/users.go")
+	expect(t, trimmedPath("main.main", "/src/main.go"), "main/main.go")
+}
+
+func TestTrimPaths(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:       buf,
+		TrimPaths: true,
+	})
+
+	var frames []Frame
+	r.OnPanic(func(req *http.Request, panicVal interface{}, stack []byte) {
+		info, _ := FromContext(req.Context())
+		frames = info.Frames
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "\tgithub.com/unrolled/recovery/recovery_test.go:")
+	expectContainsTrue(t, buf.String(), "\tnet/http/server.go:")
+	expectContainsFalse(t, buf.String(), runtime.GOROOT())
+
+	// The frames keep the paths on disk.
+	for _, frame := range frames {
+		if strings.HasSuffix(frame.File, "recovery_test.go") {
+			expect(t, filepath.IsAbs(frame.File), true)
+		}
+	}
+}
+
+func TestCallerFrames(t *testing.T) {
+	frames := callerFrames()
+
+	expect(t, frames[0].Function, "github.com/unrolled/recovery.TestCallerFrames")
+	expect(t, frames[len(frames)-1].Function != "runtime.goexit", true)
+}
+
+func TestGoroutineID(t *testing.T) {
+	var buf [64]byte
+	header := string(buf[:runtime.Stack(buf[:], false)])
+
+	expect(t, goroutineID() > 0, true)
+	expect(t, strings.HasPrefix(header, "goroutine "+strconv.FormatUint(goroutineID(), 10)+" [running]:"), true)
+}
+
+// testFrames returns the frames of the caller, as captureStack has them.
+func testFrames() []Frame {
+	var frames []Frame
+	for _, caller := range callerFrames()[1:] {
+		frames = append(frames, Frame{Func: caller.Function, File: caller.File, Line: caller.Line, PC: caller.PC})
+	}
+
+	return frames
+}
+
+func TestRenderStack(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	renderStack(buf, 7, testFrames(), 0, false)
+
+	stack := buf.Bytes()
+	expect(t, strings.HasPrefix(buf.String(), "goroutine 7 [running]:\ngithub.com/unrolled/recovery.TestRenderStack(...)\n\t"), true)
+	expectContainsTrue(t, buf.String(), "testing.tRunner(...)\n")
+
+	// The rendering parses like a runtime.Stack dump.
+	frames := parseStack(stack)
+	expect(t, frames[0].Func, "github.com/unrolled/recovery.TestRenderStack")
+	expect(t, strings.HasSuffix(frames[0].File, "stack_test.go"), true)
+}
+
+func TestRenderStackLimit(t *testing.T) {
+	callers := testFrames()
+
+	full := bytes.NewBufferString("")
+	renderStack(full, 7, callers, 0, false)
+
+	// Each limit cuts the dump after a whole frame, and stays within it.
+	for limit := 80; limit < full.Len(); limit += 7 {
+		buf := bytes.NewBufferString("")
+		renderStack(buf, 7, callers, limit, false)
+
+		stack := buf.String()
+		expect(t, len(stack) <= limit, true)
+		expect(t, strings.HasSuffix(stack, "\n"+elidedFrames), true)
+		expect(t, strings.HasPrefix(full.String(), strings.TrimSuffix(stack, elidedFrames)), true)
+	}
+
+	// The header is kept when not even it fits.
+	buf := bytes.NewBufferString("")
+	renderStack(buf, 7, callers, 10, false)
+	expect(t, buf.String(), "goroutine 7 [running]:\n"+elidedFrames)
+
+	// Without an id, the header has none.
+	buf.Reset()
+	renderStack(buf, 0, nil, 0, false)
+	expect(t, buf.String(), "goroutine [running]:\n")
+}
+
+func TestCleanStack(t *testing.T) {
+	r := New(Options{CleanStack: true})
+
+	expect(t, string(r.cleanStack([]byte(testStack))), `github.com/unrolled/recovery.(*tt).m
+	/root/module/zz_test.go:4
+github.com/unrolled/recovery.TestPrintStack.func1
+	/root/module/zz_test.go:5
+panic
+	/usr/local/go/src/runtime/panic.go:783
+testing.tRunner
+	/usr/local/go/src/testing/testing.go:2193
+testing.(*T).Run
+	/usr/local/go/src/testing/testing.go:2258
+
+main.main
+	/src/main.go:10
+net/http.(*conn).serve(0xc0001
+`)
+}
+
+func TestCleanStackLogged(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:        buf,
+		CleanStack: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expectContainsTrue(t, buf.String(), "\nnet/http.HandlerFunc.ServeHTTP\n\t")
+	expectContainsFalse(t, buf.String(), "goroutine ")
+	expectContainsFalse(t, buf.String(), " +0x")
+	expectContainsFalse(t, buf.String(), "(...)")
+}
+
+func TestRenderFrames(t *testing.T) {
+	r := New(Options{TrimPaths: true, CleanStack: true})
+	frames := testFrames()
+
+	// Rendered from the frames, the logged stack reads like the dump rewritten by TrimPaths and CleanStack.
+	dump := r.renderFrames(7, frames, false, false, false)
+	expect(t, string(r.renderFrames(7, frames, false, true, true)), string(r.cleanStack(r.trimStack(dump))))
+
+	// Frames cut short by MaxFrames end with the elided frames line.
+	expect(t, strings.HasSuffix(string(r.renderFrames(7, frames[:1], true, false, false)), "\n"+elidedFrames), true)
+}
+
+func TestFrameOffset(t *testing.T) {
+	// The offsets are the ones the runtime knows, and inlined calls have none.
+	for _, caller := range callerFrames() {
+		offset, ok := frameOffset(Frame{Func: caller.Function, PC: caller.PC})
+		expect(t, ok, caller.Func != nil)
+		if ok {
+			expect(t, offset, caller.PC-caller.Entry)
+		}
+	}
+
+	_, ok := frameOffset(Frame{Func: "main.handler"})
+	expect(t, ok, false)
+}
@@ -0,0 +1,51 @@
+package recovery
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// statsdTagReplacer drops the characters that delimit DogStatsD tags from tag values.
+var statsdTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+// StatsdHook dials the statsd daemon at the UDP address and returns a hook for OnPanic incrementing the given counter for every recovered panic. The route and panic_type tags, and any extra "name:value" tags, are sent in the DogStatsD format, which Datadog, Telegraf, and the statsd_exporter of Prometheus read:
+//
+//	myapp.panics:1|c|#route:GET /items/{id},panic_type:string,env:prod
+//
+// The route is the one of RoutePattern, or the http.ServeMux pattern, and is left out when unknown.
+func (r *Recovery) StatsdHook(addr, metric string, tags ...string) (func(req *http.Request, panicVal interface{}, stack []byte), error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(req *http.Request, panicVal interface{}, stack []byte) {
+		if _, err := conn.Write(r.statsdPacket(req, panicVal, metric, tags)); err != nil {
+			r.notef("Failed to send the panic metric: %v", err)
+		}
+	}, nil
+}
+
+// statsdPacket renders the counter increment of a panic.
+func (r *Recovery) statsdPacket(req *http.Request, panicVal interface{}, metric string, tags []string) []byte {
+	var b strings.Builder
+	b.WriteString(metric)
+	b.WriteString(":1|c|#")
+
+	if route := r.route(req); len(route) > 0 {
+		b.WriteString("route:")
+		b.WriteString(statsdTagReplacer.Replace(route))
+		b.WriteByte(',')
+	}
+	b.WriteString("panic_type:")
+	b.WriteString(statsdTagReplacer.Replace(fmt.Sprintf("%T", panicVal)))
+
+	for _, tag := range tags {
+		b.WriteByte(',')
+		b.WriteString(statsdTagReplacer.Replace(tag))
+	}
+
+	return []byte(b.String())
+}
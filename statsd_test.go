@@ -0,0 +1,55 @@
+package recovery
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatsdHook(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	r := New(Options{
+		Out:          bytes.NewBufferString(""),
+		RoutePattern: func(req *http.Request) string { return "GET /items/{id}" },
+	})
+	hook, err := r.StatsdHook(pc.LocalAddr().String(), "myapp.panics", "env:prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.OnPanic(hook)
+
+	req, _ := http.NewRequest("GET", "/items/42", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, string(buf[:n]), "myapp.panics:1|c|#route:GET /items/{id},panic_type:string,env:prod")
+}
+
+func TestStatsdPacket(t *testing.T) {
+	r := New(Options{})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	expect(t, string(r.statsdPacket(req, 42, "panics", nil)), "panics:1|c|#panic_type:int")
+
+	r = New(Options{RoutePattern: func(req *http.Request) string { return "/a,b|c#d" }})
+	expect(t, string(r.statsdPacket(req, "oops", "panics", []string{"team:a|b"})), "panics:1|c|#route:/a_b_c_d,panic_type:string,team:a_b")
+}
+
+func TestStatsdHookBadAddress(t *testing.T) {
+	hook, err := New(Options{}).StatsdHook("not an address", "panics")
+	expect(t, hook == nil, true)
+	expect(t, err != nil, true)
+}
@@ -0,0 +1,44 @@
+package recovery
+
+import (
+	"io"
+	"mime"
+	"net/http"
+)
+
+// StreamPolicy selects how a panic in the middle of a streamed response is handled.
+type StreamPolicy int
+
+const (
+	// StreamLogOnly leaves the stream as is and only logs the panic. This is the default.
+	StreamLogOnly StreamPolicy = iota
+	// StreamAbort closes the connection, so the client sees the stream break instead of a clean end.
+	StreamAbort
+	// StreamSSEError sends a final server-sent event of type "error" before the stream ends. Streams of any other Content-Type than text/event-stream are left as is, like with StreamLogOnly.
+	StreamSSEError
+)
+
+// endStream applies the StreamPolicy to a response that has already been flushed, and reports whether the connection should be aborted.
+func (r *Recovery) endStream(w *trackingWriter) bool {
+	switch r.opt.StreamPolicy {
+	case StreamAbort:
+		return true
+	case StreamSSEError:
+		if !isEventStream(w) {
+			r.notef("Response is not an event stream, skipping the error event")
+			break
+		}
+		io.WriteString(w, "event: error\ndata: "+http.StatusText(r.opt.ResponseStatus)+"\n\n")
+		w.flush()
+	default:
+		r.notef("Response is streaming, skipping the panic handler")
+	}
+
+	return false
+}
+
+// isEventStream reports whether the response sent to w is a server-sent event stream, which an error event can be appended to without corrupting it.
+func isEventStream(w http.ResponseWriter) bool {
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
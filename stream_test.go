@@ -0,0 +1,107 @@
+package recovery
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var streamingPanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Write([]byte("data: first\n\n"))
+	w.(http.Flusher).Flush()
+	panic("stream broke")
+})
+
+func TestStreamLogOnly(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	r.Handler(streamingPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "data: first\n\n")
+	expectContainsTrue(t, buf.String(), "Response is streaming, skipping the panic handler")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: stream broke")
+}
+
+func TestStreamSSEError(t *testing.T) {
+	r := New(Options{
+		Out:          ioutil.Discard,
+		StreamPolicy: StreamSSEError,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	r.Handler(streamingPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "data: first\n\nevent: error\ndata: Internal Server Error\n\n")
+}
+
+func TestStreamSSEErrorNotEventStream(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:          buf,
+		StreamPolicy: StreamSSEError,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export.ndjson", nil)
+	r.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte("{\"id\":1}\n"))
+		w.(http.Flusher).Flush()
+		panic("stream broke")
+	})).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "{\"id\":1}\n")
+	expectContainsTrue(t, buf.String(), "Response is not an event stream, skipping the error event")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: stream broke")
+}
+
+func TestStreamAbort(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out:          buf,
+		StreamPolicy: StreamAbort,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+
+	var escaped interface{}
+	func() {
+		defer func() {
+			escaped = recover()
+		}()
+		r.Handler(streamingPanicHandler).ServeHTTP(res, req)
+	}()
+
+	expect(t, escaped, interface{}(http.ErrAbortHandler))
+	expect(t, res.Body.String(), "data: first\n\n")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: stream broke")
+}
+
+func TestStreamPolicyIgnoredBeforeFlush(t *testing.T) {
+	r := New(Options{
+		Out:          ioutil.Discard,
+		StreamPolicy: StreamAbort,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+}
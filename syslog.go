@@ -0,0 +1,15 @@
+//go:build !windows && !plan9
+
+package recovery
+
+import "log/syslog"
+
+// SyslogSink dials the syslog daemon and returns a Sink that sends each panic record to it as one message, rendered in the given format. An empty network and raddr connect to the local daemon. The priority combines the facility and severity, like syslog.LOG_LOCAL0|syslog.LOG_ERR. Syslog stamps its own time, so FormatText records get no flags. The Out of the Sink is the *syslog.Writer, to close on shutdown.
+func SyslogSink(network, raddr string, priority syslog.Priority, tag string, format LogFormat) (Sink, error) {
+	w, err := syslog.Dial(network, raddr, priority, tag)
+	if err != nil {
+		return Sink{}, err
+	}
+
+	return Sink{Out: w, Format: format, OutputFlags: -1}, nil
+}
@@ -0,0 +1,55 @@
+//go:build !windows && !plan9
+
+package recovery
+
+import (
+	"log/syslog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen for syslog messages: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := SyslogSink("udp", conn.LocalAddr().String(), syslog.LOG_LOCAL0|syslog.LOG_ERR, "testapp", FormatLogfmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Out.(*syslog.Writer).Close()
+
+	r := New(Options{
+		Sinks: []Sink{sink},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(res, req)
+
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := string(buf[:n])
+
+	// LOG_LOCAL0 (16) * 8 + LOG_ERR (3).
+	expect(t, strings.HasPrefix(message, "<131>"), true)
+	expectContainsTrue(t, message, "testapp[")
+	expectContainsTrue(t, message, `message="Recovering from Panic" method=GET path=/foo panic="this did not work"`)
+}
+
+func TestSyslogSinkDialError(t *testing.T) {
+	_, err := SyslogSink("bogus", "127.0.0.1:0", syslog.LOG_ERR, "testapp", FormatText)
+	if err == nil {
+		t.Error("Expected a dial error")
+	}
+}
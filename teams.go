@@ -0,0 +1,131 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// teamsMessage is the payload of a Microsoft Teams webhook, holding one Adaptive Card.
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+// teamsElement is a TextBlock or a FactSet of a card.
+type teamsElement struct {
+	Type     string      `json:"type"`
+	Text     string      `json:"text,omitempty"`
+	Size     string      `json:"size,omitempty"`
+	Weight   string      `json:"weight,omitempty"`
+	Color    string      `json:"color,omitempty"`
+	Wrap     bool        `json:"wrap,omitempty"`
+	FontType string      `json:"fontType,omitempty"`
+	Facts    []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// teamsFormatter renders a panic as an Adaptive Card, at most once per throttle window and fingerprint.
+type teamsFormatter struct {
+	limiter *rateLimiter
+}
+
+func (f teamsFormatter) Format(rec *PanicRecord) []byte {
+	// The count is of the panics the card stands for: the ones held back since the last card, or the aggregated ones of a DedupWindow entry.
+	count := 1
+	if rec.Count > 0 {
+		count = rec.Count
+	}
+	if f.limiter != nil {
+		ok, suppressed := f.limiter.allow(rec.Fingerprint, rec.Time)
+		if !ok {
+			return nil
+		}
+		count += suppressed
+	}
+
+	route := rec.Route
+	if len(route) == 0 {
+		route = rec.Method + " " + rec.Path
+	}
+
+	facts := []teamsFact{
+		{"Route", route},
+		{"Count", strconv.Itoa(count)},
+		{"Severity", rec.Severity.String()},
+		{"Time", rec.Time.UTC().Format(time.RFC3339)},
+	}
+	for _, fact := range []teamsFact{
+		{"Request ID", rec.RequestID},
+		{"Origin", rec.Origin},
+		{"Fingerprint", rec.Fingerprint},
+	} {
+		if len(fact.Value) > 0 {
+			facts = append(facts, fact)
+		}
+	}
+
+	body := []teamsElement{
+		{Type: "TextBlock", Text: "Panic in " + route, Size: "Medium", Weight: "Bolder", Color: "Attention", Wrap: true},
+		{Type: "TextBlock", Text: rec.Panic, Wrap: true},
+		{Type: "FactSet", Facts: facts},
+	}
+	if frames := appFrames(rec.Frames, chatFrames); len(frames) > 0 {
+		var b strings.Builder
+		for _, frame := range frames {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Func, frame.File, frame.Line)
+		}
+		body = append(body, teamsElement{Type: "TextBlock", Text: b.String(), FontType: "Monospace", Wrap: true})
+	}
+
+	msg := teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+			},
+		}},
+	}
+
+	return jsonPayload(msg)
+}
+
+// TeamsOptions configures a TeamsSink.
+type TeamsOptions struct {
+	// Throttle is how often a fingerprint is posted at most. Default is 0 (every panic is posted).
+	Throttle time.Duration
+	// Client, if set, sends the posts, with its own timeout. Default is nil (a client with a 10 second timeout).
+	Client *http.Client
+}
+
+// TeamsSink returns a Sink that posts each panic to a Microsoft Teams webhook as an Adaptive Card, with the panic value, the route, the count, and the top application frames. The webhook is an incoming webhook or a Workflows one taking cards. A panic is posted at most once per Throttle window and fingerprint, the count of the next card including the panics held back. Every post happens as the panic is logged, so consider AsyncLogging.
+func TeamsSink(webhookURL string, opt TeamsOptions) Sink {
+	var f teamsFormatter
+	if opt.Throttle > 0 {
+		f.limiter = newRateLimiter(1, opt.Throttle)
+	}
+
+	return Sink{Out: newPostWriter(opt.Client, webhookURL, "application/json", "Teams"), Formatter: f}
+}
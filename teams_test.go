@@ -0,0 +1,78 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTeamsSink(t *testing.T) {
+	messages := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		messages <- b
+	}))
+	defer srv.Close()
+
+	r := New(Options{Sinks: []Sink{TeamsSink(srv.URL, TeamsOptions{Throttle: time.Minute, Client: srv.Client()})}})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	var msg teamsMessage
+	if err := json.Unmarshal(<-messages, &msg); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, msg.Type, "message")
+	expect(t, len(msg.Attachments), 1)
+	expect(t, msg.Attachments[0].ContentType, "application/vnd.microsoft.card.adaptive")
+
+	card := msg.Attachments[0].Content
+	expect(t, card.Type, "AdaptiveCard")
+	expect(t, card.Body[0].Text, "Panic in GET /foo")
+	expect(t, card.Body[1].Text, "this did not work")
+	expect(t, card.Body[2].Facts[0], teamsFact{"Route", "GET /foo"})
+	expect(t, card.Body[2].Facts[1], teamsFact{"Count", "1"})
+	expectContainsTrue(t, card.Body[3].Text, "recovery_test.go")
+
+	// The same panic again is throttled.
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	select {
+	case b := <-messages:
+		t.Errorf("Expected the second panic to be throttled - Got %s", b)
+	default:
+	}
+}
+
+func TestTeamsThrottle(t *testing.T) {
+	f := TeamsSink("", TeamsOptions{Throttle: time.Minute}).Formatter
+	now := time.Now()
+
+	rec := &PanicRecord{PanicInfo: &PanicInfo{Time: now, Fingerprint: "abc"}, Route: "/items/{id}", Panic: "oops"}
+	expect(t, len(f.Format(rec)) > 0, true)
+	expect(t, len(f.Format(rec)), 0)
+	expect(t, len(f.Format(rec)), 0)
+
+	// The count of the next card includes the panics held back.
+	rec.Time = now.Add(time.Minute)
+	var msg teamsMessage
+	if err := json.Unmarshal(f.Format(rec), &msg); err != nil {
+		t.Fatal(err)
+	}
+	facts := msg.Attachments[0].Content.Body[2].Facts
+	expect(t, facts[0], teamsFact{"Route", "/items/{id}"})
+	expect(t, facts[1], teamsFact{"Count", "3"})
+}
+
+func TestTeamsDedupCount(t *testing.T) {
+	f := TeamsSink("", TeamsOptions{}).Formatter
+
+	var msg teamsMessage
+	if err := json.Unmarshal(f.Format(&PanicRecord{PanicInfo: &PanicInfo{}, Panic: "oops", Count: 7}), &msg); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, msg.Attachments[0].Content.Body[2].Facts[1], teamsFact{"Count", "7"})
+}
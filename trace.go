@@ -0,0 +1,31 @@
+package recovery
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// traceContext returns the trace and span ids of a request, from the W3C traceparent header or else the X-Cloud-Trace-Context header of Google Cloud. The ids are blank when neither is there.
+func traceContext(req *http.Request) (traceID, spanID string, sampled bool) {
+	// traceparent: version-traceid-spanid-flags.
+	if parts := strings.Split(req.Header.Get("Traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 && len(parts[2]) == 16 {
+		flags, err := strconv.ParseUint(parts[3], 16, 8)
+		return parts[1], parts[2], err == nil && flags&1 == 1
+	}
+
+	// X-Cloud-Trace-Context: traceid/spanid;o=1, with the span id in decimal.
+	header := req.Header.Get("X-Cloud-Trace-Context")
+	if len(header) == 0 {
+		return "", "", false
+	}
+
+	header, options, _ := strings.Cut(header, ";")
+	traceID, span, _ := strings.Cut(header, "/")
+	if n, err := strconv.ParseUint(span, 10, 64); err == nil {
+		spanID = strconv.FormatUint(n, 16)
+		spanID = strings.Repeat("0", 16-len(spanID)) + spanID
+	}
+
+	return traceID, spanID, options == "o=1"
+}
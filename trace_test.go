@@ -0,0 +1,58 @@
+package recovery
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceContext(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	traceID, spanID, sampled := traceContext(req)
+	expect(t, traceID, "")
+	expect(t, spanID, "")
+	expect(t, sampled, false)
+
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/255")
+	traceID, spanID, sampled = traceContext(req)
+	expect(t, traceID, "105445aa7843bc8bf206b12000100000")
+	expect(t, spanID, "00000000000000ff")
+	expect(t, sampled, false)
+
+	// The W3C header wins.
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	traceID, spanID, sampled = traceContext(req)
+	expect(t, traceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	expect(t, spanID, "00f067aa0ba902b7")
+	expect(t, sampled, true)
+}
+
+func TestTraceFunc(t *testing.T) {
+	buf := bytes.NewBufferString("")
+	r := New(Options{
+		Out:    buf,
+		Format: FormatJSON,
+		TraceFunc: func(req *http.Request) (string, string, bool) {
+			if req.URL.Path == "/untraced" {
+				return "", "", false
+			}
+			return "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", true
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), `"trace_id":"0af7651916cd43dd8448eb211c80319c"`)
+	expectContainsTrue(t, buf.String(), `"span_id":"b7ad6b7169203331"`)
+
+	// A blank trace id falls back to the headers.
+	buf.Reset()
+	req, _ = http.NewRequest("GET", "/untraced", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+}
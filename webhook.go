@@ -0,0 +1,64 @@
+package recovery
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// WebhookOptions configures a WebhookSink.
+type WebhookOptions struct {
+	// Header holds extra request headers, like an Authorization token. Default is nil.
+	Header http.Header
+	// Timeout bounds every attempt of a post, when no Client is set. Default is 10 seconds.
+	Timeout time.Duration
+	// Retries is how many times a post failing with a network error, a 429, or a 5xx is tried again. Other responses are not retried. Default is 0.
+	Retries int
+	// RetryDelay is the wait before the first retry, doubled for each retry after. Default is 1 second.
+	RetryDelay time.Duration
+	// Secret, if set, signs every body with HMAC-SHA256, sent hex encoded as "sha256=<signature>" in the SignatureHeader, so the receiver can check where the record comes from. Default is nil (no signature).
+	Secret []byte
+	// SignatureHeader is the header holding the signature. Default is "X-Recovery-Signature".
+	SignatureHeader string
+	// Client, if set, sends the posts, with its own timeout. Default is nil.
+	Client *http.Client
+}
+
+// WebhookSink returns a Sink that posts each panic record as FormatJSON to an arbitrary URL, for incident tooling that has no dedicated integration. The first attempt of every post happens as the panic is logged, so consider AsyncLogging; retries run in order on a goroutine of the sink, and a post whose retries all fail is noted in the log. Flush and Close wait for the retries.
+func WebhookSink(url string, opt WebhookOptions) Sink {
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	if opt.RetryDelay <= 0 {
+		opt.RetryDelay = time.Second
+	}
+	if len(opt.SignatureHeader) == 0 {
+		opt.SignatureHeader = "X-Recovery-Signature"
+	}
+	client := opt.Client
+	if client == nil {
+		client = &http.Client{Timeout: opt.Timeout}
+	}
+
+	w := newPostWriter(client, url, "application/json", "webhook")
+	for name, values := range opt.Header {
+		w.header[http.CanonicalHeaderKey(name)] = values
+	}
+	w.retries, w.retryDelay = opt.Retries, opt.RetryDelay
+	if len(opt.Secret) > 0 {
+		w.sign = func(body []byte, header http.Header) {
+			header.Set(opt.SignatureHeader, "sha256="+webhookSignature(opt.Secret, body))
+		}
+	}
+
+	return Sink{Out: w, Format: FormatJSON}
+}
+
+// webhookSignature returns the hex encoded HMAC-SHA256 of a body.
+func webhookSignature(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,170 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var header http.Header
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header
+		b, _ := io.ReadAll(req.Body)
+		bodies <- b
+	}))
+	defer srv.Close()
+
+	r := New(Options{
+		Sinks: []Sink{WebhookSink(srv.URL, WebhookOptions{
+			Header: http.Header{"authorization": {"Bearer abc"}},
+			Secret: []byte("s3cret"),
+		})},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	body := <-bodies
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, doc["panic"], "this did not work")
+	expect(t, doc["path"], "/foo")
+
+	expect(t, header.Get("Content-Type"), "application/json")
+	expect(t, header.Get("Authorization"), "Bearer abc")
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	expect(t, header.Get("X-Recovery-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestWebhookRetries(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	w := WebhookSink(srv.URL, WebhookOptions{Retries: 2, RetryDelay: time.Millisecond}).Out.(*postWriter)
+	defer w.close()
+
+	// The first attempt failed, and the retries are queued.
+	_, err := w.Write([]byte("{}\n"))
+	expect(t, err, nil)
+
+	w.flush()
+	expect(t, atomic.LoadInt32(&attempts), int32(3))
+}
+
+func TestWebhookRetriesExhausted(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	w := WebhookSink(srv.URL, WebhookOptions{Retries: 2, RetryDelay: time.Millisecond}).Out.(*postWriter)
+	defer w.close()
+
+	var notes []string
+	w.notef = func(format string, v ...interface{}) { notes = append(notes, fmt.Sprintf(format, v...)) }
+
+	_, err := w.Write([]byte("{}\n"))
+	expect(t, err, nil)
+
+	w.flush()
+	expect(t, atomic.LoadInt32(&attempts), int32(3))
+	expect(t, len(notes), 1)
+	expect(t, notes[0], "Error writing panic record: recovery: webhook endpoint returned 429 Too Many Requests")
+}
+
+func TestWebhookRetriesOutsideLock(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	out := bytes.NewBufferString("")
+	r := New(Options{
+		Out:   out,
+		Sinks: []Sink{{Format: FormatJSON}, WebhookSink(srv.URL, WebhookOptions{Retries: 2, RetryDelay: 100 * time.Millisecond})},
+	})
+	defer r.Close()
+
+	// The backoff does not hold up the request, nor the records of other panics.
+	start := time.Now()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+	expect(t, time.Since(start) < 100*time.Millisecond, true)
+
+	// Once every retry failed, the post is noted in the log.
+	r.Flush()
+	expect(t, atomic.LoadInt32(&attempts), int32(6))
+	expect(t, strings.Count(out.String(), "Error writing panic record: recovery: webhook endpoint returned 503 Service Unavailable"), 2)
+}
+
+func TestWebhookFailureNoted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	out := bytes.NewBufferString("")
+	r := New(Options{
+		Out:   out,
+		Sinks: []Sink{{Format: FormatJSON}, WebhookSink(srv.URL, WebhookOptions{})},
+	})
+
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(myPanicHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	expectContainsTrue(t, out.String(), `"panic":"this did not work"`)
+	expectContainsTrue(t, out.String(), "Error writing panic record: recovery: webhook endpoint returned 400 Bad Request")
+}
+
+func TestWebhookNoRetryOnClientError(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	w := WebhookSink(srv.URL, WebhookOptions{Retries: 2, RetryDelay: time.Millisecond}).Out
+
+	_, err := w.Write([]byte("{}\n"))
+	expect(t, err.Error(), "recovery: webhook endpoint returned 400 Bad Request")
+	expect(t, attempts, 1)
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	w := WebhookSink(srv.URL, WebhookOptions{Timeout: 10 * time.Millisecond}).Out
+
+	_, err := w.Write([]byte("{}\n"))
+	expect(t, err != nil, true)
+}
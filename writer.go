@@ -0,0 +1,245 @@
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+)
+
+// trackingWriter records whether a response has been committed, meaning the status line went out and nothing can be written over it any more, and with which status. Handlers get it through wrap, so it only has the optional interfaces of the writer it wraps.
+type trackingWriter struct {
+	http.ResponseWriter
+	committed bool
+	streaming bool
+	status    int
+}
+
+// commit marks the response as committed with the given status, unless it already was.
+func (tw *trackingWriter) commit(status int) {
+	if !tw.committed {
+		tw.status = status
+	}
+	tw.committed = true
+}
+
+func (tw *trackingWriter) WriteHeader(status int) {
+	// Informational responses can be followed by the real one.
+	if status >= 200 {
+		tw.commit(status)
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *trackingWriter) Write(b []byte) (int, error) {
+	tw.commit(http.StatusOK)
+	return tw.ResponseWriter.Write(b)
+}
+
+// flush flushes the underlying writer when it is an http.Flusher.
+func (tw *trackingWriter) flush() {
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		tw.commit(http.StatusOK)
+		tw.streaming = true
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (tw *trackingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// trackedFlusher implements http.Flusher for a trackingWriter.
+type trackedFlusher struct{ tw *trackingWriter }
+
+func (f trackedFlusher) Flush() {
+	f.tw.flush()
+}
+
+// trackedHijacker implements http.Hijacker for a trackingWriter. A hijacked connection counts as committed.
+type trackedHijacker struct{ tw *trackingWriter }
+
+func (h trackedHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.tw.committed = true
+	return h.tw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// trackedPusher implements http.Pusher for a trackingWriter.
+type trackedPusher struct{ tw *trackingWriter }
+
+func (p trackedPusher) Push(target string, opts *http.PushOptions) error {
+	return p.tw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// trackedReaderFrom implements io.ReaderFrom for a trackingWriter, so io.Copy keeps using sendfile on the connection.
+type trackedReaderFrom struct{ tw *trackingWriter }
+
+func (rf trackedReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	rf.tw.commit(http.StatusOK)
+	return rf.tw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
+
+// wrap returns tw with those of http.Flusher, http.Hijacker, http.Pusher, and io.ReaderFrom the underlying writer implements, and no other, so handlers checking for one see what the connection can do.
+func (tw *trackingWriter) wrap() http.ResponseWriter {
+	var kind int
+	if _, ok := tw.ResponseWriter.(http.Flusher); ok {
+		kind |= 1
+	}
+	if _, ok := tw.ResponseWriter.(http.Hijacker); ok {
+		kind |= 2
+	}
+	if _, ok := tw.ResponseWriter.(http.Pusher); ok {
+		kind |= 4
+	}
+	if _, ok := tw.ResponseWriter.(io.ReaderFrom); ok {
+		kind |= 8
+	}
+
+	f, h, p, rf := trackedFlusher{tw}, trackedHijacker{tw}, trackedPusher{tw}, trackedReaderFrom{tw}
+	switch kind {
+	case 1:
+		return struct {
+			*trackingWriter
+			http.Flusher
+		}{tw, f}
+	case 2:
+		return struct {
+			*trackingWriter
+			http.Hijacker
+		}{tw, h}
+	case 3:
+		return struct {
+			*trackingWriter
+			http.Flusher
+			http.Hijacker
+		}{tw, f, h}
+	case 4:
+		return struct {
+			*trackingWriter
+			http.Pusher
+		}{tw, p}
+	case 5:
+		return struct {
+			*trackingWriter
+			http.Flusher
+			http.Pusher
+		}{tw, f, p}
+	case 6:
+		return struct {
+			*trackingWriter
+			http.Hijacker
+			http.Pusher
+		}{tw, h, p}
+	case 7:
+		return struct {
+			*trackingWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{tw, f, h, p}
+	case 8:
+		return struct {
+			*trackingWriter
+			io.ReaderFrom
+		}{tw, rf}
+	case 9:
+		return struct {
+			*trackingWriter
+			http.Flusher
+			io.ReaderFrom
+		}{tw, f, rf}
+	case 10:
+		return struct {
+			*trackingWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{tw, h, rf}
+	case 11:
+		return struct {
+			*trackingWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{tw, f, h, rf}
+	case 12:
+		return struct {
+			*trackingWriter
+			http.Pusher
+			io.ReaderFrom
+		}{tw, p, rf}
+	case 13:
+		return struct {
+			*trackingWriter
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{tw, f, p, rf}
+	case 14:
+		return struct {
+			*trackingWriter
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{tw, h, p, rf}
+	case 15:
+		return struct {
+			*trackingWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{tw, f, h, p, rf}
+	}
+
+	return tw
+}
+
+// bufferedWriter holds a complete response in memory until it is known to be safe to send.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedWriter() *bufferedWriter {
+	return &bufferedWriter{header: http.Header{}}
+}
+
+func (bw *bufferedWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedWriter) WriteHeader(status int) {
+	if bw.status == 0 {
+		bw.status = status
+	}
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	return bw.body.Write(b)
+}
+
+// reset discards everything written so far.
+func (bw *bufferedWriter) reset() {
+	bw.header = http.Header{}
+	bw.status = 0
+	bw.body.Reset()
+}
+
+// writeTo sends the buffered response to w.
+func (bw *bufferedWriter) writeTo(w http.ResponseWriter) {
+	dst := w.Header()
+	for name, values := range bw.header {
+		dst[name] = values
+	}
+
+	status := bw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	w.Write(bw.body.Bytes())
+}
@@ -0,0 +1,227 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var halfWrittenHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"items": [1, 2, `))
+	panic("ran out of items")
+})
+
+func TestBufferResponseDiscardsPartialOutput(t *testing.T) {
+	r := New(Options{
+		Out:            ioutil.Discard,
+		BufferResponse: true,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(halfWrittenHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusInternalServerError))
+}
+
+func TestBufferResponseSendsSuccessfulOutput(t *testing.T) {
+	r := New(Options{
+		Out:            ioutil.Discard,
+		BufferResponse: true,
+	})
+
+	createdHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/items/1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/items", nil)
+	r.Handler(createdHandler).ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusCreated)
+	expect(t, res.Header().Get("Location"), "/items/1")
+	expect(t, res.Body.String(), "created")
+
+	// Implicit 200.
+	res = httptest.NewRecorder()
+	r.Handler(myHandler).ServeHTTP(res, req)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "bar")
+}
+
+func TestWithoutBufferResponse(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(halfWrittenHandler).ServeHTTP(res, req)
+
+	// The partial body already went out.
+	expectContainsTrue(t, res.Body.String(), `{"items": [1, 2, `)
+}
+
+func TestCommittedResponseSkipsPanicHandler(t *testing.T) {
+	buf := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: buf,
+	})
+
+	called := false
+	r.SetPanicHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	}))
+
+	committedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("too late")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(committedHandler).ServeHTTP(res, req)
+
+	expect(t, called, false)
+	expect(t, res.Code, http.StatusAccepted)
+	expect(t, res.Body.String(), "partial")
+	expectContainsTrue(t, buf.String(), "Response already committed, skipping the panic handler")
+	expectContainsTrue(t, buf.String(), "Recovering from Panic: too late")
+}
+
+func TestCommittedResponseNoSuperfluousWriteHeader(t *testing.T) {
+	serverLog := bytes.NewBufferString("")
+
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	committedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("too late")
+	})
+
+	server := httptest.NewUnstartedServer(r.Handler(committedHandler))
+	server.Config.ErrorLog = log.New(serverLog, "", 0)
+	server.Start()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	server.Close()
+
+	expect(t, res.StatusCode, http.StatusOK)
+	expectContainsFalse(t, serverLog.String(), "superfluous")
+}
+
+func TestInformationalResponseIsNotCommitted(t *testing.T) {
+	r := New(Options{
+		Out: ioutil.Discard,
+	})
+
+	earlyHintsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		panic("after hints")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/foo", nil)
+	r.Handler(earlyHintsHandler).ServeHTTP(res, req)
+
+	expect(t, strings.TrimSpace(res.Body.String()), http.StatusText(http.StatusInternalServerError))
+}
+
+// readerFromRecorder is a ResponseRecorder that is an io.ReaderFrom, like the writer of an HTTP/1 connection.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (rf *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	rf.readFrom = true
+	return io.Copy(rf.ResponseRecorder, src)
+}
+
+func TestTrackingWriterInterfaces(t *testing.T) {
+	res := httptest.NewRecorder()
+	tw := &trackingWriter{ResponseWriter: res}
+
+	// A ResponseRecorder is only an http.Flusher.
+	w := tw.wrap()
+	f, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("Expected the wrapped writer to implement http.Flusher")
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		t.Error("Expected the wrapped writer not to implement http.Hijacker")
+	}
+	if _, ok := w.(http.Pusher); ok {
+		t.Error("Expected the wrapped writer not to implement http.Pusher")
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		t.Error("Expected the wrapped writer not to implement io.ReaderFrom")
+	}
+
+	f.Flush()
+	expect(t, tw.committed, true)
+	expect(t, tw.streaming, true)
+	expect(t, res.Flushed, true)
+	expect(t, w.(interface{ Unwrap() http.ResponseWriter }).Unwrap(), http.ResponseWriter(res))
+
+	// Without an http.Flusher, http.ResponseController finds none either.
+	tw = &trackingWriter{ResponseWriter: struct{ http.ResponseWriter }{res}}
+	if err := http.NewResponseController(tw.wrap()).Flush(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Expected http.ErrNotSupported - Got %v", err)
+	}
+}
+
+func TestTrackingWriterReadFrom(t *testing.T) {
+	rf := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	tw := &trackingWriter{ResponseWriter: rf}
+
+	w := tw.wrap()
+	if _, ok := w.(io.ReaderFrom); !ok {
+		t.Fatal("Expected the wrapped writer to implement io.ReaderFrom")
+	}
+	io.Copy(w, struct{ io.Reader }{strings.NewReader("file contents")})
+
+	expect(t, rf.readFrom, true)
+	expect(t, tw.committed, true)
+	expect(t, tw.status, http.StatusOK)
+	expect(t, rf.Body.String(), "file contents")
+}
+
+func TestTrackingWriterHijack(t *testing.T) {
+	var hijacked bool
+	srv := httptest.NewServer(New(Options{Out: ioutil.Discard}).Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, hijacked = w.(http.Hijacker)
+		_, pushes := w.(http.Pusher)
+		if pushes {
+			t.Error("Expected the writer of an HTTP/1 connection not to implement http.Pusher")
+		}
+	})))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	expect(t, hijacked, true)
+}